@@ -0,0 +1,60 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// Existing transactions land on a per-user default account, and running the
+// backfill again changes nothing.
+func TestBackfillDefaultAccounts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "accounts.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}, &models.Account{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, e := db.DB(); e == nil {
+			sqlDB.Close()
+		}
+	})
+	DB = db
+
+	alice := models.User{Username: "alice", Password: "x"}
+	bob := models.User{Username: "bob", Password: "x"}
+	db.Create(&alice)
+	db.Create(&bob)
+	for _, u := range []models.User{alice, bob} {
+		cat := models.Category{UserID: u.ID, Name: "Food"}
+		db.Create(&cat)
+		db.Create(&models.Transaction{UserID: u.ID, CategoryID: cat.ID, Amount: 10, Type: "expense", Date: time.Now()})
+	}
+
+	backfillDefaultAccounts()
+	backfillDefaultAccounts()
+
+	var accounts []models.Account
+	db.Order("user_id").Find(&accounts)
+	if len(accounts) != 2 || !accounts[0].IsDefault || accounts[0].UserID != alice.ID || accounts[1].UserID != bob.ID {
+		t.Fatalf("want one default account per user, got %+v", accounts)
+	}
+	var txs []models.Transaction
+	db.Find(&txs)
+	for _, tx := range txs {
+		want := accounts[0].ID
+		if tx.UserID == bob.ID {
+			want = accounts[1].ID
+		}
+		if tx.AccountID == nil || *tx.AccountID != want {
+			t.Errorf("tx %d of user %d: want account %d, got %v", tx.ID, tx.UserID, want, tx.AccountID)
+		}
+	}
+}
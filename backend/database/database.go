@@ -33,7 +33,7 @@ func Connect() {
 
 	log.Println("Database connected successfully")
 
-	err = DB.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}, &models.SalaryCycle{}, &models.FixedExpense{}, &models.SalaryCycleAudit{})
+	err = DB.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}, &models.SalaryCycle{}, &models.FixedExpense{}, &models.SalaryCycleAudit{}, &models.RecurringTransaction{}, &models.TransactionSplit{}, &models.Tag{}, &models.CategoryBudget{}, &models.Envelope{}, &models.EnvelopeTransfer{}, &models.SavingsGoal{}, &models.ExchangeRate{}, &models.Account{}, &models.Reconciliation{}, &models.CategoryRule{}, &models.IdempotencyKey{})
 	if err != nil {
 		log.Fatalf("Failed to run database migration: %v", err)
 	}
@@ -50,6 +50,15 @@ func Connect() {
 	// the router starts serving requests.
 	widenTransactionTypeColumn()
 
+	// A bank's FITID is unique per account statement; the partial unique index
+	// makes two concurrent imports of the same file unable to double-insert.
+	ensureFITIDIndex()
+	ensureRecurringInstanceIndex()
+
+	// Every user gets a default account, and transactions recorded before
+	// accounts existed move onto it.
+	backfillDefaultAccounts()
+
 	// One-time normalization: ensure all existing usernames are lowercase.
 	if res := DB.Exec("UPDATE users SET username = LOWER(username) WHERE username != LOWER(username)"); res.Error != nil {
 		log.Printf("Warning: username normalization failed: %v", res.Error)
@@ -115,6 +124,65 @@ func widenTransactionTypeColumn() {
 	log.Println("Schema: transactions.type ensured at varchar(30)")
 }
 
+// ensureFITIDIndex creates the (user_id, account, fitid) unique index used by
+// OFX/QFX import de-duplication. A FITID is only unique within one bank
+// account, so two accounts may share one. It is partial — rows without a FITID
+// (manual entries, CSV imports) all carry an empty FITID and must not collide.
+// Both SQLite and Postgres support partial and expression indexes, and IF NOT
+// EXISTS makes it safe on every startup. The older per-user index is dropped.
+func ensureFITIDIndex() {
+	if res := DB.Exec(`DROP INDEX IF EXISTS idx_transactions_user_fitid`); res.Error != nil {
+		log.Printf("Warning: ensureFITIDIndex: dropping the per-user index failed: %v", res.Error)
+	}
+	const sql = `CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_account_fitid ON transactions (user_id, COALESCE(account_id, 0), fitid) WHERE fitid <> ''`
+	if res := DB.Exec(sql); res.Error != nil {
+		log.Printf("Warning: ensureFITIDIndex failed: %v", res.Error)
+	}
+}
+
+// ensureRecurringInstanceIndex makes (recurring_id, date) unique so a
+// recurring template can never post the same occurrence twice, even if two
+// scheduler runs race. Partial, because manual transactions have no template.
+func ensureRecurringInstanceIndex() {
+	const sql = `CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_recurring_date ON transactions (recurring_id, date) WHERE recurring_id IS NOT NULL`
+	if res := DB.Exec(sql); res.Error != nil {
+		log.Printf("Warning: ensureRecurringInstanceIndex failed: %v", res.Error)
+	}
+}
+
+// backfillDefaultAccounts creates a default account for every user without
+// one and assigns it every transaction that has no account yet. Handlers
+// treat a NULL account_id as the default account anyway; the backfill makes
+// it explicit for existing rows. Idempotent.
+func backfillDefaultAccounts() {
+	const createSQL = `
+		INSERT INTO accounts (user_id, name, type, opening_balance, currency, archived, is_default, created_at, updated_at)
+		SELECT u.id, 'Main account', 'checking', 0, '', FALSE, TRUE, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM users u
+		WHERE NOT EXISTS (SELECT 1 FROM accounts a WHERE a.user_id = u.id AND a.is_default = TRUE)`
+	if res := DB.Exec(createSQL); res.Error != nil {
+		log.Printf("Warning: default account backfill failed: %v", res.Error)
+		return
+	} else if res.RowsAffected > 0 {
+		log.Printf("default account backfill: created %d account(s)", res.RowsAffected)
+	}
+
+	const assignSQL = `
+		UPDATE transactions
+		SET account_id = (
+			SELECT a.id FROM accounts a
+			WHERE a.user_id = transactions.user_id AND a.is_default = TRUE
+			ORDER BY a.id
+			LIMIT 1
+		)
+		WHERE account_id IS NULL`
+	if res := DB.Exec(assignSQL); res.Error != nil {
+		log.Printf("Warning: transaction account backfill failed: %v", res.Error)
+	} else if res.RowsAffected > 0 {
+		log.Printf("transaction account backfill: updated %d transaction(s)", res.RowsAffected)
+	}
+}
+
 // backfillFixedExpCategory sets salary_cycles.fixed_exp_category_id for rows
 // where it is still 0, matching each cycle's user to their Fixed Payments
 // category by any of the four localized names.
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"database/sql/driver"
+	"strings"
+
+	sqlitedriver "github.com/glebarez/go-sqlite"
+)
+
+// SQLite's built-in LOWER() only folds ASCII, so a case-insensitive search for
+// "молоко" would miss "Молоко" locally while Postgres (production) finds it.
+// Overriding LOWER with a Unicode-aware version keeps every
+// `LOWER(col) LIKE ?` query behaving identically on both dialects. Registered
+// at package init so it applies to every SQLite connection the process opens,
+// including the per-test databases.
+func init() {
+	sqlitedriver.MustRegisterDeterministicScalarFunction("lower", 1,
+		func(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+			switch v := args[0].(type) {
+			case string:
+				return strings.ToLower(v), nil
+			case []byte:
+				return strings.ToLower(string(v)), nil
+			default:
+				return v, nil // NULL and numbers pass through like the built-in
+			}
+		})
+}
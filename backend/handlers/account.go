@@ -0,0 +1,676 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Accounts ─────────────────────────────────────────────────────────────────
+//
+// Every transaction sits in an account (cash, checking, credit card…). Every
+// write stamps one, automatic postings (salary, recurring templates) the
+// default account. A transaction without account_id — written before accounts
+// existed — belongs to the user's default account until the default moves,
+// which pins such rows to the old default first.
+//
+// Moving money between accounts is a transfer: a linked "transfer_out" /
+// "transfer_in" pair in a built-in "Transfers" category. Neither leg is income
+// or expense, so no total, budget or cycle figure sees them; only account
+// balances do. Savings-pool entries earmark money without moving it between
+// accounts and leave balances alone.
+
+const (
+	txTypeTransferOut = "transfer_out"
+	txTypeTransferIn  = "transfer_in"
+)
+
+var accountTypes = map[string]bool{"cash": true, "checking": true, "savings": true, "credit_card": true, "other": true}
+
+// defaultAccountName is used when a user's default account is created.
+const defaultAccountName = "Main account"
+
+// isTransferType reports whether t is one leg of a transfer.
+func isTransferType(t string) bool {
+	return t == txTypeTransferOut || t == txTypeTransferIn
+}
+
+// accountTxSign is +1 for money into an account, -1 for money out and 0 for
+// savings-pool entries, which move nothing between accounts.
+func accountTxSign(txType string) float64 {
+	switch txType {
+	case "income", txTypeTransferIn, txTypeRefund:
+		return 1
+	case "expense", txTypeTransferOut:
+		return -1
+	}
+	return 0
+}
+
+// defaultAccount returns the user's default account, creating it for users
+// who registered after the startup backfill.
+func defaultAccount(db *gorm.DB, uid uint) (models.Account, error) {
+	var a models.Account
+	err := db.Where("user_id = ? AND is_default = ?", uid, true).Order("id").First(&a).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return a, err
+	}
+	a = models.Account{UserID: uid, Name: defaultAccountName, Type: "checking", IsDefault: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	return a, db.Create(&a).Error
+}
+
+// resolveTxAccount picks the account a new or moved transaction goes to: the
+// given one if it is the user's and not archived, the default account for
+// nil. The error is safe to send to the client with the returned status.
+func resolveTxAccount(uid uint, id *uint) (models.Account, int, error) {
+	if id == nil || *id == 0 {
+		a, err := defaultAccount(database.DB, uid)
+		if err != nil {
+			log.Printf("resolve account: default user=%v err=%v", uid, err)
+			return a, http.StatusInternalServerError, errors.New("Failed to load the default account")
+		}
+		return a, http.StatusOK, nil
+	}
+	var a models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", *id, uid).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return a, http.StatusNotFound, errors.New("Account not found or does not belong to you")
+		}
+		log.Printf("resolve account: user=%v account=%v err=%v", uid, *id, err)
+		return a, http.StatusInternalServerError, errors.New("Failed to verify account")
+	}
+	if a.Archived {
+		return a, http.StatusConflict, errors.New("The account is archived")
+	}
+	return a, http.StatusOK, nil
+}
+
+// ensureTransferCategory returns the built-in category transfer legs are
+// filed under. It is bucketed "ignore" so budget frameworks skip it too.
+func ensureTransferCategory(db *gorm.DB, uid uint) (uint, error) {
+	var cat models.Category
+	err := db.Where("user_id = ? AND translation_key = ?", uid, "category.transfers").First(&cat).Error
+	if err == nil {
+		return cat.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	cat = models.Category{
+		UserID: uid, Name: "Transfers", TranslationKey: "category.transfers", Bucket: bucketIgnore,
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	return cat.ID, db.Create(&cat).Error
+}
+
+// withTransferPeers adds the other leg of every transfer among ids, trashed
+// or not, so both legs are always deleted, restored or purged together.
+func withTransferPeers(db *gorm.DB, uid uint, ids []uint) ([]uint, error) {
+	var peers []uint
+	if err := db.Unscoped().Model(&models.Transaction{}).
+		Where("user_id = ? AND id IN ? AND transfer_peer_id IS NOT NULL", uid, ids).
+		Pluck("transfer_peer_id", &peers).Error; err != nil {
+		return nil, err
+	}
+	return uniqueUints(append(ids, peers...)), nil
+}
+
+// accountBalance is one row of GET /accounts/balances.
+type accountBalance struct {
+	models.Account
+	Balance      float64 `json:"balance"`
+	Transactions int     `json:"transactions"`
+	// MissingRates lists conversions into the account's currency that have no
+	// rate; those transactions are left out of Balance.
+	MissingRates []missingRate `json:"missing_rates,omitempty"`
+}
+
+// accountAmount is what t moved in account currency accCur: what was paid
+// when the currencies match, otherwise its base-currency Amount converted at
+// the rate on its date.
+func accountAmount(db *gorm.DB, uid uint, t models.Transaction, accCur, base string) (float64, *missingRate, error) {
+	if t.Currency != "" && t.Currency == accCur {
+		return txOriginalAmount(t), nil, nil
+	}
+	if accCur == base {
+		return t.Amount, nil, nil
+	}
+	rate, ok, err := fxRateOn(db, uid, base, accCur, t.Date)
+	if err != nil || !ok {
+		if err == nil {
+			return 0, &missingRate{From: base, To: accCur, Date: toDateOnly(t.Date).Format("2006-01-02")}, nil
+		}
+		return 0, nil, err
+	}
+	return round2(t.Amount * rate), nil, nil
+}
+
+// computeAccountBalances runs every account's balance up to and including
+// calendar date asOf: its opening balance plus each live transaction dated
+// on or before that day.
+func computeAccountBalances(db *gorm.DB, uid uint, asOf time.Time, includeArchived bool) ([]accountBalance, error) {
+	def, err := defaultAccount(db, uid)
+	if err != nil {
+		return nil, err
+	}
+	q := db.Where("user_id = ?", uid)
+	if !includeArchived {
+		q = q.Where("archived = ?", false)
+	}
+	var accounts []models.Account
+	if err := q.Order("is_default DESC").Order("name").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	var txs []models.Transaction
+	if err := db.Where("user_id = ? AND date < ?", uid, toDateOnly(asOf).AddDate(0, 0, 1)).
+		Order("date").Order("id").Find(&txs).Error; err != nil {
+		return nil, err
+	}
+
+	base := userBaseCurrency(db, uid)
+	out := make([]accountBalance, len(accounts))
+	index := make(map[uint]int, len(accounts))
+	for i, a := range accounts {
+		out[i] = accountBalance{Account: a, Balance: a.OpeningBalance}
+		index[a.ID] = i
+	}
+	for _, t := range txs {
+		accID := def.ID
+		if t.AccountID != nil {
+			accID = *t.AccountID
+		}
+		i, ok := index[accID]
+		sign := accountTxSign(t.Type)
+		if !ok || sign == 0 {
+			continue
+		}
+		row := &out[i]
+		accCur := row.Currency
+		if accCur == "" {
+			accCur = base
+		}
+		amount, missing, err := accountAmount(db, uid, t, accCur, base)
+		if err != nil {
+			return nil, err
+		}
+		if missing != nil {
+			row.MissingRates = append(row.MissingRates, *missing)
+			continue
+		}
+		row.Balance += sign * amount
+		row.Transactions++
+	}
+	for i := range out {
+		out[i].Balance = round2(out[i].Balance)
+	}
+	return out, nil
+}
+
+// GetAccounts → GET /api/accounts
+// Lists the user's accounts, default first; archived ones only with
+// include_archived=true.
+func GetAccounts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	if _, err := defaultAccount(database.DB, uid); err != nil {
+		log.Printf("get accounts: default user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	q := database.DB.Where("user_id = ?", uid)
+	if c.Query("include_archived") != "true" {
+		q = q.Where("archived = ?", false)
+	}
+	var accounts []models.Account
+	if err := q.Order("is_default DESC").Order("name").Find(&accounts).Error; err != nil {
+		log.Printf("get accounts: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// GetAccountBalances → GET /api/accounts/balances?as_of=YYYY-MM-DD
+// Each account's balance at the end of as_of (default: the user's today).
+func GetAccountBalances(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	asOf := localDate(time.Now(), userLocation(uid))
+	if s := c.Query("as_of"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of format. Use YYYY-MM-DD"})
+			return
+		}
+		asOf = d
+	}
+
+	balances, err := computeAccountBalances(database.DB, uid, asOf, c.Query("include_archived") == "true")
+	if err != nil {
+		log.Printf("get account balances: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":         asOf.Format("2006-01-02"),
+		"base_currency": userBaseCurrency(database.DB, uid),
+		"accounts":      balances,
+	})
+}
+
+func CreateAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		Name           string  `json:"name" binding:"required"`
+		Type           string  `json:"type"`
+		OpeningBalance float64 `json:"opening_balance"`
+		Currency       string  `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account name must be 1–100 characters"})
+		return
+	}
+	if input.Type == "" {
+		input.Type = "checking"
+	}
+	if !accountTypes[input.Type] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Allowed values: cash, checking, savings, credit_card, other"})
+		return
+	}
+	currency, err := normalizeCurrency(input.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := defaultAccount(database.DB, uid); err != nil {
+		log.Printf("create account: default user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+	var n int64
+	database.DB.Model(&models.Account{}).Where("user_id = ? AND name = ?", uid, input.Name).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this name already exists"})
+		return
+	}
+
+	account := models.Account{
+		UserID: uid, Name: input.Name, Type: input.Type, OpeningBalance: round2(input.OpeningBalance),
+		Currency: currency, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := database.DB.Create(&account).Error; err != nil {
+		log.Printf("create account: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Account created successfully", "account": account})
+}
+
+// GetAccount → GET /api/accounts/:id
+// One account, with the ETag a later update or delete can send as If-Match.
+func GetAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var account models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found or does not belong to you"})
+		} else {
+			log.Printf("get account: user=%v account=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		}
+		return
+	}
+
+	c.Header("ETag", accountETag(account))
+	c.JSON(http.StatusOK, gin.H{"account": account})
+}
+
+// UpdateAccount changes any of name, type, opening balance, currency,
+// archived and is_default (true moves the default here).
+func UpdateAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
+		return
+	}
+	var account models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(id), uid).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found or does not belong to you"})
+		} else {
+			log.Printf("update account fetch: user=%v account=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		}
+		return
+	}
+	if etag := accountETag(account); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"account": account})
+		return
+	}
+	seen := account.UpdatedAt
+
+	var input struct {
+		Name           *string  `json:"name"`
+		Type           *string  `json:"type"`
+		OpeningBalance *float64 `json:"opening_balance"`
+		Currency       *string  `json:"currency"`
+		Archived       *bool    `json:"archived"`
+		IsDefault      *bool    `json:"is_default"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" || len(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account name must be 1–100 characters"})
+			return
+		}
+		var n int64
+		database.DB.Model(&models.Account{}).Where("user_id = ? AND name = ? AND id <> ?", uid, name, account.ID).Count(&n)
+		if n > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this name already exists"})
+			return
+		}
+		account.Name = name
+	}
+	if input.Type != nil {
+		if !accountTypes[*input.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Allowed values: cash, checking, savings, credit_card, other"})
+			return
+		}
+		account.Type = *input.Type
+	}
+	if input.OpeningBalance != nil {
+		account.OpeningBalance = round2(*input.OpeningBalance)
+	}
+	if input.Currency != nil {
+		if account.Currency, err = normalizeCurrency(*input.Currency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	becomesDefault := false
+	if input.IsDefault != nil {
+		if !*input.IsDefault && account.IsDefault {
+			c.JSON(http.StatusConflict, gin.H{"error": "Make another account the default instead"})
+			return
+		}
+		becomesDefault = *input.IsDefault && !account.IsDefault
+		account.IsDefault = account.IsDefault || *input.IsDefault
+	}
+	if input.Archived != nil {
+		account.Archived = *input.Archived
+	}
+	if account.IsDefault && account.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "The default account cannot be archived — make another account the default first"})
+		return
+	}
+	account.UpdatedAt = time.Now()
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Account{}, account.ID, seen); err != nil {
+			return err
+		}
+		if becomesDefault {
+			// Rows without an account belong to whichever account is the
+			// default; pin them to the old one so the switch moves no history.
+			old, err := defaultAccount(tx, uid)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL", uid).
+				Update("account_id", old.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Account{}).Where("user_id = ? AND id <> ?", uid, account.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&account).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Account not found or does not belong to you", func() (string, gin.H, error) {
+			return currentAccount(uid, account.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update account save: user=%v account=%v err=%v", uid, account.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	c.Header("ETag", accountETag(account))
+	c.JSON(http.StatusOK, gin.H{"message": "Account updated successfully", "account": account})
+}
+
+// RemoveAccount → DELETE /api/accounts/:id (DeleteAccount deletes the user).
+// Only an account that never held a transaction can go; archive the others.
+func RemoveAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
+		return
+	}
+	var account models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(id), uid).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found or does not belong to you"})
+		} else {
+			log.Printf("delete account fetch: user=%v account=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		}
+		return
+	}
+	if etag := accountETag(account); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"account": account})
+		return
+	}
+	if account.IsDefault {
+		c.JSON(http.StatusConflict, gin.H{"error": "The default account cannot be deleted"})
+		return
+	}
+	var used int64
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("user_id = ? AND account_id = ?", uid, account.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The account has transactions — archive it instead", "transactions": used})
+		return
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Account{}, account.ID, account.UpdatedAt); err != nil {
+			return err
+		}
+		return tx.Delete(&account).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Account not found or does not belong to you", func() (string, gin.H, error) {
+			return currentAccount(uid, account.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete account: user=%v account=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// CreateAccountTransfer → POST /api/accounts/transfers
+// Moves amount (in the source account's currency) to another account. When
+// the currencies differ, to_amount is what arrived; without it the amount is
+// converted at the rate on the transfer date.
+func CreateAccountTransfer(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		FromAccountID uint     `json:"from_account_id" binding:"required"`
+		ToAccountID   uint     `json:"to_account_id" binding:"required"`
+		Amount        float64  `json:"amount" binding:"required,gt=0"`
+		ToAmount      *float64 `json:"to_amount"`
+		Date          string   `json:"date"` // YYYY-MM-DD; optional
+		Description   string   `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.FromAccountID == input.ToAccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination must be different accounts"})
+		return
+	}
+	if input.ToAmount != nil && *input.ToAmount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_amount must be greater than zero"})
+		return
+	}
+	input.Description = strings.TrimSpace(input.Description)
+	if len(input.Description) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Description must be 255 characters or fewer"})
+		return
+	}
+	from, status, err := resolveTxAccount(uid, &input.FromAccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	to, status, err := resolveTxAccount(uid, &input.ToAccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := localDate(time.Now(), userLocation(uid))
+	if input.Date != "" {
+		if date, err = time.Parse("2006-01-02", input.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	catID, err := ensureTransferCategory(database.DB, uid)
+	if err != nil {
+		log.Printf("account transfer: category user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
+		return
+	}
+
+	base := userBaseCurrency(database.DB, uid)
+	now := time.Now()
+	leg := func(a models.Account, txType string) models.Transaction {
+		return models.Transaction{
+			UserID: uid, CategoryID: catID, AccountID: &a.ID, Description: input.Description,
+			Date: date, Type: txType, IncomeType: "one_time", CreatedAt: now, UpdatedAt: now,
+		}
+	}
+	out, in := leg(from, txTypeTransferOut), leg(to, txTypeTransferIn)
+	missing, err := applyFX(database.DB, &out, base, from.Currency, input.Amount)
+	if err == nil && missing == nil {
+		toAmount := input.Amount
+		switch {
+		case input.ToAmount != nil:
+			toAmount = *input.ToAmount
+		case to.Currency != from.Currency:
+			// Same base value arriving in the destination's currency.
+			toAmount, missing, err = accountAmount(database.DB, uid, out, orBase(to.Currency, base), base)
+		}
+		if err == nil && missing == nil {
+			missing, err = applyFX(database.DB, &in, base, to.Currency, toAmount)
+		}
+	}
+	if err != nil {
+		log.Printf("account transfer: fx user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert currency"})
+		return
+	}
+	if missing != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": missing.message(), "missing_rates": []missingRate{*missing}})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Splits", "Tags").Create(&out).Error; err != nil {
+			return err
+		}
+		in.TransferPeerID = &out.ID
+		if err := tx.Omit("Splits", "Tags").Create(&in).Error; err != nil {
+			return err
+		}
+		out.TransferPeerID = &in.ID
+		return tx.Model(&out).Update("transfer_peer_id", in.ID).Error
+	})
+	if err != nil {
+		log.Printf("account transfer: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Transfer created successfully", "from": out, "to": in})
+}
+
+// orBase resolves an account's currency, where empty means the base.
+func orBase(cur, base string) string {
+	if cur == "" {
+		return base
+	}
+	return cur
+}
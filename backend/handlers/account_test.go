@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func accountBalancesAsOf(t *testing.T, uid uint, day string) map[string]float64 {
+	t.Helper()
+	w := callHandlerGET(uid, "as_of="+day, GetAccountBalances)
+	var resp struct {
+		Accounts []accountBalance `json:"accounts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("balances as of %s: %d %s", day, w.Code, w.Body.String())
+	}
+	out := map[string]float64{}
+	for _, a := range resp.Accounts {
+		out[a.Name] = a.Balance
+	}
+	return out
+}
+
+// A transfer moves money between account balances without touching any
+// spending figure, and deleting one leg takes the other with it.
+func TestAccountTransfer(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+
+	w := callHandler(uid, map[string]any{"name": "Wallet", "type": "cash", "opening_balance": 50}, CreateAccount)
+	var created struct {
+		Account models.Account `json:"account"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create account: %d %s", w.Code, w.Body.String())
+	}
+	wallet := created.Account
+	if w := callHandler(uid, map[string]any{"name": "Wallet"}, CreateAccount); w.Code != http.StatusConflict {
+		t.Errorf("duplicate name: want 409, got %d", w.Code)
+	}
+
+	// Without account_id an expense lands in the default account.
+	w = callHandler(uid, map[string]any{"category_id": f.food.ID, "amount": 30, "date": "2026-05-01", "type": "expense"}, CreateTransaction)
+	var expense struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &expense)
+	def, _ := defaultAccount(database.DB, uid)
+	if w.Code != http.StatusCreated || expense.Transaction.AccountID == nil || *expense.Transaction.AccountID != def.ID {
+		t.Fatalf("expense should be in the default account: %d %s", w.Code, w.Body.String())
+	}
+
+	body := map[string]any{"from_account_id": def.ID, "to_account_id": wallet.ID, "amount": 200, "date": "2026-05-10"}
+	w = callHandler(uid, body, CreateAccountTransfer)
+	var transfer struct {
+		From models.Transaction `json:"from"`
+		To   models.Transaction `json:"to"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &transfer); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("transfer: %d %s", w.Code, w.Body.String())
+	}
+	if transfer.From.TransferPeerID == nil || *transfer.From.TransferPeerID != transfer.To.ID ||
+		transfer.To.TransferPeerID == nil || *transfer.To.TransferPeerID != transfer.From.ID {
+		t.Errorf("legs must point at each other: %+v / %+v", transfer.From, transfer.To)
+	}
+
+	if bw := computeBudgetWindow(uid, 1000, time.Now()); bw.SpentThisWindow != 30 {
+		t.Errorf("a transfer is not spending: want 30 spent, got %.2f", bw.SpentThisWindow)
+	}
+	if got := accountBalancesAsOf(t, uid, "2026-05-09"); got[defaultAccountName] != -30 || got["Wallet"] != 50 {
+		t.Errorf("before the transfer: %v", got)
+	}
+	if got := accountBalancesAsOf(t, uid, "2026-05-10"); got[defaultAccountName] != -230 || got["Wallet"] != 250 {
+		t.Errorf("after the transfer: %v", got)
+	}
+
+	if w := callHandlerParamBody(uid, txParam(transfer.To.ID), map[string]any{"amount": 10}, UpdateTransaction); w.Code != http.StatusConflict {
+		t.Errorf("editing a leg: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, catParam(wallet.ID), RemoveAccount); w.Code != http.StatusConflict {
+		t.Errorf("deleting an account with transactions: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, txParam(transfer.From.ID), DeleteTransaction); w.Code != http.StatusOK {
+		t.Fatalf("delete leg: %d %s", w.Code, w.Body.String())
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("id IN ?", []uint{transfer.From.ID, transfer.To.ID}).Count(&live)
+	if live != 0 {
+		t.Errorf("both legs should be in the trash, %d still live", live)
+	}
+	if got := accountBalancesAsOf(t, uid, "2026-05-10"); got["Wallet"] != 50 {
+		t.Errorf("after deleting the transfer: %v", got)
+	}
+}
+
+// Rows written before accounts existed count toward the default account, and
+// archiving the default is refused.
+func TestAccountBalances_LegacyRowsAndArchive(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	addExpense(t, uid, f.rent.ID, 700, utcDay(2026, 4, 1))
+
+	if got := accountBalancesAsOf(t, uid, "2026-04-30"); got[defaultAccountName] != -700 {
+		t.Errorf("legacy row should hit the default account: %v", got)
+	}
+	def, _ := defaultAccount(database.DB, uid)
+	if w := callHandlerParamBody(uid, catParam(def.ID), map[string]any{"archived": true}, UpdateAccount); w.Code != http.StatusConflict {
+		t.Errorf("archiving the default: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, catParam(def.ID), RemoveAccount); w.Code != http.StatusConflict {
+		t.Errorf("deleting the default: want 409, got %d", w.Code)
+	}
+}
+
+// Moving the default account must not move history: a recurring instance is
+// stamped with the default at posting time, and rows without an account are
+// pinned to the old default in the same write.
+func TestAccount_DefaultSwitchKeepsHistory(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	addExpense(t, uid, f.rent.ID, 700, utcDay(2026, 4, 1)) // written before accounts existed
+	def, _ := defaultAccount(database.DB, uid)
+
+	w := callHandler(uid, map[string]any{
+		"category_id": f.food.ID, "amount": 40, "description": "Gym",
+		"frequency": "daily", "start_date": "2026-05-01", "count": 1,
+	}, CreateRecurring)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create recurring: %d %s", w.Code, w.Body.String())
+	}
+	var posted models.Transaction
+	database.DB.Where("user_id = ? AND recurring_id IS NOT NULL", uid).First(&posted)
+	if posted.AccountID == nil || *posted.AccountID != def.ID {
+		t.Fatalf("recurring instance should carry the default account, got %v", posted.AccountID)
+	}
+
+	w = callHandler(uid, map[string]any{"name": "Wallet", "type": "cash"}, CreateAccount)
+	var created struct {
+		Account models.Account `json:"account"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create account: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParamBody(uid, catParam(created.Account.ID), map[string]any{"is_default": true}, UpdateAccount); w.Code != http.StatusOK {
+		t.Fatalf("switch default: %d %s", w.Code, w.Body.String())
+	}
+
+	if got := accountBalancesAsOf(t, uid, "2026-05-31"); got[defaultAccountName] != -740 || got["Wallet"] != 0 {
+		t.Errorf("switching the default moved history: %v", got)
+	}
+	var orphans int64
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL", uid).Count(&orphans)
+	if orphans != 0 {
+		t.Errorf("rows without an account should be pinned to the old default, %d left", orphans)
+	}
+}
@@ -431,7 +431,7 @@ func AnalyzeBehavior(c *gin.Context) {
 	since := time.Now().AddDate(0, 0, -90)
 	var txs []models.Transaction
 	if err := database.DB.Preload("Category").
-		Where("user_id = ? AND date >= ?", uid, since).
+		Where("user_id = ? AND date >= ? AND type NOT IN ?", uid, since, []string{txTypeTransferOut, txTypeTransferIn}).
 		Find(&txs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
 		return
@@ -459,7 +459,7 @@ func AnalyzeBehavior(c *gin.Context) {
 				Where("user_id = ? AND category_id = ?", uid, activeCycle.SavedMoneyCategoryID).
 				Find(&pool)
 			for _, p := range pool {
-				if p.Type == "income" || p.Type == "savings_deposit" {
+				if p.Type == "income" || p.Type == "savings_deposit" || p.Type == txTypeRefund {
 					savedMoneyBalance += p.Amount
 				} else {
 					savedMoneyBalance -= p.Amount
@@ -473,9 +473,9 @@ func AnalyzeBehavior(c *gin.Context) {
 		// The cycle can end before the notional 7-day week completes — never
 		// promise more week than the cycle has left.
 		remainingInWeek := max(min(7-elapsedInWeek, stats.DaysRemaining), 0)
-		now := time.Now()
-		cycleActive := !activeCycle.CycleStartAt.After(now) &&
-			(activeCycle.NextPaydayAt == nil || !now.After(*activeCycle.NextPaydayAt))
+		today := localDate(time.Now(), userLocation(uid))
+		cycleActive := !today.Before(toDateOnly(activeCycle.CycleStartAt)) &&
+			(activeCycle.NextPaydayAt == nil || !today.After(toDateOnly(*activeCycle.NextPaydayAt)))
 
 		cyclePayload = &aiSalaryCycleInfo{
 			TotalIncome:          activeCycle.TotalIncome,
@@ -511,7 +511,7 @@ func AnalyzeBehavior(c *gin.Context) {
 	// finished cycle correctly routes here too.
 	var budgetPayload *aiBudgetWindowInfo
 	if cyclePayload == nil || !cyclePayload.CycleActive {
-		bw := computeBudgetWindow(uid, user.MonthlySpendingGoal, time.Now())
+		bw := computeBudgetWindow(uid, user.MonthlySpendingGoal, userNow(uid))
 		budgetPayload = &aiBudgetWindowInfo{
 			HasGoal:               bw.HasGoal,
 			MonthlyBudget:         bw.MonthlyBudget,
@@ -0,0 +1,625 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Full account archive: GET /api/export/full, POST /api/import/full ────────
+//
+// The archive is one versioned JSON document holding everything the user owns
+// — profile settings, categories, accounts, transactions including the trash,
+// salary cycles with their fixed expenses and audit log, and every planning
+// table — so an account can move between self-hosted instances. Rows keep
+// their ids and timestamps in the archive; the import gives them fresh ids and
+// rewrites every reference, while created_at survives because the cycle
+// windows are drawn on it. Credentials and the username are never exported.
+
+const (
+	archiveFormat = "financer-archive"
+	// archiveVersion is bumped whenever a field is renamed or its meaning
+	// changes; additive fields keep the version.
+	archiveVersion = 1
+)
+
+// archiveProfile is the user's settings, without identity or credentials.
+type archiveProfile struct {
+	Currency            string    `json:"currency"`
+	AIAdviceEnabled     bool      `json:"ai_advice_enabled"`
+	AIHumorEnabled      bool      `json:"ai_humor_enabled"`
+	MonthlySpendingGoal float64   `json:"monthly_spending_goal"`
+	ExpectedSalary      float64   `json:"expected_salary"`
+	PaydayMode          string    `json:"payday_mode"`
+	FixedPayday         int       `json:"fixed_payday"`
+	ManualNextPayday    string    `json:"manual_next_payday"`
+	HeartsCount         int       `json:"hearts_count"`
+	ReputationScore     int       `json:"reputation_score"`
+	LiteMode            bool      `json:"lite_mode"`
+	BudgetMode          string    `json:"budget_mode"`
+	Timezone            string    `json:"timezone"`
+	WeekStart           int       `json:"week_start"`
+	BudgetAnchorDay     int       `json:"budget_anchor_day"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// The archive rows below embed their model and shadow the preloaded Category
+// (and, for transactions, Tags) with a nil field, so the JSON carries ids
+// only, never a zero-valued copy of the association.
+
+type archiveSplit struct {
+	models.TransactionSplit
+	Category *models.Category `json:"category,omitempty"`
+}
+
+type archiveTransaction struct {
+	models.Transaction
+	Category  *models.Category `json:"category,omitempty"`
+	Splits    []archiveSplit   `json:"splits,omitempty"`
+	Tags      []models.Tag     `json:"tags,omitempty"`
+	TagIDs    []uint           `json:"tag_ids,omitempty"`
+	DeletedAt *time.Time       `json:"deleted_at,omitempty"` // set for rows in the trash
+}
+
+type archiveRecurring struct {
+	models.RecurringTransaction
+	Category *models.Category `json:"category,omitempty"`
+}
+
+type archiveCategoryBudget struct {
+	models.CategoryBudget
+	Category *models.Category `json:"category,omitempty"`
+}
+
+type archiveCategoryRule struct {
+	models.CategoryRule
+	Tags []string `json:"tags"`
+}
+
+// accountArchive is the whole document.
+type accountArchive struct {
+	Format                string                    `json:"format"`
+	Version               int                       `json:"version"`
+	ExportedAt            time.Time                 `json:"exported_at"`
+	Profile               archiveProfile            `json:"profile"`
+	Categories            []models.Category         `json:"categories"`
+	Envelopes             []models.Envelope         `json:"envelopes"`
+	EnvelopeTransfers     []models.EnvelopeTransfer `json:"envelope_transfers"`
+	Accounts              []models.Account          `json:"accounts"`
+	Reconciliations       []models.Reconciliation   `json:"reconciliations"`
+	SavingsGoals          []models.SavingsGoal      `json:"savings_goals"`
+	Tags                  []models.Tag              `json:"tags"`
+	RecurringTransactions []archiveRecurring        `json:"recurring_transactions"`
+	SalaryCycles          []models.SalaryCycle      `json:"salary_cycles"` // with fixed_expenses
+	SalaryCycleAudits     []models.SalaryCycleAudit `json:"salary_cycle_audits"`
+	Transactions          []archiveTransaction      `json:"transactions"`
+	CategoryBudgets       []archiveCategoryBudget   `json:"category_budgets"`
+	CategoryRules         []archiveCategoryRule     `json:"category_rules"`
+	ExchangeRates         []models.ExchangeRate     `json:"exchange_rates"`
+}
+
+// ExportFullArchive → GET /api/export/full
+func ExportFullArchive(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	archive, err := buildArchive(database.DB, uid)
+	if err != nil {
+		log.Printf("export full: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	filename := "financer-export-" + archive.ExportedAt.Format("2006-01-02") + ".json"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.JSON(http.StatusOK, archive)
+}
+
+// buildArchive reads everything uid owns, ordered by id.
+func buildArchive(db *gorm.DB, uid uint) (accountArchive, error) {
+	a := accountArchive{Format: archiveFormat, Version: archiveVersion, ExportedAt: time.Now().UTC()}
+
+	var user models.User
+	if err := db.First(&user, uid).Error; err != nil {
+		return a, err
+	}
+	a.Profile = archiveProfile{
+		Currency: user.Currency, AIAdviceEnabled: user.AIAdviceEnabled, AIHumorEnabled: user.AIHumorEnabled,
+		MonthlySpendingGoal: user.MonthlySpendingGoal, ExpectedSalary: user.ExpectedSalary,
+		PaydayMode: user.PaydayMode, FixedPayday: user.FixedPayday, ManualNextPayday: user.ManualNextPayday,
+		HeartsCount: user.HeartsCount, ReputationScore: user.ReputationScore, LiteMode: user.LiteMode,
+		BudgetMode: user.BudgetMode, Timezone: user.Timezone, WeekStart: user.WeekStart,
+		BudgetAnchorDay: user.BudgetAnchorDay, CreatedAt: user.CreatedAt,
+	}
+
+	owned := func(dest any) error {
+		return db.Where("user_id = ?", uid).Order("id").Find(dest).Error
+	}
+	for _, dest := range []any{
+		&a.Categories, &a.Envelopes, &a.EnvelopeTransfers, &a.Accounts, &a.Reconciliations,
+		&a.SavingsGoals, &a.Tags, &a.SalaryCycleAudits, &a.ExchangeRates,
+	} {
+		if err := owned(dest); err != nil {
+			return a, err
+		}
+	}
+	if err := db.Preload("FixedExpenses").Where("user_id = ?", uid).Order("id").Find(&a.SalaryCycles).Error; err != nil {
+		return a, err
+	}
+
+	var recurring []models.RecurringTransaction
+	if err := owned(&recurring); err != nil {
+		return a, err
+	}
+	a.RecurringTransactions = make([]archiveRecurring, 0, len(recurring))
+	for _, r := range recurring {
+		a.RecurringTransactions = append(a.RecurringTransactions, archiveRecurring{RecurringTransaction: r})
+	}
+	var budgets []models.CategoryBudget
+	if err := owned(&budgets); err != nil {
+		return a, err
+	}
+	a.CategoryBudgets = make([]archiveCategoryBudget, 0, len(budgets))
+	for _, b := range budgets {
+		a.CategoryBudgets = append(a.CategoryBudgets, archiveCategoryBudget{CategoryBudget: b})
+	}
+	var rules []models.CategoryRule
+	if err := owned(&rules); err != nil {
+		return a, err
+	}
+	a.CategoryRules = make([]archiveCategoryRule, 0, len(rules))
+	for _, r := range rules {
+		a.CategoryRules = append(a.CategoryRules, archiveCategoryRule{CategoryRule: r, Tags: ruleTags(r)})
+	}
+
+	var txs []models.Transaction
+	if err := db.Unscoped().Preload("Splits").Where("user_id = ?", uid).Order("id").Find(&txs).Error; err != nil {
+		return a, err
+	}
+	var links []struct{ TransactionID, TagID uint }
+	if err := db.Table("transaction_tags").Select("transaction_id, tag_id").
+		Where("tag_id IN (?)", db.Model(&models.Tag{}).Select("id").Where("user_id = ?", uid)).
+		Order("transaction_id, tag_id").Scan(&links).Error; err != nil {
+		return a, err
+	}
+	tagIDs := make(map[uint][]uint)
+	for _, l := range links {
+		tagIDs[l.TransactionID] = append(tagIDs[l.TransactionID], l.TagID)
+	}
+	a.Transactions = make([]archiveTransaction, 0, len(txs))
+	for _, t := range txs {
+		row := archiveTransaction{Transaction: t, TagIDs: tagIDs[t.ID]}
+		for _, s := range t.Splits {
+			row.Splits = append(row.Splits, archiveSplit{TransactionSplit: s})
+		}
+		if t.DeletedAt.Valid {
+			d := t.DeletedAt.Time
+			row.DeletedAt = &d
+		}
+		a.Transactions = append(a.Transactions, row)
+	}
+	return a, nil
+}
+
+// ImportFullArchive → POST /api/import/full
+// Restores an archive into the caller's account, which must be fresh: no
+// transactions (not even in the trash), salary cycles or recurring
+// transactions yet. Whatever else it holds — default categories, the default
+// account — is replaced by the archive's. All or nothing.
+func ImportFullArchive(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var archive accountArchive
+	if err := c.ShouldBindJSON(&archive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid archive: " + err.Error()})
+		return
+	}
+	if err := validateArchive(archive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fresh, err := isFreshAccount(database.DB, uid)
+	if err != nil {
+		log.Printf("import full: check user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import account data"})
+		return
+	}
+	if !fresh {
+		c.JSON(http.StatusConflict, gin.H{"error": "Import needs a fresh account — this one already has transactions, salary cycles or recurring transactions"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteUserData(tx, uid); err != nil {
+			return err
+		}
+		return restoreArchive(tx, uid, archive)
+	})
+	if err != nil {
+		log.Printf("import full: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import account data"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Account data imported",
+		"imported": gin.H{
+			"categories":             len(archive.Categories),
+			"accounts":               len(archive.Accounts),
+			"transactions":           len(archive.Transactions),
+			"salary_cycles":          len(archive.SalaryCycles),
+			"recurring_transactions": len(archive.RecurringTransactions),
+		},
+	})
+}
+
+// isFreshAccount reports whether uid has no financial history yet.
+func isFreshAccount(db *gorm.DB, uid uint) (bool, error) {
+	for _, m := range []any{&models.Transaction{}, &models.SalaryCycle{}, &models.RecurringTransaction{}} {
+		var n int64
+		if err := db.Unscoped().Model(m).Where("user_id = ?", uid).Count(&n).Error; err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// archiveIDs is the set of row ids of one kind in an archive.
+type archiveIDs map[uint]bool
+
+func collectIDs[T any](rows []T, id func(T) uint, kind string) (archiveIDs, error) {
+	ids := make(archiveIDs, len(rows))
+	for _, r := range rows {
+		if ids[id(r)] {
+			return nil, fmt.Errorf("Invalid archive: %s %d appears twice", kind, id(r))
+		}
+		ids[id(r)] = true
+	}
+	return ids, nil
+}
+
+// validateArchive checks the format and that every reference points at a row
+// of the archive, so the import can remap without surprises. The error is
+// safe to send to the client.
+func validateArchive(a accountArchive) error {
+	if a.Format != archiveFormat {
+		return errors.New("Not a Financer archive")
+	}
+	if a.Version < 1 || a.Version > archiveVersion {
+		return fmt.Errorf("Archive version %d is not supported (this server reads up to %d)", a.Version, archiveVersion)
+	}
+	if _, err := loadTimezone(a.Profile.Timezone); err != nil {
+		return err
+	}
+
+	cats, err := collectIDs(a.Categories, func(r models.Category) uint { return r.ID }, "category")
+	if err != nil {
+		return err
+	}
+	envelopes, err := collectIDs(a.Envelopes, func(r models.Envelope) uint { return r.ID }, "envelope")
+	if err != nil {
+		return err
+	}
+	accounts, err := collectIDs(a.Accounts, func(r models.Account) uint { return r.ID }, "account")
+	if err != nil {
+		return err
+	}
+	recons, err := collectIDs(a.Reconciliations, func(r models.Reconciliation) uint { return r.ID }, "reconciliation")
+	if err != nil {
+		return err
+	}
+	goals, err := collectIDs(a.SavingsGoals, func(r models.SavingsGoal) uint { return r.ID }, "savings goal")
+	if err != nil {
+		return err
+	}
+	tags, err := collectIDs(a.Tags, func(r models.Tag) uint { return r.ID }, "tag")
+	if err != nil {
+		return err
+	}
+	recurring, err := collectIDs(a.RecurringTransactions, func(r archiveRecurring) uint { return r.ID }, "recurring transaction")
+	if err != nil {
+		return err
+	}
+	cycles, err := collectIDs(a.SalaryCycles, func(r models.SalaryCycle) uint { return r.ID }, "salary cycle")
+	if err != nil {
+		return err
+	}
+	txs, err := collectIDs(a.Transactions, func(r archiveTransaction) uint { return r.ID }, "transaction")
+	if err != nil {
+		return err
+	}
+
+	var bad error
+	need := func(ids archiveIDs, kind string, id uint) {
+		if bad == nil && !ids[id] {
+			bad = fmt.Errorf("Invalid archive: unknown %s %d", kind, id)
+		}
+	}
+	needOpt := func(ids archiveIDs, kind string, id *uint) {
+		if id != nil {
+			need(ids, kind, *id)
+		}
+	}
+	for _, r := range a.Categories {
+		needOpt(cats, "category", r.ParentID)
+		needOpt(envelopes, "envelope", r.EnvelopeID)
+	}
+	for _, r := range a.EnvelopeTransfers {
+		needOpt(envelopes, "envelope", r.FromEnvelopeID)
+		needOpt(envelopes, "envelope", r.ToEnvelopeID)
+	}
+	for _, r := range a.Reconciliations {
+		need(accounts, "account", r.AccountID)
+	}
+	for _, r := range a.RecurringTransactions {
+		need(cats, "category", r.CategoryID)
+	}
+	for _, r := range a.SalaryCycleAudits {
+		need(cycles, "salary cycle", r.SalaryCycleID)
+	}
+	for _, r := range a.Transactions {
+		need(cats, "category", r.CategoryID)
+		needOpt(recurring, "recurring transaction", r.RecurringID)
+		needOpt(goals, "savings goal", r.SavingsGoalID)
+		needOpt(accounts, "account", r.AccountID)
+		needOpt(recons, "reconciliation", r.ReconciliationID)
+		needOpt(txs, "transaction", r.TransferPeerID)
+		needOpt(txs, "transaction", r.RefundOfID)
+		for _, s := range r.Splits {
+			need(cats, "category", s.CategoryID)
+		}
+		for _, id := range r.TagIDs {
+			need(tags, "tag", id)
+		}
+	}
+	for _, r := range a.CategoryBudgets {
+		need(cats, "category", r.CategoryID)
+	}
+	for _, r := range a.CategoryRules {
+		if r.CategoryID != 0 {
+			need(cats, "category", r.CategoryID)
+		}
+	}
+	return bad
+}
+
+// idMap maps archive ids of one kind to the ids the rows got on import.
+type idMap map[uint]uint
+
+// opt remaps an optional reference.
+func (m idMap) opt(id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	v := m[*id]
+	return &v
+}
+
+// restoreArchive inserts a validated archive for uid. Rows are created in
+// dependency order; self-references (category parents, transfer peers,
+// refunds) are filled in once every row of the kind has its new id.
+func restoreArchive(tx *gorm.DB, uid uint, a accountArchive) error {
+	p := a.Profile
+	weekStart, anchorDay := budgetPrefsOf(p.WeekStart, p.BudgetAnchorDay)
+	budgetMode := p.BudgetMode
+	if budgetMode != budgetModeEnvelope {
+		budgetMode = budgetModeFramework
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", uid).Updates(map[string]any{
+		"currency": p.Currency, "ai_advice_enabled": p.AIAdviceEnabled, "ai_humor_enabled": p.AIHumorEnabled,
+		"monthly_spending_goal": p.MonthlySpendingGoal, "expected_salary": p.ExpectedSalary,
+		"payday_mode": p.PaydayMode, "fixed_payday": p.FixedPayday, "manual_next_payday": p.ManualNextPayday,
+		"hearts_count": p.HeartsCount, "reputation_score": p.ReputationScore, "lite_mode": p.LiteMode,
+		"budget_mode": budgetMode, "timezone": p.Timezone, "week_start": int(weekStart), "budget_anchor_day": anchorDay,
+	}).Error; err != nil {
+		return err
+	}
+
+	create := func(row any) error { return tx.Omit(clause.Associations).Create(row).Error }
+
+	envelopes := idMap{}
+	for _, r := range a.Envelopes {
+		old := r.ID
+		r.ID, r.UserID = 0, uid
+		if err := create(&r); err != nil {
+			return err
+		}
+		envelopes[old] = r.ID
+	}
+	for _, r := range a.EnvelopeTransfers {
+		r.ID, r.UserID = 0, uid
+		r.FromEnvelopeID, r.ToEnvelopeID = envelopes.opt(r.FromEnvelopeID), envelopes.opt(r.ToEnvelopeID)
+		if err := create(&r); err != nil {
+			return err
+		}
+	}
+
+	cats := idMap{}
+	for _, r := range a.Categories {
+		old := r.ID
+		r.ID, r.UserID, r.ParentID = 0, uid, nil
+		r.EnvelopeID = envelopes.opt(r.EnvelopeID)
+		if err := create(&r); err != nil {
+			return err
+		}
+		cats[old] = r.ID
+	}
+	for _, r := range a.Categories {
+		if r.ParentID != nil {
+			if err := tx.Model(&models.Category{}).Where("id = ?", cats[r.ID]).
+				UpdateColumn("parent_id", cats[*r.ParentID]).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	accounts := idMap{}
+	for _, r := range a.Accounts {
+		old := r.ID
+		r.ID, r.UserID = 0, uid
+		if err := create(&r); err != nil {
+			return err
+		}
+		accounts[old] = r.ID
+	}
+	recons := idMap{}
+	for _, r := range a.Reconciliations {
+		old := r.ID
+		r.ID, r.UserID, r.AccountID = 0, uid, accounts[r.AccountID]
+		if err := create(&r); err != nil {
+			return err
+		}
+		recons[old] = r.ID
+	}
+	goals := idMap{}
+	for _, r := range a.SavingsGoals {
+		old := r.ID
+		r.ID, r.UserID = 0, uid
+		if err := create(&r); err != nil {
+			return err
+		}
+		goals[old] = r.ID
+	}
+	tags := idMap{}
+	for _, r := range a.Tags {
+		old := r.ID
+		r.ID, r.UserID = 0, uid
+		if err := create(&r); err != nil {
+			return err
+		}
+		tags[old] = r.ID
+	}
+
+	recurring := idMap{}
+	for _, ar := range a.RecurringTransactions {
+		r := ar.RecurringTransaction
+		old := r.ID
+		r.ID, r.UserID, r.CategoryID = 0, uid, cats[r.CategoryID]
+		if err := create(&r); err != nil {
+			return err
+		}
+		recurring[old] = r.ID
+	}
+
+	// A cycle's fixed-expense and saved-money categories are remapped with
+	// the rest; one the archive does not hold becomes 0, which the savings
+	// category healing re-links on the next read.
+	cycles := idMap{}
+	for _, r := range a.SalaryCycles {
+		old, fixed := r.ID, r.FixedExpenses
+		r.ID, r.UserID, r.FixedExpenses = 0, uid, nil
+		r.FixedExpCategoryID, r.SavedMoneyCategoryID = cats[r.FixedExpCategoryID], cats[r.SavedMoneyCategoryID]
+		if err := create(&r); err != nil {
+			return err
+		}
+		cycles[old] = r.ID
+		for _, fe := range fixed {
+			fe.ID, fe.UserID, fe.SalaryCycleID = 0, uid, r.ID
+			if err := create(&fe); err != nil {
+				return err
+			}
+		}
+	}
+	for _, r := range a.SalaryCycleAudits {
+		r.ID, r.UserID, r.SalaryCycleID = 0, uid, cycles[r.SalaryCycleID]
+		if err := create(&r); err != nil {
+			return err
+		}
+	}
+
+	txs := idMap{}
+	for _, at := range a.Transactions {
+		t := at.Transaction
+		old := t.ID
+		t.ID, t.UserID, t.CategoryID = 0, uid, cats[t.CategoryID]
+		t.RecurringID, t.SavingsGoalID = recurring.opt(t.RecurringID), goals.opt(t.SavingsGoalID)
+		t.AccountID, t.ReconciliationID = accounts.opt(t.AccountID), recons.opt(t.ReconciliationID)
+		t.TransferPeerID, t.RefundOfID = nil, nil
+		t.Splits, t.Tags = nil, nil
+		if at.DeletedAt != nil {
+			t.DeletedAt = gorm.DeletedAt{Time: *at.DeletedAt, Valid: true}
+		}
+		if err := create(&t); err != nil {
+			return err
+		}
+		txs[old] = t.ID
+		for _, as := range at.Splits {
+			s := as.TransactionSplit
+			s.ID, s.UserID, s.TransactionID, s.CategoryID = 0, uid, t.ID, cats[s.CategoryID]
+			if err := create(&s); err != nil {
+				return err
+			}
+		}
+		for _, tagID := range at.TagIDs {
+			if err := tx.Exec("INSERT INTO transaction_tags (transaction_id, tag_id) VALUES (?, ?)", t.ID, tags[tagID]).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, at := range a.Transactions {
+		links := map[string]any{}
+		if at.TransferPeerID != nil {
+			links["transfer_peer_id"] = txs[*at.TransferPeerID]
+		}
+		if at.RefundOfID != nil {
+			links["refund_of_id"] = txs[*at.RefundOfID]
+		}
+		if len(links) == 0 {
+			continue
+		}
+		if err := tx.Unscoped().Model(&models.Transaction{}).Where("id = ?", txs[at.ID]).UpdateColumns(links).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, ab := range a.CategoryBudgets {
+		b := ab.CategoryBudget
+		b.ID, b.UserID, b.CategoryID = 0, uid, cats[b.CategoryID]
+		if err := create(&b); err != nil {
+			return err
+		}
+	}
+	for _, ar := range a.CategoryRules {
+		r := ar.CategoryRule
+		r.ID, r.UserID = 0, uid
+		if r.CategoryID != 0 {
+			r.CategoryID = cats[r.CategoryID]
+		}
+		r.TagList = strings.Join(ar.Tags, ",")
+		if err := create(&r); err != nil {
+			return err
+		}
+	}
+	for _, r := range a.ExchangeRates {
+		r.ID, r.UserID = 0, uid
+		if err := create(&r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// An exported account restores into a fresh one with new ids and every
+// reference — category parents, cycle categories, refunds, splits, tags, the
+// trash — still pointing at the matching rows.
+func TestFullArchiveRoundTrip(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	sub := models.Category{UserID: uid, Name: "Restaurants", ParentID: &f.food.ID}
+	database.DB.Create(&sub)
+	cycle := models.SalaryCycle{UserID: uid, TotalIncome: 1000, SavedMoneyCategoryID: f.rent.ID, CycleStartAt: utcDay(2026, 3, 1),
+		FixedExpenses: []models.FixedExpense{{UserID: uid, Amount: 300, Description: "Flat"}}}
+	database.DB.Create(&cycle)
+	database.DB.Create(&models.SalaryCycleAudit{UserID: uid, SalaryCycleID: cycle.ID, Field: "next_payday_at", NewValue: "2026-03-31"})
+
+	createSplitTx(t, f)
+	shoes := models.Transaction{UserID: uid, CategoryID: f.beauty.ID, Amount: 80, Type: "expense", Date: utcDay(2026, 3, 5)}
+	database.DB.Create(&shoes)
+	refundOf := shoes.ID
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.beauty.ID, Amount: 30, Type: txTypeRefund, RefundOfID: &refundOf, Date: utcDay(2026, 3, 6)})
+	trashed := models.Transaction{UserID: uid, CategoryID: sub.ID, Amount: 12, Type: "expense", Description: "Binned", Date: utcDay(2026, 3, 7)}
+	database.DB.Create(&trashed)
+	database.DB.Delete(&trashed)
+	tag := models.Tag{UserID: uid, Name: "trip"}
+	database.DB.Create(&tag)
+	database.DB.Exec("INSERT INTO transaction_tags (transaction_id, tag_id) VALUES (?, ?)", shoes.ID, tag.ID)
+
+	w := callHandlerGET(uid, "", ExportFullArchive)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", w.Code, w.Body.String())
+	}
+	archive := json.RawMessage(w.Body.Bytes())
+
+	if w := callHandler(uid, archive, ImportFullArchive); w.Code != http.StatusConflict {
+		t.Errorf("importing into an account with history: want 409, got %d", w.Code)
+	}
+	fresh := models.User{Username: "newcomer", Password: "x"}
+	database.DB.Create(&fresh)
+	if w := callHandler(fresh.ID, map[string]any{"format": "something-else", "version": 1}, ImportFullArchive); w.Code != http.StatusBadRequest {
+		t.Errorf("a foreign document: want 400, got %d", w.Code)
+	}
+	if w := callHandler(fresh.ID, archive, ImportFullArchive); w.Code != http.StatusCreated {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+
+	catName := func(id uint) string {
+		var cat models.Category
+		database.DB.Where("id = ? AND user_id = ?", id, fresh.ID).First(&cat)
+		return cat.Name
+	}
+	var restoredSub models.Category
+	database.DB.Where("user_id = ? AND name = ?", fresh.ID, "Restaurants").First(&restoredSub)
+	if restoredSub.ParentID == nil || catName(*restoredSub.ParentID) != "Food" {
+		t.Errorf("the sub-category should stay under Food: %+v", restoredSub)
+	}
+
+	var cycles []models.SalaryCycle
+	database.DB.Preload("FixedExpenses").Where("user_id = ?", fresh.ID).Find(&cycles)
+	if len(cycles) != 1 || catName(cycles[0].SavedMoneyCategoryID) != "Rent" || len(cycles[0].FixedExpenses) != 1 {
+		t.Fatalf("cycle not restored consistently: %+v", cycles)
+	}
+	var audits int64
+	database.DB.Model(&models.SalaryCycleAudit{}).Where("user_id = ? AND salary_cycle_id = ?", fresh.ID, cycles[0].ID).Count(&audits)
+	if audits != 1 {
+		t.Errorf("the audit log should follow its cycle: %d", audits)
+	}
+
+	var refund, original models.Transaction
+	database.DB.Where("user_id = ? AND type = ?", fresh.ID, txTypeRefund).First(&refund)
+	if refund.RefundOfID == nil || database.DB.Preload("Tags").First(&original, *refund.RefundOfID).Error != nil ||
+		original.UserID != fresh.ID || original.Amount != 80 || len(original.Tags) != 1 || original.Tags[0].Name != "trip" {
+		t.Errorf("the refund should point at the restored, tagged expense: %+v", original)
+	}
+	var split models.Transaction
+	database.DB.Preload("Splits").Where("user_id = ? AND description = ?", fresh.ID, "Supermarket").First(&split)
+	if len(split.Splits) != 2 || catName(split.Splits[1].CategoryID) != "Beauty" {
+		t.Errorf("split lines should keep their categories: %+v", split.Splits)
+	}
+	var bin models.Transaction
+	if err := database.DB.Unscoped().Where("user_id = ? AND description = ?", fresh.ID, "Binned").First(&bin).Error; err != nil ||
+		!bin.DeletedAt.Valid || catName(bin.CategoryID) != "Restaurants" {
+		t.Errorf("the trash should be restored as trash: %+v", bin)
+	}
+}
+
+// exportArchive returns uid's archive as a JSON document.
+func exportArchive(t *testing.T, uid uint) json.RawMessage {
+	t.Helper()
+	w := callHandlerGET(uid, "", ExportFullArchive)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", w.Code, w.Body.String())
+	}
+	return json.RawMessage(w.Body.Bytes())
+}
+
+// Transfer legs, reconciliations and the accounts behind them come back
+// linked to each other's new ids, not to the exporter's rows.
+func TestFullArchive_RemapsAccountLinks(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	w := callHandler(uid, map[string]any{"name": "Wallet", "type": "cash"}, CreateAccount)
+	var wallet struct {
+		Account models.Account `json:"account"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &wallet)
+	if w := callHandler(uid, map[string]any{"from_account_id": def.ID, "to_account_id": wallet.Account.ID, "amount": 50, "date": "2026-05-20"}, CreateAccountTransfer); w.Code != http.StatusCreated {
+		t.Fatalf("transfer: %d %s", w.Code, w.Body.String())
+	}
+	w = callHandler(uid, map[string]any{"category_id": f.food.ID, "amount": 30, "date": "2026-05-02", "type": "expense"}, CreateTransaction)
+	var groceries struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &groceries)
+	w = callHandler(uid, map[string]any{"account_id": def.ID, "statement_date": "2026-05-15", "statement_balance": -30}, CreateReconciliation)
+	rec := decodeReconciliation(t, w.Body.Bytes()).Reconciliation
+	callHandlerParamBody(uid, catParam(rec.ID), map[string]any{"transaction_ids": []uint{groceries.Transaction.ID}}, ClearReconciliationRows)
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("lock: %d %s", w.Code, w.Body.String())
+	}
+
+	archive := exportArchive(t, uid)
+	fresh := models.User{Username: "mover", Password: "x"}
+	database.DB.Create(&fresh)
+	if w := callHandler(fresh.ID, archive, ImportFullArchive); w.Code != http.StatusCreated {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+
+	accountName := func(id *uint) string {
+		var a models.Account
+		if id == nil || database.DB.Where("id = ? AND user_id = ?", *id, fresh.ID).First(&a).Error != nil {
+			return ""
+		}
+		return a.Name
+	}
+	var legs []models.Transaction
+	database.DB.Where("user_id = ? AND type IN ?", fresh.ID, []string{txTypeTransferOut, txTypeTransferIn}).Order("type DESC").Find(&legs)
+	if len(legs) != 2 || legs[0].TransferPeerID == nil || *legs[0].TransferPeerID != legs[1].ID ||
+		legs[1].TransferPeerID == nil || *legs[1].TransferPeerID != legs[0].ID {
+		t.Fatalf("transfer legs should point at each other: %+v", legs)
+	}
+	if accountName(legs[0].AccountID) != defaultAccountName || accountName(legs[1].AccountID) != "Wallet" {
+		t.Errorf("legs should sit in the restored accounts: %q → %q", accountName(legs[0].AccountID), accountName(legs[1].AccountID))
+	}
+
+	var restored models.Transaction
+	database.DB.Where("user_id = ? AND type = ?", fresh.ID, "expense").First(&restored)
+	var restoredRec models.Reconciliation
+	if restored.Status != txStatusReconciled || restored.ReconciliationID == nil ||
+		database.DB.Where("id = ? AND user_id = ?", *restored.ReconciliationID, fresh.ID).First(&restoredRec).Error != nil {
+		t.Fatalf("the reconciled row should point at the restored session: %+v", restored)
+	}
+	if restoredRec.Status != reconciliationLocked || accountName(&restoredRec.AccountID) != defaultAccountName {
+		t.Errorf("the session should be locked on the restored default account: %+v", restoredRec)
+	}
+}
+
+// Only an account without history takes an import — a row in the trash or a
+// recurring template counts — and a refused import changes nothing.
+func TestFullArchive_RefusesAccountWithHistory(t *testing.T) {
+	f := seedSplitUser(t)
+	addExpense(t, f.user.ID, f.food.ID, 10, utcDay(2026, 3, 1))
+	archive := exportArchive(t, f.user.ID)
+
+	binned := models.User{Username: "binned", Password: "x"}
+	database.DB.Create(&binned)
+	old := models.Transaction{UserID: binned.ID, CategoryID: f.food.ID, Amount: 1, Type: "expense", Date: utcDay(2026, 1, 1)}
+	database.DB.Create(&old)
+	database.DB.Delete(&old)
+	planner := models.User{Username: "planner", Password: "x"}
+	database.DB.Create(&planner)
+	database.DB.Create(&models.RecurringTransaction{UserID: planner.ID, CategoryID: f.food.ID, Amount: 5, Frequency: "monthly", Interval: 1, DayOfMonth: 1, StartDate: utcDay(2026, 1, 1)})
+
+	for _, u := range []models.User{binned, planner} {
+		if w := callHandler(u.ID, archive, ImportFullArchive); w.Code != http.StatusConflict {
+			t.Errorf("%s: want 409, got %d", u.Username, w.Code)
+		}
+		var n int64
+		database.DB.Unscoped().Model(&models.Transaction{}).Where("user_id = ?", u.ID).Count(&n)
+		if want := map[string]int64{"binned": 1, "planner": 0}[u.Username]; n != want {
+			t.Errorf("%s: a refused import must not write, %d transactions", u.Username, n)
+		}
+	}
+}
+
+// A reference to a row the archive does not contain is refused before
+// anything is written.
+func TestFullArchive_RejectsDanglingReferences(t *testing.T) {
+	f := seedSplitUser(t)
+	addExpense(t, f.user.ID, f.food.ID, 10, utcDay(2026, 3, 1))
+	var doc map[string]any
+	if err := json.Unmarshal(exportArchive(t, f.user.ID), &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["transactions"].([]any)[0].(map[string]any)["category_id"] = 999999
+
+	fresh := models.User{Username: "strict-importer", Password: "x"}
+	database.DB.Create(&fresh)
+	if w := callHandler(fresh.ID, doc, ImportFullArchive); w.Code != http.StatusBadRequest {
+		t.Errorf("dangling category: want 400, got %d %s", w.Code, w.Body.String())
+	}
+	var n int64
+	database.DB.Model(&models.Category{}).Where("user_id = ?", fresh.ID).Count(&n)
+	if n != 0 {
+		t.Errorf("a rejected archive must not write, %d categories", n)
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,8 +27,10 @@ const DefaultMonthlyBudget = 500.0
 // formula is no longer the source of truth for these users.
 //
 // It creates NO rows and fabricates NO transactions — it is a pure read over the
-// user's profile goal + existing expenses, scoped to the current calendar month
-// (a stateless, auto-advancing window that matches the donut/forecaster default).
+// user's profile goal + existing expenses, scoped to the current month-long
+// window (a stateless, auto-advancing window that matches the donut/forecaster
+// default) in the user's time zone. The window runs from one anchor day to the
+// next — the 1st unless the user picked another day, e.g. their payday.
 type BudgetWindow struct {
 	HasGoal       bool    `json:"has_goal"`
 	DefaultGoal   float64 `json:"default_goal"`
@@ -33,6 +38,12 @@ type BudgetWindow struct {
 
 	WindowStart time.Time `json:"window_start"`
 	WindowEnd   time.Time `json:"window_end"`
+	// AnchorDay is the day of month the window rolls over on: 1–28 or "last".
+	AnchorDay    any    `json:"anchor_day"`
+	WeekStartsOn string `json:"week_starts_on"`
+	// The current week's bounds, clamped to the window like the figures below.
+	CurrentWeekStart time.Time `json:"current_week_start"`
+	CurrentWeekEnd   time.Time `json:"current_week_end"`
 
 	SpentThisWindow float64 `json:"spent_this_window"`
 	Remaining       float64 `json:"remaining"`
@@ -40,8 +51,8 @@ type BudgetWindow struct {
 	CurrentWeekAllowance float64 `json:"current_week_allowance"`
 	CurrentWeekSpent     float64 `json:"current_week_spent"`
 
-	// Weekly-pace day counts for the current week. Derived from the SAME Monday
-	// anchor the weekly allowance above uses — pure day counting, no extra budget
+	// Weekly-pace day counts for the current week. Derived from the SAME week
+	// start the weekly allowance above uses — pure day counting, no extra budget
 	// math — so the UFO's pace verdict is built on exactly the figures the budget
 	// bar renders. Semantics deliberately mirror the cycle engine: "elapsed"
 	// counts COMPLETED days, so the first day of the week reads 0 and the pace
@@ -59,41 +70,156 @@ func round2(v float64) float64 {
 	return math.Round(v*100) / 100
 }
 
-// startOfWeekMonday returns 00:00 on the Monday of t's week, in t's location.
-func startOfWeekMonday(t time.Time) time.Time {
+// budgetAnchorLastDay is the BudgetAnchorDay value meaning "the last day of
+// the month"; other anchors are capped at 28 so every month has them.
+const budgetAnchorLastDay = -1
+
+// weekdayNames are the API spellings of time.Weekday values.
+var weekdayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// parseWeekday reads a week start sent by the client.
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range weekdayNames {
+		if s == name || s == name[:3] {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// validAnchorDay reports whether a stored or requested BudgetAnchorDay is usable.
+func validAnchorDay(d int) bool {
+	return d == budgetAnchorLastDay || (d >= 1 && d <= 28)
+}
+
+// parseAnchorDay reads a budget anchor day sent by the client: a day number
+// 1–28 or the string "last".
+func parseAnchorDay(raw json.RawMessage) (int, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if strings.EqualFold(strings.TrimSpace(name), "last") {
+			return budgetAnchorLastDay, nil
+		}
+		return 0, errors.New("budget_anchor_day must be a day from 1 to 28 or \"last\"")
+	}
+	var day int
+	if err := json.Unmarshal(raw, &day); err != nil || day < 1 || day > 28 {
+		return 0, errors.New("budget_anchor_day must be a day from 1 to 28 or \"last\"")
+	}
+	return day, nil
+}
+
+// anchorDayJSON is the API form of a stored anchor day.
+func anchorDayJSON(d int) any {
+	if d == budgetAnchorLastDay {
+		return "last"
+	}
+	return d
+}
+
+// userBudgetPrefs returns the user's first day of the week and budget anchor
+// day.
+func userBudgetPrefs(uid uint) (time.Weekday, int) {
+	var prefs struct {
+		WeekStart       int
+		BudgetAnchorDay int
+	}
+	database.DB.Model(&models.User{}).Where("id = ?", uid).
+		Select("week_start", "budget_anchor_day").Scan(&prefs)
+	return budgetPrefsOf(prefs.WeekStart, prefs.BudgetAnchorDay)
+}
+
+// budgetPrefsOf normalizes stored week start and anchor values, falling back
+// to Monday and the 1st when they are unset or corrupt.
+func budgetPrefsOf(weekStart, anchor int) (time.Weekday, int) {
+	week := time.Monday
+	if weekStart >= 0 && weekStart <= 6 {
+		week = time.Weekday(weekStart)
+	}
+	if !validAnchorDay(anchor) {
+		anchor = 1
+	}
+	return week, anchor
+}
+
+// anchorDateIn is the date key the budget window rolls over on in month m of
+// year y.
+func anchorDateIn(y int, m time.Month, anchor int) time.Time {
+	if anchor == budgetAnchorLastDay {
+		// Day 0 of the next month is the last day of this one.
+		return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, anchor, 0, 0, 0, 0, time.UTC)
+}
+
+// budgetPeriodStart is the date key the budget window containing date key
+// today began on: the latest anchor date on or before it.
+func budgetPeriodStart(today time.Time, anchor int) time.Time {
+	if a := anchorDateIn(today.Year(), today.Month(), anchor); !a.After(today) {
+		return a
+	}
+	prev := time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	return anchorDateIn(prev.Year(), prev.Month(), anchor)
+}
+
+// nextBudgetPeriodStart is the anchor date following period start.
+func nextBudgetPeriodStart(start time.Time, anchor int) time.Time {
+	next := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	return anchorDateIn(next.Year(), next.Month(), anchor)
+}
+
+// startOfWeek returns 00:00 on the most recent `first` weekday on or before
+// t, in t's location.
+func startOfWeek(t time.Time, first time.Weekday) time.Time {
 	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
-	// time.Weekday: Sunday=0 … Saturday=6. Shift so Monday is the anchor.
-	offset := (int(d.Weekday()) + 6) % 7
+	offset := (int(d.Weekday()) - int(first) + 7) % 7
 	return d.AddDate(0, 0, -offset)
 }
 
 // computeBudgetWindow builds the monthly BudgetWindow for a user. Exposed
-// (unexported) helper so it can be unit-tested against a real DB.
+// (unexported) helper so it can be unit-tested against a real DB. Window,
+// week and day boundaries are taken in now's location — pass userNow(uid) —
+// and follow the user's week start and anchor day.
 func computeBudgetWindow(uid uint, goal float64, now time.Time) BudgetWindow {
 	loc := now.Location()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
-	// Last instant of the month = start of next month minus 1ns.
-	monthEnd := monthStart.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	firstWeekday, anchor := userBudgetPrefs(uid)
+	today := localDate(now, loc)
+	periodStart := budgetPeriodStart(today, anchor)
+	periodNext := nextBudgetPeriodStart(periodStart, anchor)
+
+	windowStart := dayStartIn(periodStart, loc)
+	// Last instant of the window = start of the next one minus 1ns.
+	windowEnd := dayStartIn(periodNext, loc).Add(-time.Nanosecond)
 
-	daysTotal := monthEnd.Day()
-	daysElapsed := now.Day()
+	daysTotal := daysBetween(periodStart, periodNext)
+	daysElapsed := daysBetween(periodStart, today) + 1
 	daysRemaining := daysTotal - daysElapsed + 1 // inclusive of today
 	if daysRemaining < 1 {
 		daysRemaining = 1
 	}
 
-	// Variable spend this month. No salary cycle ⇒ no fixed/savings categories,
-	// so every live expense counts. Windowed by created_at to match the cycle
-	// engine and the category donut.
+	// Variable spend this window. No salary cycle ⇒ no fixed/savings
+	// categories, so every live expense counts. Windowed by created_at to
+	// match the cycle engine and the category donut.
 	var txs []models.Transaction
 	database.DB.
 		Where("user_id = ? AND type = ? AND created_at >= ? AND created_at <= ?",
-			uid, "expense", monthStart, monthEnd).
+			uid, "expense", windowStart, windowEnd).
 		Find(&txs)
+	if withRefs, err := withRefunds(database.DB, txs); err != nil {
+		log.Printf("budget window: refunds user=%v err=%v", uid, err)
+	} else {
+		txs = withRefs
+	}
 
-	weekStart := startOfWeekMonday(now)
-	if weekStart.Before(monthStart) {
-		weekStart = monthStart
+	weekStart := startOfWeek(now, firstWeekday)
+	if weekStart.Before(windowStart) {
+		weekStart = windowStart
+	}
+	weekEnd := startOfWeek(now, firstWeekday).AddDate(0, 0, 7).Add(-time.Nanosecond)
+	if weekEnd.After(windowEnd) {
+		weekEnd = windowEnd
 	}
 
 	var spent, weekSpent float64
@@ -110,16 +236,21 @@ func computeBudgetWindow(uid uint, goal float64, now time.Time) BudgetWindow {
 	weekAllowance := math.Min(remaining/float64(daysRemaining)*7, remaining)
 
 	// Day counts for the very same week window used above. Completed days only,
-	// and never promise more week than the month window has left.
-	elapsedInWeek := min(max(int(now.Sub(weekStart).Hours()/24), 0), 7)
+	// and never promise more week than the budget window has left. Counted in
+	// calendar days, not 24h blocks, so a DST week still has seven days.
+	elapsedInWeek := min(max(daysBetween(localDate(weekStart, loc), today), 0), 7)
 	remainingInWeek := max(min(7-elapsedInWeek, daysRemaining), 0)
 
 	return BudgetWindow{
 		HasGoal:              goal > 0,
 		DefaultGoal:          DefaultMonthlyBudget,
 		MonthlyBudget:        round2(goal),
-		WindowStart:          monthStart,
-		WindowEnd:            monthEnd,
+		WindowStart:          windowStart,
+		WindowEnd:            windowEnd,
+		AnchorDay:            anchorDayJSON(anchor),
+		WeekStartsOn:         weekdayNames[firstWeekday],
+		CurrentWeekStart:     weekStart,
+		CurrentWeekEnd:       weekEnd,
 		SpentThisWindow:      round2(spent),
 		Remaining:            round2(remaining),
 		CurrentWeekAllowance: round2(weekAllowance),
@@ -149,5 +280,5 @@ func GetCurrentBudget(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, computeBudgetWindow(uid, user.MonthlySpendingGoal, time.Now()))
+	c.JSON(http.StatusOK, computeBudgetWindow(uid, user.MonthlySpendingGoal, userNow(uid)))
 }
@@ -197,3 +197,86 @@ func TestBudgetWindow_NoGoalYieldsNoPaceBasis(t *testing.T) {
 		t.Error("expected has_goal=false")
 	}
 }
+
+// Paid on the 25th with Sunday-first weeks: the window runs 25th to 24th and
+// the week counts from Sunday.
+func TestBudgetWindow_AnchorDayAndWeekStart(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "anchored", Password: "x"}
+	database.DB.Create(&user)
+	database.DB.Model(&user).Updates(map[string]interface{}{"week_start": 0, "budget_anchor_day": 25})
+	cat := models.Category{UserID: user.ID, Name: "Food"}
+	database.DB.Create(&cat)
+	addExpense(t, user.ID, cat.ID, 30, time.Date(2026, time.June, 24, 12, 0, 0, 0, time.UTC)) // previous window
+	addExpense(t, user.ID, cat.ID, 70, time.Date(2026, time.June, 26, 12, 0, 0, 0, time.UTC))
+
+	// Friday, July 10 2026.
+	bw := computeBudgetWindow(user.ID, 600, time.Date(2026, time.July, 10, 12, 0, 0, 0, time.UTC))
+	if !bw.WindowStart.Equal(time.Date(2026, time.June, 25, 0, 0, 0, 0, time.UTC)) ||
+		!bw.WindowEnd.Equal(time.Date(2026, time.July, 25, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+		t.Errorf("window: %v – %v", bw.WindowStart, bw.WindowEnd)
+	}
+	if bw.DaysTotal != 30 || bw.DaysElapsed != 16 || bw.DaysRemaining != 15 {
+		t.Errorf("day counts: total %d, elapsed %d, remaining %d", bw.DaysTotal, bw.DaysElapsed, bw.DaysRemaining)
+	}
+	if bw.SpentThisWindow != 70 {
+		t.Errorf("want 70 spent since the 25th, got %.2f", bw.SpentThisWindow)
+	}
+	if !bw.CurrentWeekStart.Equal(time.Date(2026, time.July, 5, 0, 0, 0, 0, time.UTC)) || bw.DaysElapsedInWeek != 5 {
+		t.Errorf("week should start on Sunday the 5th: %v, elapsed %d", bw.CurrentWeekStart, bw.DaysElapsedInWeek)
+	}
+	if bw.WeekStartsOn != "sunday" || bw.AnchorDay != 25 {
+		t.Errorf("prefs not reported: %v %v", bw.WeekStartsOn, bw.AnchorDay)
+	}
+
+	// Month rollover budgets replay the same anchored windows.
+	current, previous, _ := budgetWindows(user.ID, time.Date(2026, time.July, 10, 12, 0, 0, 0, time.UTC))
+	if !current.From.Equal(bw.WindowStart) || !previous[len(previous)-1].To.Equal(current.From) ||
+		!previous[len(previous)-1].From.Equal(time.Date(2026, time.May, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("rollover windows: current %+v, last previous %+v", current, previous[len(previous)-1])
+	}
+}
+
+// "Last day" anchors follow the month's length: January 31 → February 28 →
+// March 31.
+func TestBudgetWindow_LastDayAnchor(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "monthend", Password: "x"}
+	database.DB.Create(&user)
+	database.DB.Model(&user).Update("budget_anchor_day", budgetAnchorLastDay)
+
+	cases := []struct {
+		now       time.Time
+		wantStart time.Time
+		wantTotal int
+	}{
+		{time.Date(2026, time.February, 27, 12, 0, 0, 0, time.UTC), time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC), 28},
+		{time.Date(2026, time.February, 28, 12, 0, 0, 0, time.UTC), time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), 31},
+	}
+	for _, tc := range cases {
+		bw := computeBudgetWindow(user.ID, 500, tc.now)
+		if !bw.WindowStart.Equal(tc.wantStart) || bw.DaysTotal != tc.wantTotal {
+			t.Errorf("%v: want start %v over %d days, got %v over %d", tc.now, tc.wantStart, tc.wantTotal, bw.WindowStart, bw.DaysTotal)
+		}
+	}
+}
+
+func TestUpdateProfile_WeekStartAndAnchorDay(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "prefs", Password: "x"}
+	database.DB.Create(&user)
+
+	for _, body := range []map[string]any{{"week_start": "someday"}, {"budget_anchor_day": 29}, {"budget_anchor_day": "first"}} {
+		if w := callHandler(user.ID, body, UpdateProfile); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: want 400, got %d", body, w.Code)
+		}
+	}
+	if w := callHandler(user.ID, map[string]any{"week_start": "Sunday", "budget_anchor_day": "last"}, UpdateProfile); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	// Saves from clients that do not know the fields keep them.
+	callHandler(user.ID, map[string]any{"currency": "EUR"}, UpdateProfile)
+	if week, anchor := userBudgetPrefs(user.ID); week != time.Sunday || anchor != budgetAnchorLastDay {
+		t.Errorf("want sunday/last, got %v/%d", week, anchor)
+	}
+}
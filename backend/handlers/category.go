@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -22,7 +23,9 @@ func CreateCategory(c *gin.Context) {
 	}
 
 	var input struct {
-		Name string `json:"name" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
+		Bucket   string `json:"bucket"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -39,13 +42,33 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
+	if input.Bucket != "" && !categoryBuckets[input.Bucket] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket. Allowed values: need, want, savings, ignore"})
+		return
+	}
+
 	category := models.Category{
 		UserID:    userID.(uint),
 		Name:      input.Name,
+		Bucket:    input.Bucket,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
+	if input.ParentID != nil && *input.ParentID != 0 {
+		forest, err := loadCategoryForest(database.DB, userID.(uint))
+		if err != nil {
+			log.Printf("create category: load tree user=%v err=%v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+			return
+		}
+		if err := forest.checkParent(0, *input.ParentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		category.ParentID = input.ParentID
+	}
+
 	if err := database.DB.Create(&category).Error; err != nil {
 		log.Printf("create category: user=%v err=%v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
@@ -62,6 +85,19 @@ func GetCategories(c *gin.Context) {
 		return
 	}
 
+	// ?tree=true nests sub-categories under their parents; the default stays
+	// the flat list (each row carries parent_id) that existing clients expect.
+	if c.Query("tree") == "true" {
+		forest, err := loadCategoryForest(database.DB, userID.(uint))
+		if err != nil {
+			log.Printf("get categories tree: user=%v err=%v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"categories": forest.tree()})
+		return
+	}
+
 	var categories []models.Category
 	if err := database.DB.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
 		log.Printf("get categories: user=%v err=%v", userID, err)
@@ -72,6 +108,30 @@ func GetCategories(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
 
+// GetCategory → GET /api/categories/:id
+// One category, with the ETag a later update or delete can send as If-Match.
+func GetCategory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var category models.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+		} else {
+			log.Printf("get category: user=%v cat=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category"})
+		}
+		return
+	}
+
+	c.Header("ETag", categoryETag(category))
+	c.JSON(http.StatusOK, gin.H{"category": category})
+}
+
 func UpdateCategory(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -91,37 +151,94 @@ func UpdateCategory(c *gin.Context) {
 		}
 		return
 	}
+	if etag := categoryETag(category); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"category": category})
+		return
+	}
+	seen := category.UpdatedAt
 
+	// All fields are optional so a category can be moved or reclassified
+	// without renaming it; "parent_id": 0 moves it back to the top level.
 	var input struct {
-		Name string `json:"name" binding:"required"`
+		Name     *string `json:"name"`
+		ParentID *uint   `json:"parent_id"`
+		Bucket   *string `json:"bucket"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	input.Name = strings.TrimSpace(input.Name)
-	if input.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Category name cannot be empty"})
+	if input.Name == nil && input.ParentID == nil && input.Bucket == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update: pass name, parent_id and/or bucket"})
 		return
 	}
-	if len(input.Name) > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Category name must be 100 characters or fewer"})
-		return
+	if input.Bucket != nil {
+		if !categoryBuckets[*input.Bucket] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket. Allowed values: need, want, savings, ignore"})
+			return
+		}
+		category.Bucket = *input.Bucket
 	}
 
-	category.Name = input.Name
-	// Renaming a default makes it the user's own category → drop the translation
-	// key so it is shown verbatim (never re-translated) from now on.
-	category.TranslationKey = ""
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category name cannot be empty"})
+			return
+		}
+		if len(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category name must be 100 characters or fewer"})
+			return
+		}
+		category.Name = name
+		// Renaming a default makes it the user's own category → drop the translation
+		// key so it is shown verbatim (never re-translated) from now on.
+		category.TranslationKey = ""
+	}
+
+	if input.ParentID != nil {
+		if *input.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			forest, err := loadCategoryForest(database.DB, userID.(uint))
+			if err != nil {
+				log.Printf("update category: load tree user=%v cat=%v err=%v", userID, categoryID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+				return
+			}
+			if err := forest.checkParent(category.ID, *input.ParentID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			category.ParentID = input.ParentID
+		}
+	}
 	category.UpdatedAt = time.Now()
 
-	if err := database.DB.Save(&category).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Category{}, category.ID, seen); err != nil {
+			return err
+		}
+		return tx.Save(&category).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Category not found or does not belong to you", func() (string, gin.H, error) {
+			return currentCategory(userID.(uint), category.ID)
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("update category save: user=%v cat=%v err=%v", userID, categoryID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
 		return
 	}
+	// The bucket (own or inherited from the parent) feeds the cycle's
+	// needs/wants figures.
+	if input.Bucket != nil || input.ParentID != nil {
+		InvalidateCycleCache(userID.(uint))
+	}
 
+	c.Header("ETag", categoryETag(category))
 	c.JSON(http.StatusOK, gin.H{"message": "Category updated successfully", "category": category})
 }
 
@@ -139,25 +256,138 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.Category
+		if err := database.DB.Where("id = ? AND user_id = ?", uint(categoryID), userID).First(&current).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+			return
+		}
+		if etag := categoryETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"category": current})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	// ?reassign_to=N moves everything that still uses the category over to N
+	// (as a merge would) instead of refusing the delete.
+	var reassignTo *models.Category
+	if raw := c.Query("reassign_to"); raw != "" {
+		target, status, err := loadReassignTarget(userID.(uint), uint(categoryID), raw)
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				log.Printf("delete category: fetch reassign target user=%v cat=%v target=%v err=%v", userID, categoryID, raw, err)
+				c.JSON(status, gin.H{"error": "Failed to delete category"})
+			} else {
+				c.JSON(status, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		reassignTo = &target
+	}
+
 	var transactionCount int64
 	database.DB.Model(&models.Transaction{}).
 		Where("category_id = ? AND user_id = ?", uint(categoryID), userID.(uint)).
 		Count(&transactionCount)
-	if transactionCount > 0 {
+	if transactionCount > 0 && reassignTo == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete category: it has " + strconv.FormatInt(transactionCount, 10) + " associated transaction(s)"})
 		return
 	}
 
-	result := database.DB.Where("id = ? AND user_id = ?", uint(categoryID), userID).Delete(&models.Category{})
-	if result.Error != nil {
-		log.Printf("delete category: user=%v cat=%v err=%v", userID, categoryID, result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+	var splitCount int64
+	database.DB.Model(&models.TransactionSplit{}).
+		Where("category_id = ? AND user_id = ?", uint(categoryID), userID.(uint)).
+		Count(&splitCount)
+	if splitCount > 0 && reassignTo == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete category: it is used by " + strconv.FormatInt(splitCount, 10) + " split line(s)"})
+		return
+	}
+
+	var recurringCount int64
+	database.DB.Model(&models.RecurringTransaction{}).
+		Where("category_id = ? AND user_id = ?", uint(categoryID), userID.(uint)).
+		Count(&recurringCount)
+	if recurringCount > 0 && reassignTo == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete category: it is used by " + strconv.FormatInt(recurringCount, 10) + " recurring transaction(s)"})
+		return
+	}
+
+	// Sub-categories: ?children=block (default) refuses to delete a parent;
+	// ?children=reparent moves them up to the deleted category's own parent.
+	childMode := c.DefaultQuery("children", "block")
+	if childMode != "block" && childMode != "reparent" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid children. Allowed values: block, reparent"})
+		return
+	}
+	var category models.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(categoryID), userID).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+		} else {
+			log.Printf("delete category fetch: user=%v cat=%v err=%v", userID, categoryID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		}
+		return
+	}
+	var childCount int64
+	database.DB.Model(&models.Category{}).
+		Where("parent_id = ? AND user_id = ?", category.ID, userID.(uint)).
+		Count(&childCount)
+	if childCount > 0 && childMode == "block" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete category: it has " + strconv.FormatInt(childCount, 10) + " sub-category(ies) — pass children=reparent to move them up a level"})
+		return
+	}
+
+	// Moving children one level up can only make the tree shallower, so no
+	// depth re-check is needed.
+	var moved categoryReassignment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Category{}, category.ID, seen); err != nil {
+			return err
+		}
+		if reassignTo != nil {
+			var err error
+			if moved, err = reassignCategory(tx, userID.(uint), category.ID, reassignTo.ID); err != nil {
+				return err
+			}
+		}
+		if childCount > 0 {
+			if err := tx.Model(&models.Category{}).
+				Where("parent_id = ? AND user_id = ?", category.ID, userID.(uint)).
+				Updates(map[string]any{"parent_id": category.ParentID, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+		// A budget still attached here (none after a reassign) goes with it.
+		if err := tx.Where("user_id = ? AND category_id = ?", userID.(uint), category.ID).Delete(&models.CategoryBudget{}).Error; err != nil {
+			return err
+		}
+		// Rules still pointing here keep their other actions.
+		if err := tx.Model(&models.CategoryRule{}).Where("user_id = ? AND category_id = ?", userID.(uint), category.ID).
+			Updates(map[string]any{"category_id": 0, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Category not found or does not belong to you", func() (string, gin.H, error) {
+			return currentCategory(userID.(uint), category.ID)
+		})
 		return
 	}
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+	if err != nil {
+		log.Printf("delete category: user=%v cat=%v err=%v", userID, categoryID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
 		return
 	}
 
+	if reassignTo != nil {
+		InvalidateCycleCache(userID.(uint))
+		ScheduleBrainResync(userID.(uint))
+		c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully", "reassigned_to": reassignTo.ID, "moved": moved})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log"
+	"math"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Category buckets (need / want / savings / ignore) ────────────────────────
+//
+// The salary cycle splits income into Needs / Wants / Savings limits; a
+// category's bucket says which of them its spending counts against, so
+// CycleStats can report "needs: 410 of 900 spent" instead of one lump of
+// variable expenses. "ignore" keeps a category out of the split altogether.
+
+const (
+	bucketNeed    = "need"
+	bucketWant    = "want"
+	bucketSavings = "savings"
+	bucketIgnore  = "ignore"
+)
+
+var categoryBuckets = map[string]bool{bucketNeed: true, bucketWant: true, bucketSavings: true, bucketIgnore: true}
+
+// defaultBucketByKey is the bucket each built-in category starts in, keyed by
+// translation key so it applies in every seed language.
+var defaultBucketByKey = map[string]string{
+	"category.food":           bucketNeed,
+	"category.clothing":       bucketWant,
+	"category.entertainment":  bucketWant,
+	"category.beauty":         bucketWant,
+	"category.income":         bucketIgnore,
+	"category.fixed_payments": bucketNeed,
+	"category.saved_money":    bucketSavings,
+}
+
+// defaultCategoryBucket returns the starting bucket for a translation key, or
+// "" for a user-created category.
+func defaultCategoryBucket(key string) string {
+	return defaultBucketByKey[key]
+}
+
+// bucketsByCategory resolves the effective bucket of each of the user's
+// categories: its own, else the nearest ancestor's, else "".
+func bucketsByCategory(uid uint) map[uint]string {
+	forest, err := loadCategoryForest(database.DB, uid)
+	if err != nil {
+		log.Printf("category buckets: user=%v err=%v", uid, err)
+		return map[uint]string{}
+	}
+	out := make(map[uint]string, len(forest.byID))
+	for id, cat := range forest.byID {
+		b := cat.Bucket
+		for _, a := range forest.ancestors(id) {
+			if b != "" {
+				break
+			}
+			b = forest.byID[a].Bucket
+		}
+		out[id] = b
+	}
+	return out
+}
+
+// bucketSpend is the variable spending of a cycle broken down by bucket.
+type bucketSpend struct {
+	Needs, Wants, Savings, Unclassified float64
+}
+
+func (b *bucketSpend) add(bucket string, amount float64) {
+	switch bucket {
+	case bucketNeed:
+		b.Needs += amount
+	case bucketWant:
+		b.Wants += amount
+	case bucketSavings:
+		b.Savings += amount
+	case bucketIgnore:
+	default:
+		b.Unclassified += amount
+	}
+}
+
+// weeklyShare spreads what is left of a limit over the remaining days of the
+// cycle, in 7-day portions. Never negative.
+func weeklyShare(remaining float64, daysRemaining int) float64 {
+	if remaining <= 0 {
+		return 0
+	}
+	if daysRemaining <= 7 {
+		return remaining
+	}
+	return math.Round(remaining/float64(daysRemaining)*7*100) / 100
+}
+
+// MigrateDefaultCategoryBuckets gives existing built-in categories their
+// default bucket. Idempotent: it only fills buckets that were never set, so a
+// user's own choice is never overwritten.
+func MigrateDefaultCategoryBuckets() {
+	updated := int64(0)
+	for key, bucket := range defaultBucketByKey {
+		res := database.DB.Model(&models.Category{}).
+			Where("translation_key = ? AND (bucket IS NULL OR bucket = '')", key).
+			UpdateColumn("bucket", bucket)
+		if res.Error != nil {
+			log.Printf("category-bucket migration: key=%s err=%v", key, res.Error)
+			continue
+		}
+		updated += res.RowsAffected
+	}
+	if updated > 0 {
+		log.Printf("category-bucket migration: classified %d default category(ies)", updated)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// Variable spending is reported per bucket against the cycle's Needs / Wants
+// limits; a sub-category without a bucket inherits its parent's.
+func TestCycleStats_Buckets(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "bucketeer", Password: "x"}
+	database.DB.Create(&user)
+	food := models.Category{UserID: user.ID, Name: "Food", Bucket: bucketNeed}
+	fun := models.Category{UserID: user.ID, Name: "Fun", Bucket: bucketWant}
+	misc := models.Category{UserID: user.ID, Name: "Misc"}
+	database.DB.Create(&food)
+	database.DB.Create(&fun)
+	database.DB.Create(&misc)
+	lunch := models.Category{UserID: user.ID, Name: "Lunch", ParentID: &food.ID}
+	database.DB.Create(&lunch)
+
+	now := time.Now()
+	cycle := models.SalaryCycle{
+		UserID: user.ID, TotalIncome: 2000, SavingsPct: 20, CycleStartAt: now.AddDate(0, 0, -2),
+		NeedsLimit: 1000, WantsLimit: 600, FixedNeedsTotal: 400,
+	}
+	database.DB.Create(&cycle)
+	for _, tx := range []struct {
+		cat    uint
+		amount float64
+	}{{food.ID, 50}, {lunch.ID, 25}, {fun.ID, 80}, {misc.ID, 10}} {
+		database.DB.Create(&models.Transaction{UserID: user.ID, CategoryID: tx.cat, Amount: tx.amount, Type: "expense", Date: now, CreatedAt: now})
+	}
+
+	s := computeCycleStats(user.ID, cycle)
+	if s.NeedsSpent != 475 || s.NeedsRemaining != 525 {
+		t.Errorf("needs: want 475 spent / 525 left, got %.2f / %.2f", s.NeedsSpent, s.NeedsRemaining)
+	}
+	if s.WantsSpent != 80 || s.WantsRemaining != 520 {
+		t.Errorf("wants: want 80 spent / 520 left, got %.2f / %.2f", s.WantsSpent, s.WantsRemaining)
+	}
+	if s.UnclassifiedSpent != 10 || s.CycleVariableExpenses != 165 {
+		t.Errorf("want 10 unclassified of 165 variable, got %.2f of %.2f", s.UnclassifiedSpent, s.CycleVariableExpenses)
+	}
+	if s.NeedsWeeklyAllowance <= 0 || s.NeedsWeeklyAllowance > s.NeedsRemaining {
+		t.Errorf("needs weekly allowance out of range: %.2f", s.NeedsWeeklyAllowance)
+	}
+}
+
+func TestCategoryBucket_Validation(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "classifier", Password: "x"}
+	database.DB.Create(&user)
+	if w := callHandler(user.ID, map[string]any{"name": "Rent", "bucket": "luxury"}, CreateCategory); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown bucket: want 400, got %d", w.Code)
+	}
+	cat := createCategory(t, user.ID, "Rent", 0)
+	if w := callHandlerParamBody(user.ID, catParam(cat.ID), map[string]any{"bucket": "need"}, UpdateCategory); w.Code != http.StatusOK {
+		t.Fatalf("set bucket: %d %s", w.Code, w.Body.String())
+	}
+	var got models.Category
+	database.DB.First(&got, cat.ID)
+	if got.Bucket != bucketNeed || got.Name != "Rent" {
+		t.Errorf("want Rent classified as need, got %+v", got)
+	}
+}
+
+// Built-in categories get their default bucket once; a user's choice stays.
+func TestCategoryBucketMigration(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "bucketmig", Password: "x"}
+	database.DB.Create(&user)
+	food := models.Category{UserID: user.ID, Name: "Food", TranslationKey: "category.food"}
+	beauty := models.Category{UserID: user.ID, Name: "Beauty", TranslationKey: "category.beauty", Bucket: bucketNeed}
+	own := models.Category{UserID: user.ID, Name: "Hobby"}
+	database.DB.Create(&food)
+	database.DB.Create(&beauty)
+	database.DB.Create(&own)
+
+	MigrateDefaultCategoryBuckets()
+	MigrateDefaultCategoryBuckets()
+
+	for _, tc := range []struct {
+		id   uint
+		want string
+	}{{food.ID, bucketNeed}, {beauty.ID, bucketNeed}, {own.ID, ""}} {
+		var got models.Category
+		database.DB.First(&got, tc.id)
+		if got.Bucket != tc.want {
+			t.Errorf("%s: want bucket %q, got %q", got.Name, tc.want, got.Bucket)
+		}
+	}
+}
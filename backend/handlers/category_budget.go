@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Per-category budgets ─────────────────────────────────────────────────────
+//
+// A CategoryBudget caps one category (and its sub-categories) per budget
+// window. The window is the one the rest of the app already uses for the user:
+// the active salary cycle if there is one, otherwise the anchored month of
+// computeBudgetWindow. Rollover replays the earlier windows of the same kind,
+// oldest first, carrying each one's leftover (or overspend) forward.
+
+// maxRolloverWindows bounds how far back rollover is replayed.
+const maxRolloverWindows = 24
+
+// spendWindow is a half-open [From, To) budget window.
+type spendWindow struct {
+	From, To time.Time
+}
+
+// categoryBudgetStatus is one row of GET /budget/categories.
+type categoryBudgetStatus struct {
+	models.CategoryBudget
+	CarriedOver float64 `json:"carried_over"`
+	Available   float64 `json:"available"` // limit + carried_over
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	// Pace compares spending with the share of the window that has passed:
+	// 1 = on pace, above 1 = the money runs out before the window does.
+	Pace   float64 `json:"pace"`
+	Status string  `json:"status"` // ok | warning (ahead of pace) | over
+}
+
+// budgetWindows returns the current budget window and up to
+// maxRolloverWindows earlier ones (oldest first). inCycle reports whether the
+// windows are salary cycles rather than anchored months. Boundaries are local
+// midnights in now's location — pass userNow(uid).
+func budgetWindows(uid uint, now time.Time) (current spendWindow, previous []spendWindow, inCycle bool) {
+	var cycles []models.SalaryCycle
+	database.DB.Where("user_id = ?", uid).Order("cycle_start_at ASC").Find(&cycles)
+	loc := now.Location()
+	today := localDate(now, loc)
+	for i := len(cycles) - 1; i >= 0; i-- {
+		c := cycles[i]
+		if !isDateInCycleWindow(today, c) {
+			continue
+		}
+		current = cycleSpendWindow(c, now)
+		// Earlier cycles run until the next one starts, so gaps between
+		// cycles still count somewhere.
+		for j := max(0, i-maxRolloverWindows); j < i; j++ {
+			previous = append(previous, spendWindow{
+				From: dayStartIn(toDateOnly(cycles[j].CycleStartAt), loc),
+				To:   dayStartIn(toDateOnly(cycles[j+1].CycleStartAt), loc),
+			})
+		}
+		return current, previous, true
+	}
+
+	// No cycle: the anchored month windows of computeBudgetWindow.
+	_, anchor := userBudgetPrefs(uid)
+	start := budgetPeriodStart(today, anchor)
+	current = spendWindow{From: dayStartIn(start, loc), To: dayStartIn(nextBudgetPeriodStart(start, anchor), loc)}
+	previous = make([]spendWindow, maxRolloverWindows)
+	for k := maxRolloverWindows - 1; k >= 0; k-- {
+		prev := budgetPeriodStart(start.AddDate(0, 0, -1), anchor)
+		previous[k] = spendWindow{From: dayStartIn(prev, loc), To: dayStartIn(start, loc)}
+		start = prev
+	}
+	return current, previous, false
+}
+
+// cycleSpendWindow is a salary cycle's window, from the start of its first
+// day through the end of payday, or 30 days (the stats engine's assumption)
+// for an open-ended cycle, stretched to today if the cycle has outrun that.
+// Days are those of now's location.
+func cycleSpendWindow(c models.SalaryCycle, now time.Time) spendWindow {
+	loc := now.Location()
+	start := toDateOnly(c.CycleStartAt)
+	w := spendWindow{From: dayStartIn(start, loc)}
+	if c.NextPaydayAt != nil {
+		w.To = dayStartIn(toDateOnly(*c.NextPaydayAt).AddDate(0, 0, 1), loc)
+		return w
+	}
+	w.To = dayStartIn(start.AddDate(0, 0, 30), loc)
+	if !now.Before(w.To) {
+		w.To = dayStartIn(localDate(now, loc).AddDate(0, 0, 1), loc)
+	}
+	return w
+}
+
+// spentByCategory sums expense lines per category over w (split-aware).
+func spentByCategory(db *gorm.DB, uid uint, w spendWindow) (map[uint]float64, error) {
+	var rows []struct {
+		CategoryID uint    `gorm:"column:category_id"`
+		Total      float64 `gorm:"column:total"`
+	}
+	if err := db.Table(categoryLinesTable).
+		Select("tx_lines.category_id AS category_id, SUM(tx_lines.amount) AS total").
+		Where("tx_lines.user_id = ? AND tx_lines.type = ? AND tx_lines.created_at >= ? AND tx_lines.created_at < ?",
+			uid, "expense", w.From, w.To).
+		Group("tx_lines.category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]float64, len(rows))
+	for _, r := range rows {
+		out[r.CategoryID] = r.Total
+	}
+	return out, nil
+}
+
+// spentUnder totals a budget category and all of its sub-categories.
+func spentUnder(forest *categoryForest, categoryID uint, byCat map[uint]float64) float64 {
+	var sum float64
+	for id, amount := range byCat {
+		if id == categoryID || forest.isDescendant(id, categoryID) {
+			sum += amount
+		}
+	}
+	return sum
+}
+
+// computeCategoryBudgets evaluates every budget of the user in the current
+// window.
+func computeCategoryBudgets(uid uint, budgets []models.CategoryBudget, now time.Time) (spendWindow, bool, []categoryBudgetStatus, error) {
+	current, previous, inCycle := budgetWindows(uid, now)
+	forest, err := loadCategoryForest(database.DB, uid)
+	if err != nil {
+		return current, inCycle, nil, err
+	}
+	spentNow, err := spentByCategory(database.DB, uid, current)
+	if err != nil {
+		return current, inCycle, nil, err
+	}
+
+	// Earlier windows are only queried when some budget rolls over, and then
+	// only once each.
+	pastSpend := make([]map[uint]float64, len(previous))
+	carry := func(b models.CategoryBudget) (float64, error) {
+		var carried float64
+		for i, w := range previous {
+			if !w.To.After(b.CreatedAt) {
+				continue // the budget did not exist yet
+			}
+			if pastSpend[i] == nil {
+				if pastSpend[i], err = spentByCategory(database.DB, uid, w); err != nil {
+					return 0, err
+				}
+			}
+			carried = b.Limit + carried - spentUnder(forest, b.CategoryID, pastSpend[i])
+		}
+		return carried, nil
+	}
+
+	elapsed := float64(now.Sub(current.From)) / float64(current.To.Sub(current.From))
+	elapsed = min(max(elapsed, 0), 1)
+
+	out := make([]categoryBudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		s := categoryBudgetStatus{CategoryBudget: b, Status: "ok"}
+		if b.Rollover {
+			if s.CarriedOver, err = carry(b); err != nil {
+				return current, inCycle, nil, err
+			}
+		}
+		s.Available = b.Limit + s.CarriedOver
+		s.Spent = spentUnder(forest, b.CategoryID, spentNow)
+		s.Remaining = s.Available - s.Spent
+		if expected := s.Available * elapsed; expected > 0 {
+			s.Pace = round2(s.Spent / expected)
+		}
+		switch {
+		case s.Remaining < 0:
+			s.Status = "over"
+		case s.Pace > 1:
+			s.Status = "warning"
+		}
+		s.CarriedOver, s.Available, s.Spent, s.Remaining = round2(s.CarriedOver), round2(s.Available), round2(s.Spent), round2(s.Remaining)
+		out = append(out, s)
+	}
+	return current, inCycle, out, nil
+}
+
+func CreateCategoryBudget(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		CategoryID uint    `json:"category_id" binding:"required"`
+		Limit      float64 `json:"limit" binding:"required,gt=0"`
+		Rollover   bool    `json:"rollover"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cat models.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", input.CategoryID, uid).First(&cat).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found or does not belong to you"})
+		return
+	}
+	var n int64
+	database.DB.Model(&models.CategoryBudget{}).Where("user_id = ? AND category_id = ?", uid, cat.ID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This category already has a budget"})
+		return
+	}
+
+	budget := models.CategoryBudget{
+		UserID: uid, CategoryID: cat.ID, Limit: round2(input.Limit), Rollover: input.Rollover,
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := database.DB.Create(&budget).Error; err != nil {
+		log.Printf("create category budget: user=%v cat=%v err=%v", uid, cat.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget"})
+		return
+	}
+	budget.Category = cat
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Budget created successfully", "budget": budget})
+}
+
+// GetCategoryBudgets → GET /api/budget/categories
+// Limit, carried-over amount, spent, remaining and pace for every budget in
+// the current window.
+func GetCategoryBudgets(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var budgets []models.CategoryBudget
+	if err := database.DB.Preload("Category").Where("user_id = ?", uid).Order("id asc").Find(&budgets).Error; err != nil {
+		log.Printf("get category budgets: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+		return
+	}
+
+	window, inCycle, rows, err := computeCategoryBudgets(uid, budgets, userNow(uid))
+	if err != nil {
+		log.Printf("get category budgets: compute user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute budgets"})
+		return
+	}
+	over := 0
+	for _, r := range rows {
+		if r.Status == "over" {
+			over++
+		}
+	}
+	mode := "month"
+	if inCycle {
+		mode = "cycle"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"window_start": window.From,
+		"window_end":   window.To,
+		"window_type":  mode,
+		"budgets":      rows,
+		"over_budget":  over,
+	})
+}
+
+// GetCategoryBudget → GET /api/budget/categories/:id
+// One budget, with the ETag a later update or delete can send as If-Match.
+func GetCategoryBudget(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var budget models.CategoryBudget
+	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found or does not belong to you"})
+		} else {
+			log.Printf("get category budget: user=%v budget=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budget"})
+		}
+		return
+	}
+
+	c.Header("ETag", categoryBudgetETag(budget))
+	c.JSON(http.StatusOK, gin.H{"budget": budget})
+}
+
+func UpdateCategoryBudget(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var budget models.CategoryBudget
+	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found or does not belong to you"})
+		} else {
+			log.Printf("update category budget fetch: user=%v budget=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budget"})
+		}
+		return
+	}
+	if etag := categoryBudgetETag(budget); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"budget": budget})
+		return
+	}
+	seen := budget.UpdatedAt
+
+	var input struct {
+		Limit    *float64 `json:"limit"`
+		Rollover *bool    `json:"rollover"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Limit != nil {
+		if *input.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be greater than zero"})
+			return
+		}
+		budget.Limit = round2(*input.Limit)
+	}
+	if input.Rollover != nil {
+		budget.Rollover = *input.Rollover
+	}
+	budget.UpdatedAt = time.Now()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.CategoryBudget{}, budget.ID, seen); err != nil {
+			return err
+		}
+		return tx.Omit("Category").Save(&budget).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Budget not found or does not belong to you", func() (string, gin.H, error) {
+			return currentCategoryBudget(userID.(uint), budget.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update category budget save: user=%v budget=%v err=%v", userID, budget.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget"})
+		return
+	}
+
+	c.Header("ETag", categoryBudgetETag(budget))
+	c.JSON(http.StatusOK, gin.H{"message": "Budget updated successfully", "budget": budget})
+}
+
+func DeleteCategoryBudget(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID format"})
+		return
+	}
+
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.CategoryBudget
+		if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", uint(budgetID), userID).First(&current).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found or does not belong to you"})
+			return
+		}
+		if etag := categoryBudgetETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"budget": current})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.CategoryBudget{}, uint(budgetID), seen); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND user_id = ?", uint(budgetID), userID).Delete(&models.CategoryBudget{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Budget not found or does not belong to you", func() (string, gin.H, error) {
+			return currentCategoryBudget(userID.(uint), uint(budgetID))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete category budget: user=%v budget=%v err=%v", userID, budgetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found or does not belong to you"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
+}
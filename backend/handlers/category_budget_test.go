@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func addExpense(t *testing.T, uid, cat uint, amount float64, at time.Time) {
+	t.Helper()
+	tx := models.Transaction{UserID: uid, CategoryID: cat, Amount: amount, Type: "expense", IncomeType: "one_time", Date: at, CreatedAt: at}
+	if err := database.DB.Create(&tx).Error; err != nil {
+		t.Fatalf("seed expense: %v", err)
+	}
+}
+
+// Month mode: a parent budget covers its sub-categories, rollover carries last
+// month's leftover, and overspending is flagged.
+func TestCategoryBudgets_MonthRolloverAndOverspend(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "budgeter", Password: "x"}
+	database.DB.Create(&user)
+	food := models.Category{UserID: user.ID, Name: "Food"}
+	fun := models.Category{UserID: user.ID, Name: "Entertainment"}
+	database.DB.Create(&food)
+	database.DB.Create(&fun)
+	rest := models.Category{UserID: user.ID, Name: "Restaurants", ParentID: &food.ID}
+	database.DB.Create(&rest)
+
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	budgets := []models.CategoryBudget{
+		{UserID: user.ID, CategoryID: food.ID, Limit: 300, Rollover: true, CreatedAt: time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{UserID: user.ID, CategoryID: fun.ID, Limit: 80, CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for i := range budgets {
+		database.DB.Create(&budgets[i])
+	}
+	addExpense(t, user.ID, food.ID, 250, time.Date(2026, 4, 10, 9, 0, 0, 0, time.UTC)) // April: 50 left over
+	addExpense(t, user.ID, food.ID, 999, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)) // before the budget existed
+	addExpense(t, user.ID, rest.ID, 100, time.Date(2026, 5, 2, 19, 0, 0, 0, time.UTC)) // sub-category
+	addExpense(t, user.ID, fun.ID, 100, time.Date(2026, 5, 3, 21, 0, 0, 0, time.UTC))  // over the 80 limit
+	addExpense(t, user.ID, fun.ID, 500, time.Date(2026, 4, 3, 21, 0, 0, 0, time.UTC))  // no rollover: ignored
+
+	window, inCycle, rows, err := computeCategoryBudgets(user.ID, budgets, now)
+	if err != nil || inCycle || !window.From.Equal(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("want the May month window, got %+v cycle=%v err=%v", window, inCycle, err)
+	}
+	f, e := rows[0], rows[1]
+	if f.CarriedOver != 50 || f.Available != 350 || f.Spent != 100 || f.Remaining != 250 || f.Status != "ok" {
+		t.Errorf("food: %+v", f)
+	}
+	if e.CarriedOver != 0 || e.Spent != 100 || e.Remaining != -20 || e.Status != "over" {
+		t.Errorf("entertainment: %+v", e)
+	}
+}
+
+// Inside an active salary cycle the cycle window is used instead of the month.
+func TestCategoryBudgets_CycleWindow(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "cyclebudget", Password: "x"}
+	database.DB.Create(&user)
+	food := models.Category{UserID: user.ID, Name: "Food"}
+	database.DB.Create(&food)
+
+	now := time.Now()
+	payday := now.AddDate(0, 0, 20)
+	database.DB.Create(&models.SalaryCycle{UserID: user.ID, TotalIncome: 1000, CycleStartAt: now.AddDate(0, 0, -10), NextPaydayAt: &payday})
+	addExpense(t, user.ID, food.ID, 40, now.AddDate(0, 0, -5))
+	addExpense(t, user.ID, food.ID, 60, now.AddDate(0, 0, -15)) // before the cycle
+
+	if w := callHandler(user.ID, map[string]any{"category_id": food.ID, "limit": 300}, CreateCategoryBudget); w.Code != http.StatusCreated {
+		t.Fatalf("create budget: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandler(user.ID, map[string]any{"category_id": food.ID, "limit": 100}, CreateCategoryBudget); w.Code != http.StatusConflict {
+		t.Errorf("second budget for the same category: want 409, got %d", w.Code)
+	}
+
+	w := callHandlerGET(user.ID, "", GetCategoryBudgets)
+	var resp struct {
+		WindowType string                 `json:"window_type"`
+		Budgets    []categoryBudgetStatus `json:"budgets"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.WindowType != "cycle" || len(resp.Budgets) != 1 || resp.Budgets[0].Spent != 40 {
+		t.Errorf("want 40 spent in the cycle window, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Category merge / reassign ────────────────────────────────────────────────
+//
+// Folding one category into another rewrites every reference to it — live and
+// trashed transactions, split lines, recurring templates, rules, its budget and the
+// salary-cycle Fixed Payments / Saved Money pointers — inside one DB transaction, so a
+// failure half-way leaves nothing pointing at a category that is about to go.
+
+// categoryReassignment counts the rows moved from one category to another.
+type categoryReassignment struct {
+	Transactions int64 `json:"transactions"`
+	SplitLines   int64 `json:"split_lines"`
+	Recurring    int64 `json:"recurring"`
+	SalaryCycles int64 `json:"salary_cycles"`
+	Rules        int64 `json:"rules"`
+}
+
+// reassignCategory points every row of uid that references from at to instead.
+// Soft-deleted transactions are moved too so a later restore does not hit a
+// missing category.
+func reassignCategory(tx *gorm.DB, uid, from, to uint) (categoryReassignment, error) {
+	var moved categoryReassignment
+	now := time.Now()
+
+	res := tx.Unscoped().Model(&models.Transaction{}).
+		Where("user_id = ? AND category_id = ?", uid, from).
+		Updates(map[string]any{"category_id": to, "updated_at": now})
+	if res.Error != nil {
+		return moved, res.Error
+	}
+	moved.Transactions = res.RowsAffected
+
+	res = tx.Model(&models.TransactionSplit{}).
+		Where("user_id = ? AND category_id = ?", uid, from).
+		Updates(map[string]any{"category_id": to, "updated_at": now})
+	if res.Error != nil {
+		return moved, res.Error
+	}
+	moved.SplitLines = res.RowsAffected
+
+	res = tx.Model(&models.RecurringTransaction{}).
+		Where("user_id = ? AND category_id = ?", uid, from).
+		Updates(map[string]any{"category_id": to, "updated_at": now})
+	if res.Error != nil {
+		return moved, res.Error
+	}
+	moved.Recurring = res.RowsAffected
+
+	res = tx.Model(&models.CategoryRule{}).
+		Where("user_id = ? AND category_id = ?", uid, from).
+		Updates(map[string]any{"category_id": to, "updated_at": now})
+	if res.Error != nil {
+		return moved, res.Error
+	}
+	moved.Rules = res.RowsAffected
+
+	// A budget follows its category unless the target already has one, in
+	// which case the target's limit is kept.
+	var targetBudgets int64
+	if err := tx.Model(&models.CategoryBudget{}).Where("user_id = ? AND category_id = ?", uid, to).Count(&targetBudgets).Error; err != nil {
+		return moved, err
+	}
+	budgets := tx.Where("user_id = ? AND category_id = ?", uid, from)
+	if targetBudgets > 0 {
+		res = budgets.Delete(&models.CategoryBudget{})
+	} else {
+		res = budgets.Model(&models.CategoryBudget{}).Updates(map[string]any{"category_id": to, "updated_at": now})
+	}
+	if res.Error != nil {
+		return moved, res.Error
+	}
+
+	for _, col := range []string{"fixed_exp_category_id", "saved_money_category_id"} {
+		res = tx.Model(&models.SalaryCycle{}).
+			Where("user_id = ? AND "+col+" = ?", uid, from).
+			Update(col, to)
+		if res.Error != nil {
+			return moved, res.Error
+		}
+		moved.SalaryCycles += res.RowsAffected
+	}
+	return moved, nil
+}
+
+// mergedTranslationKey decides the target's key after a merge. The target
+// keeps its own name and key; it only adopts the source's key when it has
+// none and its name is itself one of that default's seeded names (e.g. a
+// stray "Essen" merged with the keyed "Food"). A user-named target is never
+// turned into a translated default.
+func mergedTranslationKey(source, target models.Category) string {
+	if target.TranslationKey != "" || source.TranslationKey == "" {
+		return target.TranslationKey
+	}
+	if defaultCategoryKey(target.Name) == source.TranslationKey {
+		return source.TranslationKey
+	}
+	return ""
+}
+
+// loadReassignTarget fetches the user's target category for a merge or a
+// delete-with-reassign, rejecting the source itself.
+func loadReassignTarget(uid, sourceID uint, raw string) (models.Category, int, error) {
+	var target models.Category
+	targetID, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return target, http.StatusBadRequest, errors.New("Invalid target category ID format")
+	}
+	if uint(targetID) == sourceID {
+		return target, http.StatusBadRequest, errors.New("The target category must differ from the one being removed")
+	}
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(targetID), uid).First(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return target, http.StatusNotFound, errors.New("Target category not found or does not belong to you")
+		}
+		return target, http.StatusInternalServerError, err
+	}
+	return target, 0, nil
+}
+
+// MergeCategory → POST /api/categories/:id/merge-into/:target
+//
+// Moves everything that uses :id over to :target, moves :id's sub-categories
+// under :target, and deletes :id.
+func MergeCategory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID format"})
+		return
+	}
+
+	forest, err := loadCategoryForest(database.DB, uid)
+	if err != nil {
+		log.Printf("merge category: load tree user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge category"})
+		return
+	}
+	source, ok := forest.byID[uint(sourceID)]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+		return
+	}
+	target, status, err := loadReassignTarget(uid, source.ID, c.Param("target"))
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			log.Printf("merge category: fetch target user=%v cat=%v target=%v err=%v", uid, source.ID, c.Param("target"), err)
+			c.JSON(status, gin.H{"error": "Failed to merge category"})
+		} else {
+			c.JSON(status, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// The source's sub-categories end up under the target, which must not be
+	// one of them and must leave room for their subtrees.
+	if forest.isDescendant(target.ID, source.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be merged into one of its own sub-categories"})
+		return
+	}
+	for _, child := range forest.children[source.ID] {
+		if forest.depth(target.ID)+forest.height(child) > maxCategoryDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Merging would nest sub-categories more than " + strconv.Itoa(maxCategoryDepth) + " levels deep"})
+			return
+		}
+	}
+
+	var moved categoryReassignment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if moved, err = reassignCategory(tx, uid, source.ID, target.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Category{}).
+			Where("user_id = ? AND parent_id = ?", uid, source.ID).
+			Updates(map[string]any{"parent_id": target.ID, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if key := mergedTranslationKey(source, target); key != target.TranslationKey {
+			// UpdateColumn, not Save: Save would bump updated_at, which is what
+			// MigrateDefaultCategoryKeys reads as "the user edited this".
+			if err := tx.Model(&models.Category{}).Where("id = ?", target.ID).
+				UpdateColumn("translation_key", key).Error; err != nil {
+				return err
+			}
+			target.TranslationKey = key
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		log.Printf("merge category: user=%v cat=%v target=%v err=%v", uid, source.ID, target.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge category"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categories merged successfully", "category": target, "moved": moved})
+}
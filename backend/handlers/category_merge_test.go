@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func mergeParams(id, target uint) gin.Params {
+	return gin.Params{
+		{Key: "id", Value: strconv.FormatUint(uint64(id), 10)},
+		{Key: "target", Value: strconv.FormatUint(uint64(target), 10)},
+	}
+}
+
+// Merging moves live and trashed transactions, split lines, recurring
+// templates, cycle pointers and sub-categories, then removes the source.
+func TestMergeCategory_MovesEverything(t *testing.T) {
+	f := seedSplitUser(t)
+	createSplitTx(t, f) // Food 35.5 / Beauty 14.5
+	trashed := models.Transaction{UserID: f.user.ID, CategoryID: f.beauty.ID, Amount: 3, Date: time.Now(), Type: "expense", IncomeType: "one_time"}
+	database.DB.Create(&trashed)
+	database.DB.Delete(&trashed)
+	rec := models.RecurringTransaction{UserID: f.user.ID, CategoryID: f.beauty.ID, Amount: 9, Type: "expense", Frequency: "monthly", Interval: 1, StartDate: time.Now()}
+	database.DB.Create(&rec)
+	cycle := models.SalaryCycle{UserID: f.user.ID, TotalIncome: 1000, CycleStartAt: time.Now(), SavedMoneyCategoryID: f.beauty.ID}
+	database.DB.Create(&cycle)
+	child := createCategory(t, f.user.ID, "Haircuts", f.beauty.ID)
+
+	w := callHandler(f.user.ID, nil, func(c *gin.Context) { c.Params = mergeParams(f.beauty.ID, f.food.ID); MergeCategory(c) })
+	if w.Code != http.StatusOK {
+		t.Fatalf("merge: %d %s", w.Code, w.Body.String())
+	}
+
+	var n int64
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("category_id = ?", f.beauty.ID).Count(&n)
+	if n != 0 {
+		t.Errorf("%d transaction(s) still point at the merged category", n)
+	}
+	database.DB.Model(&models.TransactionSplit{}).Where("category_id = ?", f.food.ID).Count(&n)
+	if n != 2 {
+		t.Errorf("want both split lines in Food, got %d", n)
+	}
+	var gotRec models.RecurringTransaction
+	database.DB.First(&gotRec, rec.ID)
+	var gotCycle models.SalaryCycle
+	database.DB.First(&gotCycle, cycle.ID)
+	var gotChild models.Category
+	database.DB.First(&gotChild, child.ID)
+	if gotRec.CategoryID != f.food.ID || gotCycle.SavedMoneyCategoryID != f.food.ID {
+		t.Errorf("recurring/cycle not rewritten: rec=%d cycle=%d", gotRec.CategoryID, gotCycle.SavedMoneyCategoryID)
+	}
+	if gotChild.ParentID == nil || *gotChild.ParentID != f.food.ID {
+		t.Errorf("sub-category should move under the target, got %v", gotChild.ParentID)
+	}
+	database.DB.Model(&models.Category{}).Where("id = ?", f.beauty.ID).Count(&n)
+	if n != 0 {
+		t.Error("merged category should be deleted")
+	}
+}
+
+func TestMergeCategory_TranslationKey(t *testing.T) {
+	keyed := models.Category{Name: "Food", TranslationKey: "category.food"}
+	if got := mergedTranslationKey(keyed, models.Category{Name: "Essen"}); got != "category.food" {
+		t.Errorf("an unkeyed default-named target should adopt the key, got %q", got)
+	}
+	if got := mergedTranslationKey(keyed, models.Category{Name: "Groceries"}); got != "" {
+		t.Errorf("a user-named target must stay verbatim, got %q", got)
+	}
+	if got := mergedTranslationKey(keyed, models.Category{Name: "Beauty", TranslationKey: "category.beauty"}); got != "category.beauty" {
+		t.Errorf("the target's own key wins, got %q", got)
+	}
+}
+
+// A category in use can be deleted once a reassign target is named.
+func TestDeleteCategory_ReassignTo(t *testing.T) {
+	f := seedSplitUser(t)
+	tx := models.Transaction{UserID: f.user.ID, CategoryID: f.rent.ID, Amount: 500, Date: time.Now(), Type: "expense", IncomeType: "one_time"}
+	database.DB.Create(&tx)
+
+	del := func(query string) int {
+		return callHandlerParam(f.user.ID, catParam(f.rent.ID), func(c *gin.Context) {
+			c.Request.URL.RawQuery = query
+			DeleteCategory(c)
+		}).Code
+	}
+	if code := del(""); code != http.StatusConflict {
+		t.Fatalf("in-use delete: want 409, got %d", code)
+	}
+	if code := del("reassign_to=" + strconv.FormatUint(uint64(f.rent.ID), 10)); code != http.StatusBadRequest {
+		t.Errorf("reassign to itself: want 400, got %d", code)
+	}
+	if code := del("reassign_to=" + strconv.FormatUint(uint64(f.food.ID), 10)); code != http.StatusOK {
+		t.Fatalf("reassign delete: want 200, got %d", code)
+	}
+	var got models.Transaction
+	database.DB.First(&got, tx.ID)
+	if got.CategoryID != f.food.ID {
+		t.Errorf("want the transaction in Food, got category %d", got.CategoryID)
+	}
+}
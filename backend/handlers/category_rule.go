@@ -0,0 +1,699 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Auto-categorization rules ────────────────────────────────────────────────
+//
+// A rule maps "description contains LIDL, expense" to "Food" (plus optional
+// tags and a cleaned-up description). Rules run where the user gave no
+// category: CreateTransaction without category_id, and import rows without a
+// category name, before the import's default category. POST /rules/test
+// shows what a rule would match among existing transactions; POST
+// /rules/apply re-runs the rules over a date range, as a preview unless
+// "commit" is set.
+
+// maxRuleMatchesShown caps the rows a test or preview response lists; the
+// counts always cover everything.
+const maxRuleMatchesShown = 200
+
+// compiledRule is a rule ready to be evaluated.
+type compiledRule struct {
+	models.CategoryRule
+	re   *regexp.Regexp
+	tags []string
+}
+
+// ruleTags splits a rule's stored tag list.
+func ruleTags(r models.CategoryRule) []string {
+	if r.TagList == "" {
+		return []string{}
+	}
+	return strings.Split(r.TagList, ",")
+}
+
+func compileRule(r models.CategoryRule) (compiledRule, error) {
+	cr := compiledRule{CategoryRule: r, tags: ruleTags(r)}
+	if r.DescriptionRegex != "" {
+		re, err := regexp.Compile(r.DescriptionRegex)
+		if err != nil {
+			return cr, err
+		}
+		cr.re = re
+	}
+	return cr, nil
+}
+
+// matches reports whether every condition of the rule holds.
+func (r compiledRule) matches(description string, amount float64, txType string) bool {
+	if r.MatchType != "" && r.MatchType != txType {
+		return false
+	}
+	if r.DescriptionContains != "" && !strings.Contains(strings.ToLower(description), strings.ToLower(r.DescriptionContains)) {
+		return false
+	}
+	if r.re != nil && !r.re.MatchString(description) {
+		return false
+	}
+	if r.MinAmount > 0 && amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// ruleOutcome is what a matching rule does to a transaction.
+type ruleOutcome struct {
+	RuleID      uint     `json:"rule_id"`
+	CategoryID  uint     `json:"category_id,omitempty"` // 0 = keep
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func (r compiledRule) outcome(description string) ruleOutcome {
+	out := ruleOutcome{RuleID: r.ID, CategoryID: r.CategoryID, Description: description, Tags: r.tags}
+	switch {
+	case r.DescriptionRewrite == "":
+	case r.re != nil:
+		out.Description = truncateDescription(r.re.ReplaceAllString(description, r.DescriptionRewrite))
+	default:
+		out.Description = r.DescriptionRewrite
+	}
+	return out
+}
+
+// ruleSet is a user's active rules in evaluation order.
+type ruleSet []compiledRule
+
+// loadRules reads uid's active rules, ordered by priority. A rule whose
+// category has been deleted since keeps its other actions.
+func loadRules(db *gorm.DB, uid uint) (ruleSet, error) {
+	var rules []models.CategoryRule
+	if err := db.Where("user_id = ? AND paused = ?", uid, false).
+		Order("priority").Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	set := make(ruleSet, 0, len(rules))
+	for _, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			log.Printf("load rules: user=%v rule=%v bad regex err=%v", uid, r.ID, err)
+			continue
+		}
+		set = append(set, cr)
+	}
+	return set, nil
+}
+
+// first returns the outcome of the first rule that matches.
+func (rs ruleSet) first(description string, amount float64, txType string) (ruleOutcome, bool) {
+	for _, r := range rs {
+		if r.matches(description, amount, txType) {
+			return r.outcome(description), true
+		}
+	}
+	return ruleOutcome{}, false
+}
+
+// ruleResponse is a rule as the API shows it, with its tags as a list.
+type ruleResponse struct {
+	models.CategoryRule
+	Tags []string `json:"tags"`
+}
+
+func toRuleResponse(r models.CategoryRule) ruleResponse {
+	return ruleResponse{CategoryRule: r, Tags: ruleTags(r)}
+}
+
+// ruleInput is the writable part of a rule; nil fields are left alone.
+type ruleInput struct {
+	Name                *string   `json:"name"`
+	Priority            *int      `json:"priority"`
+	Paused              *bool     `json:"paused"`
+	MatchType           *string   `json:"match_type"`
+	DescriptionContains *string   `json:"description_contains"`
+	DescriptionRegex    *string   `json:"description_regex"`
+	MinAmount           *float64  `json:"min_amount"`
+	MaxAmount           *float64  `json:"max_amount"`
+	CategoryID          *uint     `json:"category_id"`
+	Tags                *[]string `json:"tags"`
+	DescriptionRewrite  *string   `json:"description_rewrite"`
+}
+
+// applyTo copies the given fields onto r.
+func (in ruleInput) applyTo(r *models.CategoryRule) error {
+	if in.Name != nil {
+		r.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.Priority != nil {
+		r.Priority = *in.Priority
+	}
+	if in.Paused != nil {
+		r.Paused = *in.Paused
+	}
+	if in.MatchType != nil {
+		r.MatchType = *in.MatchType
+	}
+	if in.DescriptionContains != nil {
+		r.DescriptionContains = strings.TrimSpace(*in.DescriptionContains)
+	}
+	if in.DescriptionRegex != nil {
+		r.DescriptionRegex = *in.DescriptionRegex
+	}
+	if in.MinAmount != nil {
+		r.MinAmount = round2(*in.MinAmount)
+	}
+	if in.MaxAmount != nil {
+		r.MaxAmount = round2(*in.MaxAmount)
+	}
+	if in.CategoryID != nil {
+		r.CategoryID = *in.CategoryID
+	}
+	if in.Tags != nil {
+		names, err := normalizeTagNames(*in.Tags)
+		if err != nil {
+			return err
+		}
+		r.TagList = strings.Join(names, ",")
+	}
+	if in.DescriptionRewrite != nil {
+		r.DescriptionRewrite = strings.TrimSpace(*in.DescriptionRewrite)
+	}
+	return nil
+}
+
+// validateRule checks a complete rule. The error is safe to send to the
+// client with the returned status.
+func validateRule(uid uint, r models.CategoryRule) (int, error) {
+	switch {
+	case r.Name == "" || len(r.Name) > 100:
+		return http.StatusBadRequest, errors.New("Rule name must be 1–100 characters")
+	case r.MatchType != "" && r.MatchType != "expense" && r.MatchType != "income":
+		return http.StatusBadRequest, errors.New("Invalid match_type. Allowed values: expense, income (empty = both)")
+	case len(r.DescriptionContains) > 255 || len(r.DescriptionRegex) > 255 || len(r.DescriptionRewrite) > 255:
+		return http.StatusBadRequest, errors.New("Rule texts must be 255 characters or fewer")
+	case r.MinAmount < 0 || r.MaxAmount < 0:
+		return http.StatusBadRequest, errors.New("Amount bounds must not be negative")
+	case r.MaxAmount > 0 && r.MinAmount > r.MaxAmount:
+		return http.StatusBadRequest, errors.New("min_amount must not exceed max_amount")
+	case r.DescriptionContains == "" && r.DescriptionRegex == "" && r.MinAmount == 0 && r.MaxAmount == 0 && r.MatchType == "":
+		return http.StatusBadRequest, errors.New("A rule needs at least one condition")
+	case r.CategoryID == 0 && r.TagList == "" && r.DescriptionRewrite == "":
+		return http.StatusBadRequest, errors.New("A rule needs at least one action: category_id, tags or description_rewrite")
+	}
+	if _, err := compileRule(r); err != nil {
+		return http.StatusBadRequest, errors.New("Invalid description_regex: " + err.Error())
+	}
+	if r.CategoryID != 0 {
+		var n int64
+		if err := database.DB.Model(&models.Category{}).Where("id = ? AND user_id = ?", r.CategoryID, uid).Count(&n).Error; err != nil {
+			log.Printf("validate rule: category user=%v cat=%v err=%v", uid, r.CategoryID, err)
+			return http.StatusInternalServerError, errors.New("Failed to verify category")
+		}
+		if n == 0 {
+			return http.StatusNotFound, errors.New("Category not found or does not belong to you")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// ruleMatch is one existing transaction a rule matched, and what it would
+// change.
+type ruleMatch struct {
+	TransactionID     uint      `json:"transaction_id"`
+	Date              time.Time `json:"date"`
+	Amount            float64   `json:"amount"`
+	Type              string    `json:"type"`
+	RuleID            uint      `json:"rule_id"`
+	CategoryBefore    uint      `json:"category_before"`
+	CategoryAfter     uint      `json:"category_after"`
+	DescriptionBefore string    `json:"description_before"`
+	DescriptionAfter  string    `json:"description_after"`
+	TagsAdded         []string  `json:"tags_added,omitempty"`
+	Changed           bool      `json:"changed"`
+}
+
+// ruleRun is the outcome of running rules over existing transactions.
+type ruleRun struct {
+	Matched int `json:"matched"`
+	Changed int `json:"changed"`
+	// Reconciled counts matching rows left alone because they are locked.
+	Reconciled int         `json:"reconciled"`
+	Matches    []ruleMatch `json:"matches"`
+}
+
+// planRules evaluates rules over txs (Tags and Splits preloaded). Split
+// transactions keep their category — it is derived from the lines — and
+// reconciled rows are only counted.
+func planRules(rules ruleSet, txs []models.Transaction) (ruleRun, []ruleMatch) {
+	run := ruleRun{Matches: []ruleMatch{}}
+	var changes []ruleMatch
+	for _, t := range txs {
+		out, ok := rules.first(t.Description, txOriginalAmount(t), t.Type)
+		if !ok {
+			continue
+		}
+		run.Matched++
+		if t.Status == txStatusReconciled {
+			run.Reconciled++
+			continue
+		}
+		m := ruleMatch{
+			TransactionID: t.ID, Date: t.Date, Amount: t.Amount, Type: t.Type, RuleID: out.RuleID,
+			CategoryBefore: t.CategoryID, CategoryAfter: t.CategoryID,
+			DescriptionBefore: t.Description, DescriptionAfter: out.Description,
+		}
+		if out.CategoryID != 0 && len(t.Splits) == 0 {
+			m.CategoryAfter = out.CategoryID
+		}
+		have := make(map[string]bool, len(t.Tags))
+		for _, tag := range t.Tags {
+			have[tag.Name] = true
+		}
+		for _, name := range out.Tags {
+			if !have[name] {
+				m.TagsAdded = append(m.TagsAdded, name)
+			}
+		}
+		m.Changed = m.CategoryAfter != m.CategoryBefore || m.DescriptionAfter != m.DescriptionBefore || len(m.TagsAdded) > 0
+		if m.Changed {
+			run.Changed++
+			changes = append(changes, m)
+		}
+		if len(run.Matches) < maxRuleMatchesShown {
+			run.Matches = append(run.Matches, m)
+		}
+	}
+	return run, changes
+}
+
+// loadRuleCandidates reads the expense and income rows rules may touch,
+// optionally limited to [begin, end] (calendar dates, inclusive).
+func loadRuleCandidates(db *gorm.DB, uid uint, begin, end *time.Time) ([]models.Transaction, error) {
+	q := db.Preload("Tags").Preload("Splits").Where("user_id = ? AND type IN ?", uid, []string{"expense", "income"})
+	if begin != nil {
+		q = q.Where("date >= ?", *begin)
+	}
+	if end != nil {
+		q = q.Where("date < ?", end.AddDate(0, 0, 1))
+	}
+	var txs []models.Transaction
+	err := q.Order("date DESC").Order("id DESC").Find(&txs).Error
+	return txs, err
+}
+
+// parseRuleRange reads optional begin_date / end_date (YYYY-MM-DD).
+func parseRuleRange(beginStr, endStr string) (*time.Time, *time.Time, error) {
+	var begin, end *time.Time
+	if beginStr != "" {
+		d, err := time.Parse("2006-01-02", beginStr)
+		if err != nil {
+			return nil, nil, errors.New("Invalid begin_date format. Use YYYY-MM-DD")
+		}
+		begin = &d
+	}
+	if endStr != "" {
+		d, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			return nil, nil, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+		}
+		end = &d
+	}
+	if begin != nil && end != nil && end.Before(*begin) {
+		return nil, nil, errors.New("end_date must not be before begin_date")
+	}
+	return begin, end, nil
+}
+
+// GetRules → GET /api/rules
+// Lists all rules in evaluation order, paused ones included.
+func GetRules(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var rules []models.CategoryRule
+	if err := database.DB.Where("user_id = ?", uid).Order("priority").Order("id").Find(&rules).Error; err != nil {
+		log.Printf("get rules: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+	out := make([]ruleResponse, len(rules))
+	for i, r := range rules {
+		out[i] = toRuleResponse(r)
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": out})
+}
+
+// GetRule → GET /api/rules/:id
+// One rule, with the ETag a later update or delete can send as If-Match.
+func GetRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var rule models.CategoryRule
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found or does not belong to you"})
+		} else {
+			log.Printf("get rule: user=%v rule=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule"})
+		}
+		return
+	}
+
+	c.Header("ETag", ruleETag(rule))
+	c.JSON(http.StatusOK, gin.H{"rule": toRuleResponse(rule)})
+}
+
+func CreateRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input ruleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := models.CategoryRule{UserID: uid, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := input.applyTo(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Priority == nil {
+		// New rules go last unless placed explicitly.
+		var last struct{ Max *int }
+		database.DB.Model(&models.CategoryRule{}).Where("user_id = ?", uid).Select("MAX(priority) AS max").Scan(&last)
+		if last.Max != nil {
+			rule.Priority = *last.Max + 10
+		}
+	}
+	if status, err := validateRule(uid, rule); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.Create(&rule).Error; err != nil {
+		log.Printf("create rule: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Rule created successfully", "rule": toRuleResponse(rule)})
+}
+
+func UpdateRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID format"})
+		return
+	}
+	var rule models.CategoryRule
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(id), uid).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found or does not belong to you"})
+		} else {
+			log.Printf("update rule fetch: user=%v rule=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule"})
+		}
+		return
+	}
+	if etag := ruleETag(rule); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"rule": toRuleResponse(rule)})
+		return
+	}
+	seen := rule.UpdatedAt
+
+	var input ruleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.applyTo(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := validateRule(uid, rule); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	rule.UpdatedAt = time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.CategoryRule{}, rule.ID, seen); err != nil {
+			return err
+		}
+		return tx.Save(&rule).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Rule not found or does not belong to you", func() (string, gin.H, error) {
+			return currentRule(uid, rule.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update rule save: user=%v rule=%v err=%v", uid, rule.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule"})
+		return
+	}
+
+	c.Header("ETag", ruleETag(rule))
+	c.JSON(http.StatusOK, gin.H{"message": "Rule updated successfully", "rule": toRuleResponse(rule)})
+}
+
+func DeleteRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID format"})
+		return
+	}
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.CategoryRule
+		if err := database.DB.Where("id = ? AND user_id = ?", uint(id), uid).First(&current).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found or does not belong to you"})
+			return
+		}
+		if etag := ruleETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"rule": toRuleResponse(current)})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.CategoryRule{}, uint(id), seen); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND user_id = ?", uint(id), uid).Delete(&models.CategoryRule{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Rule not found or does not belong to you", func() (string, gin.H, error) {
+			return currentRule(uid, uint(id))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete rule: user=%v rule=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found or does not belong to you"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted successfully"})
+}
+
+// TestRule → POST /api/rules/test
+// Runs one rule — a saved one by rule_id, or an unsaved definition in the
+// body — over existing transactions (optionally begin_date..end_date) and
+// lists what it matches and would change. Nothing is written.
+func TestRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		ruleInput
+		RuleID    uint   `json:"rule_id"`
+		BeginDate string `json:"begin_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	begin, end, err := parseRuleRange(input.BeginDate, input.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.CategoryRule{UserID: uid, Name: "test"}
+	if input.RuleID != 0 {
+		if err := database.DB.Where("id = ? AND user_id = ?", input.RuleID, uid).First(&rule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found or does not belong to you"})
+			} else {
+				log.Printf("test rule fetch: user=%v rule=%v err=%v", uid, input.RuleID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule"})
+			}
+			return
+		}
+	}
+	// Fields sent along with rule_id try out an edit before saving it.
+	if err := input.ruleInput.applyTo(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := validateRule(uid, rule); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	compiled, _ := compileRule(rule)
+
+	txs, err := loadRuleCandidates(database.DB, uid, begin, end)
+	if err != nil {
+		log.Printf("test rule: load user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	run, _ := planRules(ruleSet{compiled}, txs)
+	c.JSON(http.StatusOK, run)
+}
+
+// ApplyRules → POST /api/rules/apply
+// Re-runs the active rules (or just rule_ids, in priority order) over the
+// transactions dated begin_date..end_date. Without "commit" it is a preview
+// of every change; with it, the changes are written in one DB transaction.
+// Reconciled rows are never touched.
+func ApplyRules(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		BeginDate string `json:"begin_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+		RuleIDs   []uint `json:"rule_ids"`
+		Commit    bool   `json:"commit"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	begin, end, err := parseRuleRange(input.BeginDate, input.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := loadRules(database.DB, uid)
+	if err != nil {
+		log.Printf("apply rules: load rules user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+	if len(input.RuleIDs) > 0 {
+		wanted := make(map[uint]bool, len(input.RuleIDs))
+		for _, id := range input.RuleIDs {
+			wanted[id] = true
+		}
+		picked := rules[:0]
+		for _, r := range rules {
+			if wanted[r.ID] {
+				picked = append(picked, r)
+			}
+		}
+		rules = picked
+	}
+	txs, err := loadRuleCandidates(database.DB, uid, begin, end)
+	if err != nil {
+		log.Printf("apply rules: load user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	run, changes := planRules(rules, txs)
+	if !input.Commit || len(changes) == 0 {
+		c.JSON(http.StatusOK, gin.H{"preview": run, "committed": false})
+		return
+	}
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, m := range changes {
+			if err := tx.Model(&models.Transaction{}).Where("id = ? AND user_id = ?", m.TransactionID, uid).
+				Updates(map[string]any{"category_id": m.CategoryAfter, "description": m.DescriptionAfter, "updated_at": now}).Error; err != nil {
+				return err
+			}
+			if len(m.TagsAdded) == 0 {
+				continue
+			}
+			tags, err := resolveTags(tx, uid, m.TagsAdded)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Transaction{ID: m.TransactionID}).Association("Tags").Append(tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("apply rules: commit user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rules"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rules applied successfully", "preview": run, "committed": true})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func createRule(t *testing.T, uid uint, body map[string]any) models.CategoryRule {
+	t.Helper()
+	w := callHandler(uid, body, CreateRule)
+	var resp struct {
+		Rule models.CategoryRule `json:"rule"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create rule: %d %s", w.Code, w.Body.String())
+	}
+	return resp.Rule
+}
+
+// Without a category the first matching rule by priority categorizes,
+// rewrites and tags the new transaction; an explicit category is left alone.
+func TestRulesOnCreateTransaction(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	createRule(t, uid, map[string]any{"name": "Markets", "description_contains": "markt", "category_id": f.beauty.ID})
+	createRule(t, uid, map[string]any{
+		"name": "REWE", "priority": -1, "match_type": "expense", "description_regex": `^REWE (\w+).*`,
+		"category_id": f.food.ID, "tags": []string{"Groceries"}, "description_rewrite": "Rewe $1",
+	})
+	if w := callHandler(uid, map[string]any{"name": "empty", "category_id": f.food.ID}, CreateRule); w.Code != http.StatusBadRequest {
+		t.Errorf("rule without a condition: want 400, got %d", w.Code)
+	}
+	if w := callHandler(uid, map[string]any{"name": "bad", "description_regex": "(", "category_id": f.food.ID}, CreateRule); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid regex: want 400, got %d", w.Code)
+	}
+
+	w := callHandler(uid, map[string]any{"amount": 12.5, "date": "2026-05-02", "type": "expense", "description": "REWE Markt 0815"}, CreateTransaction)
+	var created struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	tx := created.Transaction
+	if tx.CategoryID != f.food.ID || tx.Description != "Rewe Markt" || len(tx.Tags) != 1 || tx.Tags[0].Name != "groceries" {
+		t.Errorf("the higher-priority rule should win: cat %d, %q, %+v", tx.CategoryID, tx.Description, tx.Tags)
+	}
+
+	w = callHandler(uid, map[string]any{"category_id": f.rent.ID, "amount": 5, "date": "2026-05-02", "type": "expense", "description": "REWE Markt"}, CreateTransaction)
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Transaction.CategoryID != f.rent.ID || created.Transaction.Description != "REWE Markt" {
+		t.Errorf("an explicit category skips the rules: %+v", created.Transaction)
+	}
+	if w := callHandler(uid, map[string]any{"amount": 5, "date": "2026-05-02", "type": "expense", "description": "Kiosk"}, CreateTransaction); w.Code != http.StatusBadRequest {
+		t.Errorf("no category and no matching rule: want 400, got %d", w.Code)
+	}
+}
+
+// Rows of an import without a category name go through the rules before the
+// default category.
+func TestRulesOnImport(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	createRule(t, uid, map[string]any{"name": "LIDL", "description_contains": "lidl", "category_id": f.food.ID, "tags": []string{"groceries"}})
+
+	body := map[string]any{
+		"csv":                 "date,amount,description\n2026-05-01,-20,LIDL DANKT\n2026-05-02,-50,Hairdresser\n",
+		"mapping":             map[string]any{"date": "date", "amount": "amount", "description": "description"},
+		"default_category_id": f.beauty.ID,
+		"commit":              true,
+	}
+	if w := callHandler(uid, body, ImportTransactionsCSV); w.Code != http.StatusCreated {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+	var lidl, hair models.Transaction
+	database.DB.Preload("Tags").Where("user_id = ? AND description = ?", uid, "LIDL DANKT").First(&lidl)
+	database.DB.Where("user_id = ? AND description = ?", uid, "Hairdresser").First(&hair)
+	if lidl.CategoryID != f.food.ID || len(lidl.Tags) != 1 {
+		t.Errorf("rule should categorize and tag the LIDL row: cat %d, tags %+v", lidl.CategoryID, lidl.Tags)
+	}
+	if hair.CategoryID != f.beauty.ID {
+		t.Errorf("unmatched row should use the default category, got %d", hair.CategoryID)
+	}
+}
+
+// Re-applying over a date range previews first, then rewrites only rows in
+// range and never touches reconciled ones.
+func TestApplyRulesRetroactively(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	seed := func(desc string, day int, status string) models.Transaction {
+		tx := models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 10, Type: "expense", Description: desc, Date: utcDay(2026, 4, day), Status: status}
+		database.DB.Create(&tx)
+		return tx
+	}
+	inRange := seed("LIDL 1", 10, txStatusPending)
+	locked := seed("LIDL 2", 11, txStatusReconciled)
+	outOfRange := seed("LIDL 3", 28, txStatusPending)
+	rule := createRule(t, uid, map[string]any{"name": "LIDL", "description_contains": "lidl", "category_id": f.food.ID})
+
+	w := callHandler(uid, map[string]any{"rule_id": rule.ID}, TestRule)
+	var tested ruleRun
+	if err := json.Unmarshal(w.Body.Bytes(), &tested); err != nil || tested.Matched != 3 || tested.Reconciled != 1 {
+		t.Errorf("test over all time: %d %s", w.Code, w.Body.String())
+	}
+
+	body := map[string]any{"begin_date": "2026-04-01", "end_date": "2026-04-20"}
+	w = callHandler(uid, body, ApplyRules)
+	var resp struct {
+		Preview   ruleRun `json:"preview"`
+		Committed bool    `json:"committed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Committed || resp.Preview.Changed != 1 || resp.Preview.Reconciled != 1 {
+		t.Fatalf("preview: %d %s", w.Code, w.Body.String())
+	}
+	var stored models.Transaction
+	database.DB.First(&stored, inRange.ID)
+	if stored.CategoryID != f.rent.ID {
+		t.Fatalf("a preview must not write")
+	}
+
+	body["commit"] = true
+	if w := callHandler(uid, body, ApplyRules); w.Code != http.StatusOK {
+		t.Fatalf("commit: %d %s", w.Code, w.Body.String())
+	}
+	for id, want := range map[uint]uint{inRange.ID: f.food.ID, locked.ID: f.rent.ID, outOfRange.ID: f.rent.ID} {
+		var got models.Transaction
+		database.DB.First(&got, id)
+		if got.CategoryID != want {
+			t.Errorf("tx %d: want category %d, got %d", id, want, got.CategoryID)
+		}
+	}
+}
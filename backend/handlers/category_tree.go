@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Category hierarchy ───────────────────────────────────────────────────────
+//
+// A category may nest under another one via ParentID ("Food > Restaurants").
+// Users have tens of categories, not thousands, so every hierarchy question is
+// answered from the user's whole category list loaded into a categoryForest
+// rather than with recursive SQL.
+
+// maxCategoryDepth is the deepest nesting allowed: a top-level category is at
+// depth 1, so 3 permits "Food > Eating out > Lunch".
+const maxCategoryDepth = 3
+
+type categoryForest struct {
+	byID     map[uint]models.Category
+	children map[uint][]uint
+	roots    []uint
+}
+
+// categoryNode is one category with its sub-categories, as returned by
+// GET /categories?tree=true.
+type categoryNode struct {
+	models.Category
+	Children []categoryNode `json:"children"`
+}
+
+func loadCategoryForest(db *gorm.DB, uid uint) (*categoryForest, error) {
+	var cats []models.Category
+	if err := db.Where("user_id = ?", uid).Order("name asc").Order("id asc").Find(&cats).Error; err != nil {
+		return nil, err
+	}
+	return newCategoryForest(cats), nil
+}
+
+func newCategoryForest(cats []models.Category) *categoryForest {
+	f := &categoryForest{byID: make(map[uint]models.Category, len(cats)), children: map[uint][]uint{}}
+	for _, c := range cats {
+		f.byID[c.ID] = c
+	}
+	for _, c := range cats {
+		// A dangling parent (should not happen, but the column has no FK)
+		// makes the category a root rather than an orphan nobody can see.
+		if c.ParentID != nil && *c.ParentID != c.ID {
+			if _, ok := f.byID[*c.ParentID]; ok {
+				f.children[*c.ParentID] = append(f.children[*c.ParentID], c.ID)
+				continue
+			}
+		}
+		f.roots = append(f.roots, c.ID)
+	}
+	return f
+}
+
+// parentOf returns id's parent, or 0 for a root (or unknown) category.
+func (f *categoryForest) parentOf(id uint) uint {
+	c, ok := f.byID[id]
+	if !ok || c.ParentID == nil || *c.ParentID == id {
+		return 0
+	}
+	if _, ok := f.byID[*c.ParentID]; !ok {
+		return 0
+	}
+	return *c.ParentID
+}
+
+// ancestors returns id's parent, grandparent, … up to its root. The walk is
+// bounded by the number of categories so bad data cannot loop forever.
+func (f *categoryForest) ancestors(id uint) []uint {
+	var out []uint
+	for p := f.parentOf(id); p != 0 && len(out) <= len(f.byID); p = f.parentOf(p) {
+		out = append(out, p)
+	}
+	return out
+}
+
+// depth is 1 for a top-level category, 2 for its children, and so on.
+func (f *categoryForest) depth(id uint) int {
+	return len(f.ancestors(id)) + 1
+}
+
+// height is the number of levels in id's subtree, itself included (1 = leaf).
+func (f *categoryForest) height(id uint) int {
+	var walk func(id uint, budget int) int
+	walk = func(id uint, budget int) int {
+		h := 0
+		if budget > 0 {
+			for _, ch := range f.children[id] {
+				h = max(h, walk(ch, budget-1))
+			}
+		}
+		return h + 1
+	}
+	return walk(id, len(f.byID))
+}
+
+// rootOf maps a category to its top-level ancestor. Unknown IDs map to
+// themselves so rolled-up totals never lose a row.
+func (f *categoryForest) rootOf(id uint) uint {
+	if a := f.ancestors(id); len(a) > 0 {
+		return a[len(a)-1]
+	}
+	return id
+}
+
+// isDescendant reports whether id sits somewhere below ancestor.
+func (f *categoryForest) isDescendant(id, ancestor uint) bool {
+	for _, a := range f.ancestors(id) {
+		if a == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// checkParent validates moving category id (0 for a new one) under parentID:
+// the parent must be one of the user's categories, must not be the category
+// itself or one of its descendants, and the moved subtree must still fit
+// within maxCategoryDepth.
+func (f *categoryForest) checkParent(id, parentID uint) error {
+	if _, ok := f.byID[parentID]; !ok {
+		return errors.New("Parent category not found")
+	}
+	if id != 0 {
+		if parentID == id {
+			return errors.New("A category cannot be its own parent")
+		}
+		if f.isDescendant(parentID, id) {
+			return errors.New("A category cannot be moved under one of its own sub-categories")
+		}
+	}
+	subtree := 1
+	if id != 0 {
+		subtree = f.height(id)
+	}
+	if f.depth(parentID)+subtree > maxCategoryDepth {
+		return errors.New("Categories can be nested at most " + strconv.Itoa(maxCategoryDepth) + " levels deep")
+	}
+	return nil
+}
+
+// tree returns the forest as nested nodes; siblings keep the load order (name).
+func (f *categoryForest) tree() []categoryNode {
+	var build func(ids []uint) []categoryNode
+	build = func(ids []uint) []categoryNode {
+		nodes := make([]categoryNode, 0, len(ids))
+		for _, id := range ids {
+			nodes = append(nodes, categoryNode{Category: f.byID[id], Children: build(f.children[id])})
+		}
+		return nodes
+	}
+	return build(f.roots)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func catParam(id uint) gin.Params {
+	return gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(id), 10)}}
+}
+
+func createCategory(t *testing.T, uid uint, name string, parent uint) models.Category {
+	t.Helper()
+	body := map[string]any{"name": name}
+	if parent != 0 {
+		body["parent_id"] = parent
+	}
+	w := callHandler(uid, body, CreateCategory)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create %s: %d %s", name, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Category models.Category `json:"category"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Category
+}
+
+func TestCategoryTree_DepthAndCycles(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "nester", Password: "x"}
+	database.DB.Create(&user)
+	food := createCategory(t, user.ID, "Food", 0)
+	out := createCategory(t, user.ID, "Eating out", food.ID)
+	lunch := createCategory(t, user.ID, "Lunch", out.ID)
+
+	if w := callHandler(user.ID, map[string]any{"name": "Too deep", "parent_id": lunch.ID}, CreateCategory); w.Code != http.StatusBadRequest {
+		t.Errorf("fourth level: want 400, got %d", w.Code)
+	}
+	if w := callHandlerParamBody(user.ID, catParam(food.ID), map[string]any{"parent_id": lunch.ID}, UpdateCategory); w.Code != http.StatusBadRequest {
+		t.Errorf("moving a parent under its grandchild: want 400, got %d", w.Code)
+	}
+	if w := callHandlerParamBody(user.ID, catParam(out.ID), map[string]any{"parent_id": out.ID}, UpdateCategory); w.Code != http.StatusBadRequest {
+		t.Errorf("own parent: want 400, got %d", w.Code)
+	}
+
+	// A two-level subtree does not fit under a second-level category.
+	travel := createCategory(t, user.ID, "Travel", 0)
+	hotels := createCategory(t, user.ID, "Hotels", travel.ID)
+	if w := callHandlerParamBody(user.ID, catParam(out.ID), map[string]any{"parent_id": hotels.ID}, UpdateCategory); w.Code != http.StatusBadRequest {
+		t.Errorf("subtree too deep after move: want 400, got %d", w.Code)
+	}
+	if w := callHandlerParamBody(user.ID, catParam(out.ID), map[string]any{"parent_id": travel.ID}, UpdateCategory); w.Code != http.StatusOK {
+		t.Fatalf("valid move: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParamBody(user.ID, catParam(out.ID), map[string]any{"parent_id": 0}, UpdateCategory); w.Code != http.StatusOK {
+		t.Fatalf("move to top level: %d %s", w.Code, w.Body.String())
+	}
+	var got models.Category
+	database.DB.First(&got, out.ID)
+	if got.ParentID != nil || got.Name != "Eating out" {
+		t.Errorf("want a top-level, unrenamed category, got %+v", got)
+	}
+}
+
+func TestCategoryTree_GetTree(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "treeview", Password: "x"}
+	database.DB.Create(&user)
+	food := createCategory(t, user.ID, "Food", 0)
+	createCategory(t, user.ID, "Restaurants", food.ID)
+	createCategory(t, user.ID, "Groceries", food.ID)
+	createCategory(t, user.ID, "Rent", 0)
+
+	w := callHandlerGET(user.ID, "tree=true", GetCategories)
+	var resp struct {
+		Categories []categoryNode `json:"categories"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Categories) != 2 || resp.Categories[0].Name != "Food" || len(resp.Categories[0].Children) != 2 {
+		t.Fatalf("want Food{2 children} + Rent at the top, got %s", w.Body.String())
+	}
+	if resp.Categories[0].Children[0].Name != "Groceries" {
+		t.Errorf("children should be ordered by name, got %+v", resp.Categories[0].Children)
+	}
+}
+
+func TestCategoryTree_PeriodSummaryRollup(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "roller", Password: "x"}
+	database.DB.Create(&user)
+	food := createCategory(t, user.ID, "Food", 0)
+	rest := createCategory(t, user.ID, "Restaurants", food.ID)
+	rent := createCategory(t, user.ID, "Rent", 0)
+	for _, tx := range []struct {
+		cat    uint
+		amount float64
+	}{{food.ID, 10}, {rest.ID, 25.5}, {rent.ID, 30}} {
+		callHandler(user.ID, map[string]any{"category_id": tx.cat, "amount": tx.amount, "date": "2026-04-02", "type": "expense"}, CreateTransaction)
+	}
+
+	summary := func(query string) map[uint]float64 {
+		w := callHandlerGET(user.ID, query, GetPeriodSummary)
+		var resp struct {
+			Summary []struct {
+				Category    struct{ ID uint } `json:"category"`
+				TotalAmount float64           `json:"total_amount"`
+			} `json:"summary"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		got := map[uint]float64{}
+		for _, s := range resp.Summary {
+			got[s.Category.ID] = s.TotalAmount
+		}
+		return got
+	}
+	if got := summary("begin_date=2026-04-01&end_date=2026-04-30"); len(got) != 3 || got[rest.ID] != 25.5 {
+		t.Errorf("without rollup every category is its own row, got %v", got)
+	}
+	if got := summary("begin_date=2026-04-01&end_date=2026-04-30&rollup=true"); len(got) != 2 || got[food.ID] != 35.5 || got[rent.ID] != 30 {
+		t.Errorf("want Food 35.5 / Rent 30 rolled up, got %v", got)
+	}
+}
+
+// Deleting a parent is blocked by default; reparent moves its children up.
+func TestCategoryTree_DeleteParent(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "pruner", Password: "x"}
+	database.DB.Create(&user)
+	food := createCategory(t, user.ID, "Food", 0)
+	out := createCategory(t, user.ID, "Eating out", food.ID)
+	lunch := createCategory(t, user.ID, "Lunch", out.ID)
+
+	if w := callHandlerParam(user.ID, catParam(out.ID), DeleteCategory); w.Code != http.StatusConflict {
+		t.Fatalf("parent delete without strategy: want 409, got %d", w.Code)
+	}
+	w := callHandlerParam(user.ID, catParam(out.ID), func(c *gin.Context) {
+		c.Request.URL.RawQuery = "children=reparent"
+		DeleteCategory(c)
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reparent delete: %d %s", w.Code, w.Body.String())
+	}
+	var got models.Category
+	database.DB.First(&got, lunch.ID)
+	if got.ParentID == nil || *got.ParentID != food.ID {
+		t.Errorf("Lunch should now sit under Food, got parent %v", got.ParentID)
+	}
+}
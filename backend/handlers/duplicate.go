@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Duplicates: detect and merge double entries ─────────────────────────────
+//
+// Two rows are duplicate candidates when they have the same type, currency and
+// amount and lie at most a few days apart. Confidence starts at 0.5 for that
+// and adds up to 0.25 for date proximity and up to 0.25 for description
+// similarity. Rows with two different bank FITIDs, or two postings of the same
+// recurring template, are distinct by construction and never pair up.
+
+const (
+	defaultDuplicateWindowDays = 3
+	maxDuplicateWindowDays     = 14
+	defaultDuplicateConfidence = 0.6
+)
+
+// descriptionTokens splits s into lower-case words, dropping pure numbers —
+// reference and card numbers differ between a typed entry and the bank's.
+func descriptionTokens(s string) map[string]bool {
+	out := map[string]bool{}
+	for _, f := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if strings.IndexFunc(f, unicode.IsLetter) >= 0 {
+			out[f] = true
+		}
+	}
+	return out
+}
+
+// descriptionSimilarity is the Jaccard overlap of two descriptions' words;
+// an empty description says nothing either way (0.5).
+func descriptionSimilarity(a, b string) float64 {
+	ta, tb := descriptionTokens(a), descriptionTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0.5
+	}
+	shared := 0
+	for w := range ta {
+		if tb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// amountCents is what was paid, in the row's own currency, in cents.
+func amountCents(t models.Transaction) int64 {
+	return int64(math.Round(txOriginalAmount(t) * 100))
+}
+
+// duplicateConfidence scores how likely a and b are the same payment; ok is
+// false when they cannot be duplicates at all.
+func duplicateConfidence(a, b models.Transaction, windowDays int) (float64, bool) {
+	if a.ID == b.ID || a.Type != b.Type || a.Currency != b.Currency || amountCents(a) != amountCents(b) {
+		return 0, false
+	}
+	if a.FITID != "" && b.FITID != "" && a.FITID != b.FITID {
+		return 0, false
+	}
+	if a.RecurringID != nil && b.RecurringID != nil && *a.RecurringID == *b.RecurringID {
+		return 0, false
+	}
+	days := daysBetween(toDateOnly(a.Date), toDateOnly(b.Date))
+	if days < 0 {
+		days = -days
+	}
+	if days > windowDays {
+		return 0, false
+	}
+	score := 0.5 +
+		0.25*(1-float64(days)/float64(windowDays+1)) +
+		0.25*descriptionSimilarity(a.Description, b.Description)
+	return math.Round(score*100) / 100, true
+}
+
+// duplicatePair is one likely double entry; Transactions holds the older
+// row first.
+type duplicatePair struct {
+	Confidence   float64              `json:"confidence"`
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+// findDuplicatePairs compares rows only within (type, currency, amount)
+// buckets, so the cost stays near-linear for real ledgers.
+func findDuplicatePairs(txs []models.Transaction, windowDays int, minConfidence float64) []duplicatePair {
+	type bucketKey struct {
+		typ, currency string
+		cents         int64
+	}
+	buckets := map[bucketKey][]models.Transaction{}
+	for _, t := range txs {
+		k := bucketKey{t.Type, t.Currency, amountCents(t)}
+		buckets[k] = append(buckets[k], t)
+	}
+
+	pairs := []duplicatePair{}
+	for _, rows := range buckets {
+		sort.Slice(rows, func(i, j int) bool {
+			if !rows[i].Date.Equal(rows[j].Date) {
+				return rows[i].Date.Before(rows[j].Date)
+			}
+			return rows[i].ID < rows[j].ID
+		})
+		for i := range rows {
+			for j := i + 1; j < len(rows); j++ {
+				if daysBetween(toDateOnly(rows[i].Date), toDateOnly(rows[j].Date)) > windowDays {
+					break
+				}
+				score, ok := duplicateConfidence(rows[i], rows[j], windowDays)
+				if !ok || score < minConfidence {
+					continue
+				}
+				older, newer := rows[i], rows[j]
+				if newer.ID < older.ID {
+					older, newer = newer, older
+				}
+				pairs = append(pairs, duplicatePair{Confidence: score, Transactions: []models.Transaction{older, newer}})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Confidence != pairs[j].Confidence {
+			return pairs[i].Confidence > pairs[j].Confidence
+		}
+		return pairs[i].Transactions[0].ID < pairs[j].Transactions[0].ID
+	})
+	return pairs
+}
+
+// possibleDuplicate is the warning CreateTransaction attaches when the new
+// row looks like one the user already has.
+type possibleDuplicate struct {
+	TransactionID uint    `json:"transaction_id"`
+	Confidence    float64 `json:"confidence"`
+	Date          string  `json:"date"`
+	Description   string  `json:"description"`
+}
+
+// findPossibleDuplicate returns the existing row t most likely duplicates,
+// or nil when none reaches the default confidence.
+func findPossibleDuplicate(db *gorm.DB, t models.Transaction) (*possibleDuplicate, error) {
+	day := toDateOnly(t.Date)
+	var near []models.Transaction
+	err := db.Where("user_id = ? AND id <> ? AND type = ? AND date >= ? AND date < ?",
+		t.UserID, t.ID, t.Type,
+		day.AddDate(0, 0, -defaultDuplicateWindowDays), day.AddDate(0, 0, defaultDuplicateWindowDays+1)).
+		Find(&near).Error
+	if err != nil {
+		return nil, err
+	}
+	var best *possibleDuplicate
+	for _, o := range near {
+		score, ok := duplicateConfidence(t, o, defaultDuplicateWindowDays)
+		if !ok || score < defaultDuplicateConfidence || (best != nil && score <= best.Confidence) {
+			continue
+		}
+		best = &possibleDuplicate{
+			TransactionID: o.ID,
+			Confidence:    score,
+			Date:          o.Date.Format("2006-01-02"),
+			Description:   o.Description,
+		}
+	}
+	return best, nil
+}
+
+// GetDuplicates → GET /api/transactions/duplicates
+// Optional: begin_date, end_date (YYYY-MM-DD), window_days (default 3, max
+// 14), min_confidence (0–1, default 0.6). Pairs come most confident first.
+func GetDuplicates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	begin, end, err := parseRuleRange(c.Query("begin_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window := defaultDuplicateWindowDays
+	if raw := c.Query("window_days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxDuplicateWindowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window_days must be between 0 and 14"})
+			return
+		}
+		window = n
+	}
+	minConfidence := defaultDuplicateConfidence
+	if raw := c.Query("min_confidence"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil || f < 0 || f > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_confidence must be between 0 and 1"})
+			return
+		}
+		minConfidence = f
+	}
+
+	q := database.DB.Preload("Category").Preload("Tags").
+		Where("user_id = ? AND type IN ?", uid, []string{"expense", "income"})
+	if begin != nil {
+		q = q.Where("date >= ?", *begin)
+	}
+	if end != nil {
+		q = q.Where("date < ?", end.AddDate(0, 0, 1))
+	}
+	var txs []models.Transaction
+	if err := q.Find(&txs).Error; err != nil {
+		log.Printf("get duplicates: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	pairs := findDuplicatePairs(txs, window, minConfidence)
+	c.JSON(http.StatusOK, gin.H{"duplicates": pairs, "count": len(pairs)})
+}
+
+// MergeTransactions → POST /api/transactions/merge
+// Body: {"keep_id": 1, "merge_ids": [2, 3]}. The kept row picks up the merged
+// rows' tags, plus their bank FITID and description when it has none; the
+// merged rows go to the trash. Every merged row must pass duplicateConfidence
+// against the kept one over the widest window. All or nothing.
+func MergeTransactions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		KeepID   uint   `json:"keep_id" binding:"required"`
+		MergeIDs []uint `json:"merge_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seen := map[uint]bool{input.KeepID: true}
+	mergeIDs := make([]uint, 0, len(input.MergeIDs))
+	for _, id := range input.MergeIDs {
+		if id == input.KeepID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "keep_id must not be in merge_ids"})
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			mergeIDs = append(mergeIDs, id)
+		}
+	}
+
+	var rows []models.Transaction
+	if err := database.DB.Preload("Tags").Where("user_id = ? AND id IN ?", uid, append([]uint{input.KeepID}, mergeIDs...)).
+		Find(&rows).Error; err != nil {
+		log.Printf("merge transactions: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	if len(rows) != len(mergeIDs)+1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or does not belong to you"})
+		return
+	}
+	var keep models.Transaction
+	var merged []models.Transaction
+	for _, r := range rows {
+		if isTransferType(r.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Transfers cannot be merged"})
+			return
+		}
+		if r.ID == input.KeepID {
+			keep = r
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	for _, m := range merged {
+		if _, ok := duplicateConfidence(keep, m, maxDuplicateWindowDays); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Transaction " + strconv.FormatUint(uint64(m.ID), 10) +
+					" is not a duplicate of the kept one — type, currency and amount must match within 14 days",
+			})
+			return
+		}
+	}
+	// The kept row takes on tags, a FITID and a description, so it must be
+	// editable too.
+	if reconciled, err := anyReconciled(database.DB, uid, append([]uint{keep.ID}, mergeIDs...)); err != nil {
+		log.Printf("merge transactions: reconciled check user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge transactions"})
+		return
+	} else if reconciled {
+		c.JSON(http.StatusConflict, gin.H{"error": errReconciled.Error()})
+		return
+	}
+	if refunded, err := hasLiveRefunds(database.DB, mergeIDs); err != nil {
+		log.Printf("merge transactions: refunds user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge transactions"})
+		return
+	} else if refunded {
+		c.JSON(http.StatusConflict, gin.H{"error": errHasRefunds.Error()})
+		return
+	}
+
+	tagNames := make([]string, 0)
+	tagSeen := map[string]bool{}
+	for _, r := range append([]models.Transaction{keep}, merged...) {
+		for _, t := range r.Tags {
+			if !tagSeen[t.Name] {
+				tagSeen[t.Name] = true
+				tagNames = append(tagNames, t.Name)
+			}
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{}
+		for _, m := range merged {
+			// The FITID index is per account and covers the trash, so the
+			// merged row gives it up before the kept one takes it.
+			if keep.FITID == "" && m.FITID != "" {
+				if err := tx.Model(&models.Transaction{}).Where("id = ?", m.ID).Update("fitid", "").Error; err != nil {
+					return err
+				}
+				keep.FITID = m.FITID
+				updates["fitid"] = m.FITID
+			}
+			if keep.Description == "" && m.Description != "" {
+				keep.Description = m.Description
+				updates["description"] = m.Description
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&models.Transaction{}).Where("id = ?", keep.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if err := setTransactionTags(tx, &keep, tagNames); err != nil {
+			return err
+		}
+		res := tx.Where("user_id = ? AND id IN ?", uid, mergeIDs).Delete(&models.Transaction{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != int64(len(mergeIDs)) {
+			return errors.New("merged rows changed concurrently")
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("merge transactions: user=%v keep=%v err=%v", uid, input.KeepID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge transactions"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").First(&keep, keep.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Transactions merged", "transaction": keep, "merged_ids": mergeIDs})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// A typed entry and its imported twin pair up with high confidence; merging
+// keeps the typed row, hands it the bank's FITID and tags, and trashes the
+// import. Rows with different FITIDs never pair.
+func TestDuplicatesDetectAndMerge(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	imported := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 23.4, Type: "expense", Description: "REWE MARKT 4711", Date: utcDay(2026, 5, 3), FITID: "F1"}
+	database.DB.Create(&imported)
+	if err := setTransactionTags(database.DB, &imported, []string{"groceries"}); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 9, Type: "expense", Description: "Bakery", Date: utcDay(2026, 5, 3), FITID: "F2"})
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 9, Type: "expense", Description: "Bakery", Date: utcDay(2026, 5, 4), FITID: "F3"})
+
+	w := callHandler(uid, map[string]any{"category_id": f.food.ID, "amount": 23.4, "date": "2026-05-02", "type": "expense", "description": "Rewe markt"}, CreateTransaction)
+	var created struct {
+		Transaction       models.Transaction `json:"transaction"`
+		PossibleDuplicate *possibleDuplicate `json:"possible_duplicate"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	if created.PossibleDuplicate == nil || created.PossibleDuplicate.TransactionID != imported.ID {
+		t.Fatalf("the create should warn about the imported row: %s", w.Body.String())
+	}
+	typed := created.Transaction
+
+	w = callHandlerGET(uid, "", GetDuplicates)
+	var found struct {
+		Duplicates []duplicatePair `json:"duplicates"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &found); err != nil || len(found.Duplicates) != 1 {
+		t.Fatalf("duplicates: %d %s", w.Code, w.Body.String())
+	}
+	if p := found.Duplicates[0]; p.Confidence < 0.8 || p.Transactions[0].ID != imported.ID || p.Transactions[1].ID != typed.ID {
+		t.Errorf("unexpected pair: %.2f %d/%d", p.Confidence, p.Transactions[0].ID, p.Transactions[1].ID)
+	}
+
+	if w := callHandler(uid, map[string]any{"keep_id": typed.ID, "merge_ids": []uint{typed.ID}}, MergeTransactions); w.Code != http.StatusBadRequest {
+		t.Errorf("keeping and merging the same row: want 400, got %d", w.Code)
+	}
+	if w := callHandler(uid, map[string]any{"keep_id": typed.ID, "merge_ids": []uint{imported.ID}}, MergeTransactions); w.Code != http.StatusOK {
+		t.Fatalf("merge: %d %s", w.Code, w.Body.String())
+	}
+	var kept models.Transaction
+	database.DB.Preload("Tags").First(&kept, typed.ID)
+	if kept.FITID != "F1" || len(kept.Tags) != 1 || kept.Tags[0].Name != "groceries" {
+		t.Errorf("kept row should carry the FITID and tags: %q %+v", kept.FITID, kept.Tags)
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("id = ?", imported.ID).Count(&live)
+	if live != 0 {
+		t.Errorf("the merged row should be in the trash")
+	}
+}
+
+// seedTwins stores two rows that pass as duplicates of each other.
+func seedTwins(t *testing.T, f splitFixture) (models.Transaction, models.Transaction) {
+	t.Helper()
+	a := models.Transaction{UserID: f.user.ID, CategoryID: f.food.ID, Amount: 40, Type: "expense", Description: "Market", Date: utcDay(2026, 5, 3)}
+	b := models.Transaction{UserID: f.user.ID, CategoryID: f.food.ID, Amount: 40, Type: "expense", Description: "MARKET 123", Date: utcDay(2026, 5, 4)}
+	database.DB.Create(&a)
+	database.DB.Create(&b)
+	return a, b
+}
+
+func mergeCode(uid, keep uint, merge ...uint) int {
+	return callHandler(uid, map[string]any{"keep_id": keep, "merge_ids": merge}, MergeTransactions).Code
+}
+
+// Only rows that could be the same payment merge: a refund is not a twin of
+// an unrelated expense, nor are different amounts or dates weeks apart.
+func TestMerge_RejectsNonDuplicates(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	keep, twin := seedTwins(t, f)
+	w := callHandler(uid, map[string]any{"type": "refund", "refund_of_id": twin.ID, "amount": 40, "date": "2026-05-06"}, CreateTransaction)
+	var refund struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &refund); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("refund: %d %s", w.Code, w.Body.String())
+	}
+	other := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 41, Type: "expense", Date: utcDay(2026, 5, 3)}
+	late := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 40, Type: "expense", Date: utcDay(2026, 6, 30)}
+	database.DB.Create(&other)
+	database.DB.Create(&late)
+
+	for name, id := range map[string]uint{"refund": refund.Transaction.ID, "other amount": other.ID, "weeks apart": late.ID} {
+		if code := mergeCode(uid, keep.ID, id); code != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", name, code)
+		}
+	}
+}
+
+// Transfers never merge, and neither side of a merge may be reconciled or
+// have refunds hanging off a row that would go to the trash.
+func TestMerge_RefusesProtectedRows(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+
+	w := callHandler(uid, map[string]any{"name": "Wallet", "type": "cash"}, CreateAccount)
+	var wallet struct {
+		Account models.Account `json:"account"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &wallet)
+	def, _ := defaultAccount(database.DB, uid)
+	w = callHandler(uid, map[string]any{"from_account_id": def.ID, "to_account_id": wallet.Account.ID, "amount": 40, "date": "2026-05-03"}, CreateAccountTransfer)
+	var transfer struct {
+		From models.Transaction `json:"from"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &transfer); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("transfer: %d %s", w.Code, w.Body.String())
+	}
+	keep, twin := seedTwins(t, f)
+	if code := mergeCode(uid, keep.ID, transfer.From.ID); code != http.StatusBadRequest {
+		t.Errorf("merging a transfer: want 400, got %d", code)
+	}
+
+	database.DB.Model(&keep).Update("status", txStatusReconciled)
+	if code := mergeCode(uid, keep.ID, twin.ID); code != http.StatusConflict {
+		t.Errorf("reconciled kept row: want 409, got %d", code)
+	}
+	if code := mergeCode(uid, twin.ID, keep.ID); code != http.StatusConflict {
+		t.Errorf("reconciled merged row: want 409, got %d", code)
+	}
+	database.DB.Model(&keep).Update("status", txStatusPending)
+
+	if w := callHandler(uid, map[string]any{"type": "refund", "refund_of_id": twin.ID, "amount": 10, "date": "2026-05-06"}, CreateTransaction); w.Code != http.StatusCreated {
+		t.Fatalf("refund: %d %s", w.Code, w.Body.String())
+	}
+	if code := mergeCode(uid, keep.ID, twin.ID); code != http.StatusConflict {
+		t.Errorf("merged row with a live refund: want 409, got %d", code)
+	}
+	if code := mergeCode(uid, twin.ID, keep.ID); code != http.StatusOK {
+		t.Errorf("keeping the refunded row instead: want 200, got %d", code)
+	}
+}
@@ -0,0 +1,651 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Envelope (zero-based) budgeting ──────────────────────────────────────────
+//
+// The alternative to the 50/30/20 framework: the income of the current window
+// (the active salary cycle, else the calendar month — the same window as
+// per-category budgets) is handed out to named envelopes until nothing is left
+// "to be assigned". Spending in a category draws down the envelope the category
+// points at. Money only ever moves through EnvelopeTransfer rows, so every
+// balance is auditable, and the server refuses any move that would assign more
+// than there is or take more out of an envelope than it holds.
+
+const (
+	budgetModeFramework = "framework"
+	budgetModeEnvelope  = "envelope"
+)
+
+// envelopeBalance is one envelope's state within a window.
+type envelopeBalance struct {
+	models.Envelope
+	CategoryIDs []uint  `json:"category_ids"`
+	Assigned    float64 `json:"assigned"`
+	Spent       float64 `json:"spent"`
+	Balance     float64 `json:"balance"`
+}
+
+// envelopeState is the whole envelope picture for a window.
+type envelopeState struct {
+	WindowStart  time.Time `json:"window_start"`
+	WindowEnd    time.Time `json:"window_end"`
+	Income       float64   `json:"income"`
+	Assigned     float64   `json:"assigned"`
+	ToBeAssigned float64   `json:"to_be_assigned"`
+	// UnenvelopedSpent is spending in categories linked to no envelope.
+	UnenvelopedSpent float64           `json:"unenveloped_spent"`
+	Envelopes        []envelopeBalance `json:"envelopes"`
+}
+
+// envelopeOf resolves the envelope a category draws from: its own, else the
+// nearest ancestor's, else 0.
+func envelopeOf(forest *categoryForest, categoryID uint) uint {
+	for _, id := range append([]uint{categoryID}, forest.ancestors(categoryID)...) {
+		if c, ok := forest.byID[id]; ok && c.EnvelopeID != nil {
+			return *c.EnvelopeID
+		}
+	}
+	return 0
+}
+
+// computeEnvelopeState reads income, transfers and spending of window w.
+// Only transfers made inside the window count, so each window starts from a
+// clean slate and its income has to be assigned afresh.
+func computeEnvelopeState(db *gorm.DB, uid uint, w spendWindow) (envelopeState, error) {
+	st := envelopeState{WindowStart: w.From, WindowEnd: w.To, Envelopes: []envelopeBalance{}}
+
+	var envelopes []models.Envelope
+	if err := db.Where("user_id = ?", uid).Order("name asc").Find(&envelopes).Error; err != nil {
+		return st, err
+	}
+	forest, err := loadCategoryForest(db, uid)
+	if err != nil {
+		return st, err
+	}
+	if err := db.Model(&models.Transaction{}).
+		Where("user_id = ? AND type = ? AND created_at >= ? AND created_at < ?", uid, "income", w.From, w.To).
+		Select("COALESCE(SUM(amount), 0)").Scan(&st.Income).Error; err != nil {
+		return st, err
+	}
+	var transfers []models.EnvelopeTransfer
+	if err := db.Where("user_id = ? AND created_at >= ? AND created_at < ?", uid, w.From, w.To).
+		Find(&transfers).Error; err != nil {
+		return st, err
+	}
+	spent, err := spentByCategory(db, uid, w)
+	if err != nil {
+		return st, err
+	}
+
+	index := make(map[uint]int, len(envelopes))
+	for i, e := range envelopes {
+		index[e.ID] = i
+		st.Envelopes = append(st.Envelopes, envelopeBalance{Envelope: e, CategoryIDs: []uint{}})
+	}
+	for _, c := range forest.byID {
+		if c.EnvelopeID != nil {
+			if i, ok := index[*c.EnvelopeID]; ok {
+				st.Envelopes[i].CategoryIDs = append(st.Envelopes[i].CategoryIDs, c.ID)
+			}
+		}
+	}
+	// Transfers touching a deleted envelope only count on the side that still
+	// exists — whatever the deleted one held falls back to "to be assigned".
+	for _, t := range transfers {
+		if t.ToEnvelopeID != nil {
+			if i, ok := index[*t.ToEnvelopeID]; ok {
+				st.Envelopes[i].Assigned += t.Amount
+			}
+		}
+		if t.FromEnvelopeID != nil {
+			if i, ok := index[*t.FromEnvelopeID]; ok {
+				st.Envelopes[i].Assigned -= t.Amount
+			}
+		}
+	}
+	for catID, amount := range spent {
+		if i, ok := index[envelopeOf(forest, catID)]; ok {
+			st.Envelopes[i].Spent += amount
+		} else {
+			st.UnenvelopedSpent += amount
+		}
+	}
+
+	for i := range st.Envelopes {
+		e := &st.Envelopes[i]
+		sort.Slice(e.CategoryIDs, func(a, b int) bool { return e.CategoryIDs[a] < e.CategoryIDs[b] })
+		st.Assigned += e.Assigned
+		e.Assigned, e.Spent = round2(e.Assigned), round2(e.Spent)
+		e.Balance = round2(e.Assigned - e.Spent)
+	}
+	st.Income, st.Assigned, st.UnenvelopedSpent = round2(st.Income), round2(st.Assigned), round2(st.UnenvelopedSpent)
+	st.ToBeAssigned = round2(st.Income - st.Assigned)
+	return st, nil
+}
+
+// errEnvelopeRejected aborts a transfer's DB transaction; the caller reports
+// the accompanying message as a 409.
+var errEnvelopeRejected = errors.New("envelope transfer rejected")
+
+// recordEnvelopeTransfer moves amount from one side to the other (nil = the
+// "to be assigned" pool) after checking, inside the same DB transaction, that
+// the source actually holds it. Returns the state after the move, or a
+// non-empty rejection message.
+func recordEnvelopeTransfer(uid uint, from, to *uint, amount float64, note string) (envelopeState, string, error) {
+	var st envelopeState
+	var rejected string
+	w, _, _ := budgetWindows(uid, userNow(uid))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := computeEnvelopeState(tx, uid, w)
+		if err != nil {
+			return err
+		}
+		available := before.ToBeAssigned
+		if from != nil {
+			available = 0
+			for _, e := range before.Envelopes {
+				if e.ID == *from {
+					available = e.Balance
+				}
+			}
+		}
+		if amount > available {
+			if from == nil {
+				rejected = "Only " + strconv.FormatFloat(max(available, 0), 'f', 2, 64) + " is left to assign"
+			} else {
+				rejected = "The envelope only holds " + strconv.FormatFloat(max(available, 0), 'f', 2, 64)
+			}
+			return errEnvelopeRejected
+		}
+		t := models.EnvelopeTransfer{UserID: uid, FromEnvelopeID: from, ToEnvelopeID: to, Amount: amount, Note: note, CreatedAt: time.Now()}
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		st, err = computeEnvelopeState(tx, uid, w)
+		return err
+	})
+	if errors.Is(err, errEnvelopeRejected) {
+		return st, rejected, nil
+	}
+	return st, "", err
+}
+
+// loadEnvelope fetches one of the user's envelopes.
+func loadEnvelope(uid uint, id uint) (models.Envelope, error) {
+	var e models.Envelope
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&e).Error
+	return e, err
+}
+
+// linkEnvelopeCategories points exactly categoryIDs at envelope id, unlinking
+// any category that pointed at it before. A category can only draw from one
+// envelope, so linking takes it away from its previous envelope.
+func linkEnvelopeCategories(tx *gorm.DB, uid, id uint, categoryIDs []uint) error {
+	if err := tx.Model(&models.Category{}).Where("user_id = ? AND envelope_id = ?", uid, id).
+		Update("envelope_id", nil).Error; err != nil {
+		return err
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Category{}).Where("user_id = ? AND id IN ?", uid, categoryIDs).
+		Update("envelope_id", id).Error
+}
+
+// checkEnvelopeCategories verifies every ID is one of the user's categories.
+func checkEnvelopeCategories(uid uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var n int64
+	if err := database.DB.Model(&models.Category{}).Where("user_id = ? AND id IN ?", uid, ids).Count(&n).Error; err != nil {
+		return err
+	}
+	if int(n) != len(uniqueUints(ids)) {
+		return errors.New("One or more categories not found or do not belong to you")
+	}
+	return nil
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := map[uint]bool{}
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// GetEnvelopes → GET /api/envelopes
+// Income, to-be-assigned and every envelope's balance in the current window.
+func GetEnvelopes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var user models.User
+	if err := database.DB.First(&user, uid).Error; err != nil {
+		log.Printf("get envelopes: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load profile"})
+		return
+	}
+	w, _, _ := budgetWindows(uid, userNow(uid))
+	st, err := computeEnvelopeState(database.DB, uid, w)
+	if err != nil {
+		log.Printf("get envelopes: compute user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch envelopes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budget_mode": user.BudgetMode, "state": st})
+}
+
+func CreateEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		CategoryIDs []uint `json:"category_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Envelope name must be 1–100 characters"})
+		return
+	}
+	if err := checkEnvelopeCategories(uid, input.CategoryIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var n int64
+	database.DB.Model(&models.Envelope{}).Where("user_id = ? AND name = ?", uid, input.Name).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An envelope with this name already exists"})
+		return
+	}
+
+	envelope := models.Envelope{UserID: uid, Name: input.Name, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&envelope).Error; err != nil {
+			return err
+		}
+		return linkEnvelopeCategories(tx, uid, envelope.ID, input.CategoryIDs)
+	}); err != nil {
+		log.Printf("create envelope: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create envelope"})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Envelope created successfully", "envelope": envelope})
+}
+
+// GetEnvelope → GET /api/envelopes/:id
+// One envelope, with the ETag a later update or delete can send as If-Match.
+func GetEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid envelope ID format"})
+		return
+	}
+	envelope, err := loadEnvelope(uid, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+		} else {
+			log.Printf("get envelope: user=%v envelope=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch envelope"})
+		}
+		return
+	}
+
+	c.Header("ETag", envelopeETag(envelope))
+	c.JSON(http.StatusOK, gin.H{"envelope": envelope})
+}
+
+// UpdateEnvelope renames an envelope and/or replaces its set of categories.
+func UpdateEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid envelope ID format"})
+		return
+	}
+	envelope, err := loadEnvelope(uid, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+		} else {
+			log.Printf("update envelope fetch: user=%v envelope=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch envelope"})
+		}
+		return
+	}
+	if etag := envelopeETag(envelope); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"envelope": envelope})
+		return
+	}
+	seen := envelope.UpdatedAt
+
+	var input struct {
+		Name        *string `json:"name"`
+		CategoryIDs *[]uint `json:"category_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" || len(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Envelope name must be 1–100 characters"})
+			return
+		}
+		var n int64
+		database.DB.Model(&models.Envelope{}).Where("user_id = ? AND name = ? AND id <> ?", uid, name, envelope.ID).Count(&n)
+		if n > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "An envelope with this name already exists"})
+			return
+		}
+		envelope.Name = name
+	}
+	if input.CategoryIDs != nil {
+		if err := checkEnvelopeCategories(uid, *input.CategoryIDs); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	envelope.UpdatedAt = time.Now()
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Envelope{}, envelope.ID, seen); err != nil {
+			return err
+		}
+		if err := tx.Save(&envelope).Error; err != nil {
+			return err
+		}
+		if input.CategoryIDs == nil {
+			return nil
+		}
+		return linkEnvelopeCategories(tx, uid, envelope.ID, *input.CategoryIDs)
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Envelope not found or does not belong to you", func() (string, gin.H, error) {
+			return currentEnvelope(uid, envelope.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update envelope save: user=%v envelope=%v err=%v", uid, envelope.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update envelope"})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.Header("ETag", envelopeETag(envelope))
+	c.JSON(http.StatusOK, gin.H{"message": "Envelope updated successfully", "envelope": envelope})
+}
+
+// DeleteEnvelope unlinks the envelope's categories and removes it. Its
+// transfers stay in the log; whatever it still held returns to "to be
+// assigned".
+func DeleteEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid envelope ID format"})
+		return
+	}
+
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		current, err := loadEnvelope(uid, uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+			return
+		}
+		if etag := envelopeETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"envelope": current})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Envelope{}, uint(id), seen); err != nil {
+			return err
+		}
+		res := tx.Where("id = ? AND user_id = ?", uint(id), uid).Delete(&models.Envelope{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if deleted = res.RowsAffected; deleted == 0 {
+			return nil
+		}
+		return linkEnvelopeCategories(tx, uid, uint(id), nil)
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Envelope not found or does not belong to you", func() (string, gin.H, error) {
+			return currentEnvelope(uid, uint(id))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete envelope: user=%v envelope=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete envelope"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Envelope deleted successfully"})
+}
+
+// AssignEnvelope → POST /api/envelopes/assign
+// {"envelope_id", "amount", "note"}: a positive amount moves money from "to be
+// assigned" into the envelope, a negative one hands it back.
+func AssignEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		EnvelopeID uint    `json:"envelope_id" binding:"required"`
+		Amount     float64 `json:"amount" binding:"required"`
+		Note       string  `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount := round2(input.Amount)
+	if amount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must not be zero"})
+		return
+	}
+	if len(input.Note) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Note must be 255 characters or fewer"})
+		return
+	}
+	if _, err := loadEnvelope(uid, input.EnvelopeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+		return
+	}
+
+	id := input.EnvelopeID
+	var from, to *uint = nil, &id
+	if amount < 0 {
+		from, to, amount = &id, nil, -amount
+	}
+	st, rejected, err := recordEnvelopeTransfer(uid, from, to, amount, input.Note)
+	if err != nil {
+		log.Printf("assign envelope: user=%v envelope=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign money"})
+		return
+	}
+	if rejected != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": rejected})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Money assigned", "state": st})
+}
+
+// MoveEnvelope → POST /api/envelopes/move
+// {"from_envelope_id", "to_envelope_id", "amount", "note"}.
+func MoveEnvelope(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		FromEnvelopeID uint    `json:"from_envelope_id" binding:"required"`
+		ToEnvelopeID   uint    `json:"to_envelope_id" binding:"required"`
+		Amount         float64 `json:"amount" binding:"required,gt=0"`
+		Note           string  `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.FromEnvelopeID == input.ToEnvelopeID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and target envelope must differ"})
+		return
+	}
+	if len(input.Note) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Note must be 255 characters or fewer"})
+		return
+	}
+	for _, id := range []uint{input.FromEnvelopeID, input.ToEnvelopeID} {
+		if _, err := loadEnvelope(uid, id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found or does not belong to you"})
+			return
+		}
+	}
+
+	st, rejected, err := recordEnvelopeTransfer(uid, &input.FromEnvelopeID, &input.ToEnvelopeID, round2(input.Amount), input.Note)
+	if err != nil {
+		log.Printf("move envelope: user=%v from=%v to=%v err=%v", uid, input.FromEnvelopeID, input.ToEnvelopeID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move money"})
+		return
+	}
+	if rejected != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": rejected})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Money moved", "state": st})
+}
+
+// GetEnvelopeTransfers → GET /api/envelopes/transfers
+// The audit log of the current window, newest first.
+func GetEnvelopeTransfers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	w, _, _ := budgetWindows(uid, userNow(uid))
+	var transfers []models.EnvelopeTransfer
+	if err := database.DB.Where("user_id = ? AND created_at >= ? AND created_at < ?", uid, w.From, w.To).
+		Order("created_at desc").Order("id desc").Find(&transfers).Error; err != nil {
+		log.Printf("get envelope transfers: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"window_start": w.From, "window_end": w.To, "transfers": transfers})
+}
+
+// SetBudgetMode → PUT /api/envelopes/mode {"mode": "framework" | "envelope"}
+// Switching is non-destructive: envelopes and transfers are kept either way.
+func SetBudgetMode(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Mode != budgetModeFramework && input.Mode != budgetModeEnvelope {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode. Allowed values: framework, envelope"})
+		return
+	}
+	if err := database.DB.Model(&models.User{}).Where("id = ?", uid).Update("budget_mode", input.Mode).Error; err != nil {
+		log.Printf("set budget mode: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget mode"})
+		return
+	}
+	InvalidateCycleCache(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget mode updated", "budget_mode": input.Mode})
+}
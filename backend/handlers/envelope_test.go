@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func envelopeStateOf(t *testing.T, uid uint) envelopeState {
+	t.Helper()
+	w := callHandlerGET(uid, "", GetEnvelopes)
+	var resp struct {
+		State envelopeState `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("get envelopes: %d %s", w.Code, w.Body.String())
+	}
+	return resp.State
+}
+
+// Income is assigned to envelopes, never beyond "to be assigned"; spending in
+// a (sub-)category draws down its envelope and moves are capped by the balance.
+func TestEnvelopes_AssignMoveAndSpend(t *testing.T) {
+	setupFlowDB(t)
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	now := time.Now()
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 1000, Type: "income", Date: now, CreatedAt: now})
+	snacks := createCategory(t, uid, "Snacks", f.food.ID)
+
+	var groceries, fun models.Envelope
+	for _, e := range []struct {
+		name string
+		cats []uint
+		out  *models.Envelope
+	}{{"Groceries", []uint{f.food.ID}, &groceries}, {"Fun", []uint{f.beauty.ID}, &fun}} {
+		w := callHandler(uid, map[string]any{"name": e.name, "category_ids": e.cats}, CreateEnvelope)
+		var resp struct {
+			Envelope models.Envelope `json:"envelope"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("create %s: %d %s", e.name, w.Code, w.Body.String())
+		}
+		*e.out = resp.Envelope
+	}
+
+	if w := callHandler(uid, map[string]any{"envelope_id": groceries.ID, "amount": 600}, AssignEnvelope); w.Code != http.StatusOK {
+		t.Fatalf("assign: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandler(uid, map[string]any{"envelope_id": fun.ID, "amount": 500}, AssignEnvelope); w.Code != http.StatusConflict {
+		t.Errorf("assigning more than is left: want 409, got %d", w.Code)
+	}
+	if w := callHandler(uid, map[string]any{"envelope_id": fun.ID, "amount": 400}, AssignEnvelope); w.Code != http.StatusOK {
+		t.Fatalf("assign rest: %d %s", w.Code, w.Body.String())
+	}
+	addExpense(t, uid, snacks.ID, 150, now)
+
+	if w := callHandler(uid, map[string]any{"from_envelope_id": groceries.ID, "to_envelope_id": fun.ID, "amount": 500}, MoveEnvelope); w.Code != http.StatusConflict {
+		t.Errorf("moving more than the balance: want 409, got %d", w.Code)
+	}
+	if w := callHandler(uid, map[string]any{"from_envelope_id": groceries.ID, "to_envelope_id": fun.ID, "amount": 50, "note": "treat"}, MoveEnvelope); w.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", w.Code, w.Body.String())
+	}
+
+	st := envelopeStateOf(t, uid)
+	if st.Income != 1000 || st.ToBeAssigned != 0 || len(st.Envelopes) != 2 {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+	fe, ge := st.Envelopes[0], st.Envelopes[1] // ordered by name
+	if ge.Assigned != 550 || ge.Spent != 150 || ge.Balance != 400 {
+		t.Errorf("groceries: %+v", ge)
+	}
+	if fe.Assigned != 450 || fe.Balance != 450 {
+		t.Errorf("fun: %+v", fe)
+	}
+
+	// Deleting an envelope hands what it held back to "to be assigned".
+	if w := callHandlerParam(uid, catParam(fun.ID), DeleteEnvelope); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	if st := envelopeStateOf(t, uid); st.ToBeAssigned != 450 || len(st.Envelopes) != 1 {
+		t.Errorf("after delete: %+v", st)
+	}
+	var n int64
+	database.DB.Model(&models.EnvelopeTransfer{}).Where("user_id = ?", uid).Count(&n)
+	if n != 3 {
+		t.Errorf("want all 3 transfers kept in the audit log, got %d", n)
+	}
+}
+
+// The cycle stats carry the envelope picture only in envelope mode.
+func TestCycleStats_EnvelopeMode(t *testing.T) {
+	setupFlowDB(t)
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	now := time.Now()
+	cycle := models.SalaryCycle{UserID: uid, TotalIncome: 800, CycleStartAt: now.AddDate(0, 0, -3)}
+	database.DB.Create(&cycle)
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 800, Type: "income", Date: now, CreatedAt: now})
+
+	if s := computeCycleStats(uid, cycle); s.Envelopes != nil {
+		t.Errorf("framework mode: want no envelope state")
+	}
+	if w := callHandlerParamBody(uid, nil, map[string]any{"mode": "envelope"}, SetBudgetMode); w.Code != http.StatusOK {
+		t.Fatalf("set mode: %d %s", w.Code, w.Body.String())
+	}
+	s := computeCycleStats(uid, cycle)
+	if s.Envelopes == nil || s.Envelopes.ToBeAssigned != 800 {
+		t.Errorf("envelope mode: want 800 to be assigned, got %+v", s.Envelopes)
+	}
+}
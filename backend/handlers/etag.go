@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Optimistic concurrency: ETag / If-Match ──────────────────────────────────
+//
+// Single-resource GETs send an ETag derived from the row's UpdatedAt. A write
+// that carries If-Match only goes through while the row still has that ETag;
+// otherwise it gets 412 with the current representation so the client can
+// rebase its edit. Without If-Match writes stay unconditional, as before.
+//
+// Comparing the ETag up front is only a fast path: the write itself is made
+// conditional by claimIfMatch, so two clients holding the same ETag can never
+// both get through.
+
+// resourceETag is the strong ETag of one row. UpdatedAt counts in
+// microseconds, the finest precision every supported database keeps, so a
+// freshly saved row and the same row read back share their ETag.
+func resourceETag(kind string, id uint, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%d", kind, id, updatedAt.UTC().UnixMicro())))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// ifMatchFails reports whether the request has an If-Match header that
+// names neither etag nor "*".
+func ifMatchFails(c *gin.Context, etag string) bool {
+	h := c.GetHeader("If-Match")
+	if h == "" {
+		return false
+	}
+	for _, tag := range strings.Split(h, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag {
+			return false
+		}
+	}
+	return true
+}
+
+// preconditionFailed answers 412 with current — the body a GET of the
+// resource would return — and its ETag.
+func preconditionFailed(c *gin.Context, etag string, current gin.H) {
+	c.Header("ETag", etag)
+	current["error"] = "The resource was changed since you loaded it — review the current version and retry"
+	c.JSON(http.StatusPreconditionFailed, current)
+}
+
+// errStale is returned from inside a write's DB transaction when the row was
+// saved by someone else after its ETag was checked.
+var errStale = errors.New("resource changed since its ETag was checked")
+
+// claimIfMatch makes a write that carries If-Match conditional. It bumps the
+// row's updated_at only while it still equals seen — the value the ETag was
+// checked against — and returns errStale when no row matched. Call it first
+// inside the write's DB transaction: the row stays locked until commit, so
+// the check and the write are one step. Without If-Match it does nothing.
+func claimIfMatch(c *gin.Context, tx *gorm.DB, model any, id uint, seen time.Time) error {
+	if c.GetHeader("If-Match") == "" {
+		return nil
+	}
+	res := tx.Model(model).Where("id = ? AND updated_at = ?", id, seen).UpdateColumn("updated_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errStale
+	}
+	return nil
+}
+
+// respondStale answers a write that lost the race in claimIfMatch: 412 with
+// the version load finds now, or 404 with notFound once the row is gone.
+func respondStale(c *gin.Context, notFound string, load func() (etag string, current gin.H, err error)) {
+	etag, current, err := load()
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the current version"})
+	default:
+		preconditionFailed(c, etag, current)
+	}
+}
+
+func transactionETag(t models.Transaction) string {
+	return resourceETag("transaction", t.ID, t.UpdatedAt)
+}
+
+// currentTransaction is transaction id as GET /transactions/:id returns it.
+func currentTransaction(uid, id uint) (string, gin.H, error) {
+	var t models.Transaction
+	err := database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").
+		Where("id = ? AND user_id = ?", id, uid).First(&t).Error
+	return transactionETag(t), gin.H{"transaction": t}, err
+}
+
+func categoryETag(cat models.Category) string {
+	return resourceETag("category", cat.ID, cat.UpdatedAt)
+}
+
+// currentCategory is category id as GET /categories/:id returns it.
+func currentCategory(uid, id uint) (string, gin.H, error) {
+	var cat models.Category
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&cat).Error
+	return categoryETag(cat), gin.H{"category": cat}, err
+}
+
+func profileETag(u models.User) string {
+	return resourceETag("profile", u.ID, u.UpdatedAt)
+}
+
+// currentProfile is the user's profile as GET /profile returns it.
+func currentProfile(uid uint) (string, gin.H, error) {
+	var u models.User
+	err := database.DB.First(&u, uid).Error
+	return profileETag(u), profileView(u), err
+}
+
+func cycleETag(cy models.SalaryCycle) string {
+	return resourceETag("salary_cycle", cy.ID, cy.UpdatedAt)
+}
+
+// currentCycle is cycle id of uid in the shape a 412 on a cycle carries.
+func currentCycle(uid, id uint) (string, gin.H, error) {
+	var cy models.SalaryCycle
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&cy).Error
+	return cycleETag(cy), gin.H{"cycle": cy}, err
+}
+
+func tagETag(t models.Tag) string {
+	return resourceETag("tag", t.ID, t.UpdatedAt)
+}
+
+// currentTag is tag id as GET /tags/:id returns it.
+func currentTag(uid, id uint) (string, gin.H, error) {
+	var t models.Tag
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&t).Error
+	return tagETag(t), gin.H{"tag": t}, err
+}
+
+func recurringETag(r models.RecurringTransaction) string {
+	return resourceETag("recurring", r.ID, r.UpdatedAt)
+}
+
+// currentRecurring is template id as GET /recurring/:id returns it.
+func currentRecurring(uid, id uint) (string, gin.H, error) {
+	var r models.RecurringTransaction
+	err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", id, uid).First(&r).Error
+	return recurringETag(r), gin.H{"recurring": r}, err
+}
+
+func accountETag(a models.Account) string {
+	return resourceETag("account", a.ID, a.UpdatedAt)
+}
+
+// currentAccount is account id as GET /accounts/:id returns it.
+func currentAccount(uid, id uint) (string, gin.H, error) {
+	var a models.Account
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&a).Error
+	return accountETag(a), gin.H{"account": a}, err
+}
+
+// reconciliationETag versions a session; clearing rows through it counts as
+// a change of the session.
+func reconciliationETag(r models.Reconciliation) string {
+	return resourceETag("reconciliation", r.ID, r.UpdatedAt)
+}
+
+// currentReconciliation is session id as GET /reconciliations/:id returns it.
+func currentReconciliation(uid, id uint) (string, gin.H, error) {
+	var rec models.Reconciliation
+	if err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&rec).Error; err != nil {
+		return "", nil, err
+	}
+	var account models.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", rec.AccountID, uid).First(&account).Error; err != nil {
+		return "", nil, err
+	}
+	view, err := computeReconciliation(database.DB, uid, rec, account)
+	return reconciliationETag(rec), view.asH(), err
+}
+
+func ruleETag(r models.CategoryRule) string {
+	return resourceETag("rule", r.ID, r.UpdatedAt)
+}
+
+// currentRule is rule id as GET /rules/:id returns it.
+func currentRule(uid, id uint) (string, gin.H, error) {
+	var r models.CategoryRule
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&r).Error
+	return ruleETag(r), gin.H{"rule": toRuleResponse(r)}, err
+}
+
+func savingsGoalETag(g models.SavingsGoal) string {
+	return resourceETag("savings_goal", g.ID, g.UpdatedAt)
+}
+
+// currentSavingsGoal is goal id as GET /savings-goals/:id returns it.
+func currentSavingsGoal(uid, id uint) (string, gin.H, error) {
+	goal, err := loadSavingsGoal(uid, id)
+	if err != nil {
+		return "", nil, err
+	}
+	byGoal, err := loadGoalTransactions(uid)
+	if err != nil {
+		return "", nil, err
+	}
+	p := computeGoalProgress(goal, byGoal[goal.ID], goalPeriodDays(uid), userNow(uid))
+	return savingsGoalETag(goal), gin.H{"goal": p}, nil
+}
+
+func categoryBudgetETag(b models.CategoryBudget) string {
+	return resourceETag("category_budget", b.ID, b.UpdatedAt)
+}
+
+// currentCategoryBudget is budget id as GET /budget/categories/:id returns it.
+func currentCategoryBudget(uid, id uint) (string, gin.H, error) {
+	var b models.CategoryBudget
+	err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", id, uid).First(&b).Error
+	return categoryBudgetETag(b), gin.H{"budget": b}, err
+}
+
+func envelopeETag(e models.Envelope) string {
+	return resourceETag("envelope", e.ID, e.UpdatedAt)
+}
+
+// currentEnvelope is envelope id as GET /envelopes/:id returns it.
+func currentEnvelope(uid, id uint) (string, gin.H, error) {
+	e, err := loadEnvelope(uid, id)
+	return envelopeETag(e), gin.H{"envelope": e}, err
+}
+
+// setCycleETag sets the ETag of the cycle in a GET /salary-cycle/current
+// payload; the no-cycle payload has none.
+func setCycleETag(c *gin.Context, payload gin.H) {
+	if cy, ok := payload["cycle"].(models.SalaryCycle); ok {
+		c.Header("ETag", cycleETag(cy))
+	}
+}
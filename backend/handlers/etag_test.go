@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// callHandlerIfMatch runs h as a PUT with an optional If-Match header.
+func callHandlerIfMatch(uid uint, params gin.Params, body any, ifMatch string, h gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(body)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", &buf)
+	c.Request.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		c.Request.Header.Set("If-Match", ifMatch)
+	}
+	c.Params = params
+	c.Set("userID", uid)
+	h(c)
+	return w
+}
+
+// A write with a stale If-Match gets 412 and the current row; the ETag a
+// successful write returns is the one the next GET sends.
+func TestETagIfMatch(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	tx := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 10, Type: "expense", Date: utcDay(2026, 3, 10)}
+	database.DB.Create(&tx)
+
+	w := callHandlerParam(uid, txParam(tx.ID), GetTransactionByID)
+	loaded := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || loaded == "" {
+		t.Fatalf("GET should send an ETag: %d %q", w.Code, loaded)
+	}
+
+	w = callHandlerIfMatch(uid, txParam(tx.ID), map[string]any{"amount": 12}, loaded, UpdateTransaction)
+	saved := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || saved == "" || saved == loaded {
+		t.Fatalf("update with the current ETag: %d %q", w.Code, saved)
+	}
+	if got := callHandlerParam(uid, txParam(tx.ID), GetTransactionByID).Header().Get("ETag"); got != saved {
+		t.Errorf("the update's ETag %s should match a fresh GET, got %s", saved, got)
+	}
+
+	w = callHandlerIfMatch(uid, txParam(tx.ID), map[string]any{"amount": 99}, loaded, UpdateTransaction)
+	var stale struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &stale)
+	if w.Code != http.StatusPreconditionFailed || stale.Transaction.Amount != 12 || w.Header().Get("ETag") != saved {
+		t.Errorf("a stale If-Match: want 412 with the current row, got %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerIfMatch(uid, txParam(tx.ID), map[string]any{"amount": 13}, "", UpdateTransaction); w.Code != http.StatusOK {
+		t.Errorf("no If-Match stays unconditional: got %d", w.Code)
+	}
+
+	w = callHandlerIfMatch(uid, catParam(f.food.ID), map[string]any{"name": "Groceries"}, `"nope"`, UpdateCategory)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("category with a stale If-Match: want 412, got %d", w.Code)
+	}
+	if w := callHandlerIfMatch(uid, catParam(f.food.ID), map[string]any{"name": "Groceries"}, "*", UpdateCategory); w.Code != http.StatusOK {
+		t.Errorf("If-Match: * should match: got %d", w.Code)
+	}
+}
+
+// Two writers holding the same ETag: the conditional claim lets exactly one
+// through, however late the second one checked.
+func TestClaimIfMatch_OneWriterWins(t *testing.T) {
+	f := seedSplitUser(t)
+	tx := models.Transaction{UserID: f.user.ID, CategoryID: f.food.ID, Amount: 10, Type: "expense", Date: utcDay(2026, 3, 10)}
+	database.DB.Create(&tx)
+	var loaded models.Transaction
+	database.DB.First(&loaded, tx.ID)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+	c.Request.Header.Set("If-Match", transactionETag(loaded))
+
+	if err := claimIfMatch(c, database.DB, &models.Transaction{}, tx.ID, loaded.UpdatedAt); err != nil {
+		t.Fatalf("first writer: %v", err)
+	}
+	if err := claimIfMatch(c, database.DB, &models.Transaction{}, tx.ID, loaded.UpdatedAt); !errors.Is(err, errStale) {
+		t.Errorf("second writer with the same ETag: want errStale, got %v", err)
+	}
+}
+
+// Every single resource hands out an ETag that its writes honour: a write
+// with the current tag goes through and returns the next one, a delete with
+// the tag it replaced gets 412, and one with the current tag succeeds.
+func TestETagIfMatch_AllResources(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	future := utcDay(2030, 1, 1)
+	tag := models.Tag{UserID: uid, Name: "trip"}
+	account := models.Account{UserID: uid, Name: "Wallet", Type: "cash"}
+	rule := models.CategoryRule{UserID: uid, Name: "Lidl", DescriptionContains: "LIDL", CategoryID: f.food.ID}
+	goal := models.SavingsGoal{UserID: uid, Name: "Bike", TargetAmount: 400}
+	budget := models.CategoryBudget{UserID: uid, CategoryID: f.food.ID, Limit: 100}
+	envelope := models.Envelope{UserID: uid, Name: "Fun"}
+	recurring := models.RecurringTransaction{UserID: uid, CategoryID: f.rent.ID, Amount: 700, Type: "expense",
+		Frequency: "monthly", Interval: 1, DayOfMonth: 1, StartDate: future, NextDate: &future}
+	for _, row := range []any{&tag, &account, &rule, &goal, &budget, &envelope, &recurring} {
+		database.DB.Create(row)
+	}
+	rec := models.Reconciliation{UserID: uid, AccountID: account.ID, StatementDate: utcDay(2026, 3, 31), Status: "open"}
+	database.DB.Create(&rec)
+
+	cases := []struct {
+		name          string
+		id            uint
+		get, put, del gin.HandlerFunc
+		body          map[string]any
+	}{
+		{"tag", tag.ID, GetTag, UpdateTag, DeleteTag, map[string]any{"name": "holiday"}},
+		{"recurring", recurring.ID, GetRecurringByID, UpdateRecurring, DeleteRecurring, map[string]any{"description": "Rent"}},
+		{"reconciliation", rec.ID, GetReconciliation, nil, DeleteReconciliation, nil},
+		{"rule", rule.ID, GetRule, UpdateRule, DeleteRule, map[string]any{"name": "Groceries"}},
+		{"savings goal", goal.ID, GetSavingsGoal, UpdateSavingsGoal, DeleteSavingsGoal, map[string]any{"target_amount": 500}},
+		{"budget", budget.ID, GetCategoryBudget, UpdateCategoryBudget, DeleteCategoryBudget, map[string]any{"limit": 150}},
+		{"envelope", envelope.ID, GetEnvelope, UpdateEnvelope, DeleteEnvelope, map[string]any{"name": "Treats"}},
+		{"account", account.ID, GetAccount, UpdateAccount, RemoveAccount, map[string]any{"name": "Purse"}},
+	}
+	for _, tc := range cases {
+		w := callHandlerParam(uid, txParam(tc.id), tc.get)
+		current := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || current == "" {
+			t.Errorf("%s: GET should send an ETag: %d %q", tc.name, w.Code, current)
+			continue
+		}
+		stale := current
+		if tc.put != nil {
+			w = callHandlerIfMatch(uid, txParam(tc.id), tc.body, current, tc.put)
+			current = w.Header().Get("ETag")
+			if w.Code != http.StatusOK || current == "" || current == stale {
+				t.Errorf("%s: update with the current ETag: %d %q %s", tc.name, w.Code, current, w.Body.String())
+				continue
+			}
+			if w := callHandlerIfMatch(uid, txParam(tc.id), tc.body, stale, tc.put); w.Code != http.StatusPreconditionFailed ||
+				w.Header().Get("ETag") != current {
+				t.Errorf("%s: update with a stale ETag: want 412 with the current tag, got %d", tc.name, w.Code)
+			}
+		} else {
+			stale = `"nope"`
+		}
+		if w := callHandlerIfMatch(uid, txParam(tc.id), nil, stale, tc.del); w.Code != http.StatusPreconditionFailed {
+			t.Errorf("%s: delete with a stale ETag: want 412, got %d", tc.name, w.Code)
+		}
+		if w := callHandlerIfMatch(uid, txParam(tc.id), nil, current, tc.del); w.Code != http.StatusOK {
+			t.Errorf("%s: delete with the current ETag: %d %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
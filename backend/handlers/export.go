@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -158,13 +159,22 @@ func pdfDrawMonthSubHeader(pdf *fpdf.Fpdf, label string) {
 // whether it is an inflow (income-like → green). Deposits to the savings pool
 // are inflows; withdrawals are outflows. This must check the explicit type
 // rather than assume the whole savings-pool category is an expense — otherwise
-// a positive top-up (savings_deposit) is mislabeled as an expense.
+// a positive top-up (savings_deposit) is mislabeled as an expense. Transfer
+// legs are labelled as transfers, coloured by which side of the move they are.
+// A refund is money back on the expense side: printed in red as negative spend
+// in the original's category, the way categoryLinesTable nets it.
 func txDirection(t string, s pdfStrings) (label string, isIncome bool) {
 	switch t {
 	case "income", "savings_deposit":
 		return s.Income, true
 	case "savings_withdrawal":
 		return s.Expense, false
+	case txTypeTransferIn:
+		return s.Transfer, true
+	case txTypeTransferOut:
+		return s.Transfer, false
+	case txTypeRefund:
+		return s.Refund, false
 	default: // "expense" and anything unknown
 		return s.Expense, false
 	}
@@ -255,7 +265,7 @@ func ExportTransactionsPDF(c *gin.Context) {
 	}
 
 	var txs []models.Transaction
-	if err := database.DB.Preload("Category").
+	if err := database.DB.Preload("Category").Preload("Splits.Category").
 		Where("user_id = ?", uid).
 		Order("date desc").
 		Find(&txs).Error; err != nil {
@@ -264,7 +274,8 @@ func ExportTransactionsPDF(c *gin.Context) {
 		return
 	}
 
-	data, err := renderTransactionsPDF(txs, user.Currency, lang)
+	// Split transactions print one row per line so each category shows its share.
+	data, err := renderTransactionsPDF(expandSplitsForExport(txs), user.Currency, lang)
 	if err != nil {
 		log.Printf("export pdf: render err=%v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "PDF generation failed"})
@@ -341,7 +352,14 @@ func renderTransactionsPDF(txs []models.Transaction, currency, lang string) ([]b
 
 		for _, tx := range g.txs {
 			// SplitLines requires the font to already be set to the correct size.
-			descLines := pdf.SplitLines([]byte(tx.Description), pdfWDesc)
+			// Amounts are in the base currency; a foreign-currency row also
+			// shows what was actually paid.
+			desc := tx.Description
+			if tx.Currency != "" && tx.OriginalAmount != nil {
+				paid := "(" + pdfFormatAmount(lang, pdfCurrencySymbol(tx.Currency), *tx.OriginalAmount) + ")"
+				desc = strings.TrimSpace(desc + " " + paid)
+			}
+			descLines := pdf.SplitLines([]byte(desc), pdfWDesc)
 			if len(descLines) == 0 {
 				descLines = [][]byte{{}}
 			}
@@ -358,12 +376,16 @@ func renderTransactionsPDF(txs []models.Transaction, currency, lang string) ([]b
 			// ones are printed verbatim in every language.
 			cat := pdfCategoryLabel(lang, tx.Category.TranslationKey, tx.Category.Name, s.NoCategory)
 			typeLabel, isIncome := txDirection(tx.Type, s)
+			amount := tx.Amount
+			if tx.Type == txTypeRefund {
+				amount = -amount
+			}
 
 			pdfDrawDataRow(
 				pdf,
 				tx.Date.Format(dateLayout),
 				cat,
-				pdfFormatAmount(lang, sym, tx.Amount),
+				pdfFormatAmount(lang, sym, amount),
 				typeLabel,
 				isIncome,
 				altRow,
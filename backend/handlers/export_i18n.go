@@ -32,6 +32,8 @@ type pdfStrings struct {
 	ColDesc     string // transactions.col_description
 	Income      string // transactions.type_income
 	Expense     string // transactions.type_expense
+	Transfer    string // transactions.type_transfer
+	Refund      string // transactions.type_refund
 	NoTx        string // transactions.no_transactions
 	NoCategory  string // transactions.no_category
 }
@@ -48,6 +50,8 @@ var pdfStringsMap = map[string]pdfStrings{
 		ColDesc:     "Description",
 		Income:      "Income",
 		Expense:     "Expense",
+		Transfer:    "Transfer",
+		Refund:      "Refund",
 		NoTx:        "No transactions yet",
 		NoCategory:  "No category",
 	},
@@ -62,6 +66,8 @@ var pdfStringsMap = map[string]pdfStrings{
 		ColDesc:     "Beschreibung",
 		Income:      "Einnahme",
 		Expense:     "Ausgabe",
+		Transfer:    "Umbuchung",
+		Refund:      "Erstattung",
 		NoTx:        "Noch keine Transaktionen",
 		NoCategory:  "Keine Kategorie",
 	},
@@ -76,6 +82,8 @@ var pdfStringsMap = map[string]pdfStrings{
 		ColDesc:     "Описание",
 		Income:      "Доход",
 		Expense:     "Расход",
+		Transfer:    "Перевод",
+		Refund:      "Возврат",
 		NoTx:        "Транзакций пока нет",
 		NoCategory:  "Без категории",
 	},
@@ -90,6 +98,8 @@ var pdfStringsMap = map[string]pdfStrings{
 		ColDesc:     "Опис",
 		Income:      "Дохід",
 		Expense:     "Витрата",
+		Transfer:    "Переказ",
+		Refund:      "Повернення",
 		NoTx:        "Транзакцій поки немає",
 		NoCategory:  "Без категорії",
 	},
@@ -108,6 +118,7 @@ var pdfCategoryNames = map[string]map[string]string{
 		"category.income":         "Income",
 		"category.saved_money":    "Saved Money",
 		"category.fixed_payments": "Fixed Payments",
+		"category.transfers":      "Transfers",
 	},
 	"de": {
 		"category.food":           "Essen",
@@ -117,6 +128,7 @@ var pdfCategoryNames = map[string]map[string]string{
 		"category.income":         "Einkommen",
 		"category.saved_money":    "Ersparnisse",
 		"category.fixed_payments": "Feste Zahlungen",
+		"category.transfers":      "Umbuchungen",
 	},
 	"ru": {
 		"category.food":           "Еда",
@@ -126,6 +138,7 @@ var pdfCategoryNames = map[string]map[string]string{
 		"category.income":         "Доход",
 		"category.saved_money":    "Сбережения",
 		"category.fixed_payments": "Фиксированные платежи",
+		"category.transfers":      "Переводы",
 	},
 	"uk": {
 		"category.food":           "Їжа",
@@ -135,6 +148,7 @@ var pdfCategoryNames = map[string]map[string]string{
 		"category.income":         "Дохід",
 		"category.saved_money":    "Заощадження",
 		"category.fixed_payments": "Фіксовані платежі",
+		"category.transfers":      "Перекази",
 	},
 }
 
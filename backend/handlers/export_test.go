@@ -16,7 +16,8 @@ func TestTxDirection(t *testing.T) {
 		{"savings_deposit", "Income", true}, // +€147.90 top-up → Income
 		{"savings_withdrawal", "Expense", false},
 		{"expense", "Expense", false},
-		{"", "Expense", false}, // unknown/legacy → safe default
+		{"refund", "Refund", false}, // negative spend, not income
+		{"", "Expense", false},      // unknown/legacy → safe default
 	}
 
 	en := pdfT("en")
@@ -0,0 +1,634 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Foreign currencies ───────────────────────────────────────────────────────
+//
+// User.Currency is the base currency every total is reported in. A
+// transaction made in another currency keeps what was paid (Currency,
+// OriginalAmount) and stores its base-currency value in Amount, converted at
+// the rate on its Date. Converting when the transaction is written — and
+// again whenever a rate or the base currency changes — keeps every existing
+// aggregate (summaries, cycle stats, budget window, budgets, PDF) correct
+// without teaching each of them about currencies.
+//
+// A rate is never assumed: a conversion without a known rate is refused and
+// the missing pair and date are reported back, so the user can import ECB
+// rates or enter one by hand.
+
+// fxLookbackDays: the ECB publishes on TARGET business days only, so a
+// transaction on a weekend or holiday uses the latest earlier fixing.
+const fxLookbackDays = 7
+
+var currencyCodeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// missingRate names a conversion that has no known rate.
+type missingRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Date string `json:"date"`
+}
+
+func (m missingRate) message() string {
+	return "No exchange rate for " + m.From + "→" + m.To + " on " + m.Date +
+		" — import ECB rates or add one by hand"
+}
+
+// errFXMissing aborts a DB transaction that would leave a transaction
+// without a rate; the caller reports the collected missingRate list.
+var errFXMissing = errors.New("exchange rate missing")
+
+// errRefundCap aborts a re-conversion that would take a refund past what is
+// left of its original expense.
+var errRefundCap = errors.New("A refund would exceed its original expense at the new rates")
+
+// normalizeCurrency upper-cases an ISO 4217 code; "" stays "" (the base
+// currency).
+func normalizeCurrency(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s != "" && !currencyCodeRe.MatchString(s) {
+		return "", errors.New("Invalid currency code. Use a 3-letter ISO code such as EUR")
+	}
+	return s, nil
+}
+
+// userBaseCurrency is the currency the user's totals are reported in.
+func userBaseCurrency(db *gorm.DB, uid uint) string {
+	var cur string
+	db.Model(&models.User{}).Where("id = ?", uid).Select("currency").Scan(&cur)
+	if cur == "" {
+		return "USD"
+	}
+	return cur
+}
+
+// crossRate finds how many units of to one unit of from is worth among the
+// rates of a single day, directly, inverted or through a shared currency
+// (EUR for ECB rates).
+func crossRate(rates []models.ExchangeRate, from, to string) (float64, bool) {
+	// per is the number of units of c one unit of pivot p buys.
+	per := func(p, c string) (float64, bool) {
+		if p == c {
+			return 1, true
+		}
+		for _, r := range rates {
+			if r.Base == p && r.Quote == c {
+				return r.Rate, true
+			}
+			if r.Base == c && r.Quote == p {
+				return 1 / r.Rate, true
+			}
+		}
+		return 0, false
+	}
+	pivots := []string{from, to}
+	for _, r := range rates {
+		pivots = append(pivots, r.Base, r.Quote)
+	}
+	for _, p := range pivots {
+		f, okFrom := per(p, from)
+		t, okTo := per(p, to)
+		if okFrom && okTo {
+			return t / f, true
+		}
+	}
+	return 0, false
+}
+
+// fxRateOn returns the rate converting from into to for calendar date day:
+// the latest day within fxLookbackDays whose rates connect the pair. Both
+// legs of a cross rate always come from the same day.
+func fxRateOn(db *gorm.DB, uid uint, from, to string, day time.Time) (float64, bool, error) {
+	if from == to {
+		return 1, true, nil
+	}
+	day = toDateOnly(day)
+	var rows []models.ExchangeRate
+	if err := db.Where("user_id = ? AND date >= ? AND date <= ?", uid, day.AddDate(0, 0, -fxLookbackDays), day).
+		Order("date DESC").Find(&rows).Error; err != nil {
+		return 0, false, err
+	}
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && rows[j].Date.Equal(rows[i].Date) {
+			j++
+		}
+		if r, ok := crossRate(rows[i:j], from, to); ok {
+			return r, true, nil
+		}
+		i = j
+	}
+	return 0, false, nil
+}
+
+// applyFX sets t's amount from an amount in currency cur. A base-currency
+// amount clears the foreign fields; a foreign one is converted at the rate on
+// t.Date, or a missingRate is returned and t is left untouched.
+func applyFX(db *gorm.DB, t *models.Transaction, base, cur string, amount float64) (*missingRate, error) {
+	if cur == "" || cur == base {
+		t.Currency, t.OriginalAmount, t.FXRate, t.Amount = "", nil, nil, round2(amount)
+		return nil, nil
+	}
+	rate, ok, err := fxRateOn(db, t.UserID, cur, base, t.Date)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &missingRate{From: cur, To: base, Date: toDateOnly(t.Date).Format("2006-01-02")}, nil
+	}
+	original := round2(amount)
+	t.Currency, t.OriginalAmount, t.FXRate = cur, &original, &rate
+	t.Amount = round2(original * rate)
+	return nil, nil
+}
+
+// txOriginalAmount is what was paid, in the transaction's own currency.
+func txOriginalAmount(t models.Transaction) float64 {
+	if t.OriginalAmount != nil {
+		return *t.OriginalAmount
+	}
+	return t.Amount
+}
+
+// rescaleSplits scales split lines proportionally so they sum to total,
+// giving the rounding remainder to the largest line.
+func rescaleSplits(splits []models.TransactionSplit, total float64) {
+	var sum float64
+	largest := 0
+	for i, s := range splits {
+		sum += s.Amount
+		if s.Amount > splits[largest].Amount {
+			largest = i
+		}
+	}
+	if len(splits) == 0 || sum == 0 {
+		return
+	}
+	var scaledCents int64
+	for i := range splits {
+		splits[i].Amount = round2(splits[i].Amount * total / sum)
+		scaledCents += int64(math.Round(splits[i].Amount * 100))
+	}
+	diff := int64(math.Round(total*100)) - scaledCents
+	splits[largest].Amount = round2(splits[largest].Amount + float64(diff)/100)
+}
+
+// reconvertForeignTransactions re-applies the current rates and base
+// currency to every foreign transaction of the user, including those in the
+// trash, inside db (a DB transaction). A transaction in the base currency
+// becomes a plain one. Reconciled rows keep the amounts they were reconciled
+// at and are returned by id. Conversions without a rate are collected and
+// left unchanged; the caller decides whether that aborts. A refund that would
+// outgrow its original aborts with errRefundCap.
+func reconvertForeignTransactions(db *gorm.DB, uid uint, base string) ([]missingRate, []uint, error) {
+	var txs []models.Transaction
+	if err := db.Unscoped().Preload("Splits").Where("user_id = ? AND currency <> ''", uid).Find(&txs).Error; err != nil {
+		return nil, nil, err
+	}
+	var missing []missingRate
+	reconciled := []uint{}
+	seen := map[missingRate]bool{}
+	for i := range txs {
+		t := &txs[i]
+		if t.Status == txStatusReconciled {
+			reconciled = append(reconciled, t.ID)
+			continue
+		}
+		before := t.Amount
+		m, err := applyFX(db, t, base, t.Currency, txOriginalAmount(*t))
+		if err != nil {
+			return nil, nil, err
+		}
+		if m != nil {
+			if !seen[*m] {
+				seen[*m] = true
+				missing = append(missing, *m)
+			}
+			continue
+		}
+		if err := db.Unscoped().Model(t).Updates(map[string]interface{}{
+			"currency": t.Currency, "original_amount": t.OriginalAmount, "fx_rate": t.FXRate, "amount": t.Amount,
+		}).Error; err != nil {
+			return nil, nil, err
+		}
+		if t.Amount == before {
+			continue
+		}
+		if len(t.Splits) > 0 {
+			rescaleSplits(t.Splits, t.Amount)
+			if err := saveSplitAmounts(db, t.Splits); err != nil {
+				return nil, nil, err
+			}
+		}
+		if t.Type == txTypeRefund && t.RefundOfID != nil && !t.DeletedAt.Valid {
+			var original models.Transaction
+			if err := db.Where("id = ? AND user_id = ?", *t.RefundOfID, uid).First(&original).Error; err != nil {
+				return nil, nil, err
+			}
+			if status, err := checkRefundAmount(db, original, txOriginalAmount(*t), t.ID); status == http.StatusInternalServerError {
+				return nil, nil, err
+			} else if err != nil {
+				return nil, nil, fmt.Errorf("%w: refund %d", errRefundCap, t.ID)
+			}
+		}
+	}
+	return missing, reconciled, nil
+}
+
+// saveSplitAmounts writes back the amounts of rescaled split lines.
+func saveSplitAmounts(db *gorm.DB, splits []models.TransactionSplit) error {
+	for _, s := range splits {
+		if err := db.Model(&models.TransactionSplit{}).Where("id = ?", s.ID).Update("amount", s.Amount).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── ECB reference rate files ─────────────────────────────────────────────────
+
+// ecbCSVDateLayouts: the daily file says "17 October 2026", the history file
+// "2026-10-17".
+var ecbCSVDateLayouts = []string{"2006-01-02", "2 January 2006", "02 January 2006"}
+
+// parseECBCSV reads eurofxref.csv / eurofxref-hist.csv: a "Date" column
+// followed by one column per currency quoted against EUR. "N/A" and blank
+// cells (currencies not fixed that day, the trailing comma) are skipped.
+func parseECBCSV(data string) ([]models.ExchangeRate, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.New("Could not read the CSV: " + err.Error())
+	}
+	if len(records) < 2 || !strings.EqualFold(strings.TrimSpace(records[0][0]), "Date") {
+		return nil, errors.New("Not an ECB reference rate CSV: expected a Date column and one column per currency")
+	}
+	header := records[0]
+	var out []models.ExchangeRate
+	for line, rec := range records[1:] {
+		day, err := parseECBDate(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("Row %d: unrecognised date %q", line+2, rec[0])
+		}
+		for col := 1; col < len(rec) && col < len(header); col++ {
+			code := strings.ToUpper(strings.TrimSpace(header[col]))
+			cell := strings.TrimSpace(rec[col])
+			if code == "" || cell == "" || strings.EqualFold(cell, "N/A") {
+				continue
+			}
+			rate, err := strconv.ParseFloat(cell, 64)
+			if err != nil || rate <= 0 || !currencyCodeRe.MatchString(code) {
+				return nil, fmt.Errorf("Row %d: invalid rate %q for %s", line+2, cell, code)
+			}
+			out = append(out, models.ExchangeRate{Date: day, Base: "EUR", Quote: code, Rate: rate})
+		}
+	}
+	return out, nil
+}
+
+func parseECBDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range ecbCSVDateLayouts {
+		if d, err := time.Parse(layout, s); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognised date")
+}
+
+// ecbEnvelope mirrors eurofxref-daily.xml / eurofxref-hist.xml: a Cube per
+// day, each holding a Cube per currency.
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func parseECBXML(data string) ([]models.ExchangeRate, error) {
+	var env ecbEnvelope
+	if err := xml.Unmarshal([]byte(data), &env); err != nil {
+		return nil, errors.New("Could not read the XML: " + err.Error())
+	}
+	if len(env.Cube.Days) == 0 {
+		return nil, errors.New("Not an ECB reference rate XML: no daily Cube elements")
+	}
+	var out []models.ExchangeRate
+	for _, d := range env.Cube.Days {
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(d.Time))
+		if err != nil {
+			return nil, fmt.Errorf("Unrecognised date %q", d.Time)
+		}
+		for _, r := range d.Rates {
+			code := strings.ToUpper(strings.TrimSpace(r.Currency))
+			rate, err := strconv.ParseFloat(strings.TrimSpace(r.Rate), 64)
+			if err != nil || rate <= 0 || !currencyCodeRe.MatchString(code) {
+				return nil, fmt.Errorf("%s: invalid rate %q for %s", d.Time, r.Rate, r.Currency)
+			}
+			out = append(out, models.ExchangeRate{Date: day, Base: "EUR", Quote: code, Rate: rate})
+		}
+	}
+	return out, nil
+}
+
+// upsertExchangeRates stores rates for uid, replacing any rate already known
+// for the same day and pair, then re-converts the user's foreign
+// transactions so they pick the new figures up. Returns the reconciled rows
+// that kept their amounts.
+func upsertExchangeRates(uid uint, rates []models.ExchangeRate, source string) ([]uint, error) {
+	now := time.Now()
+	for i := range rates {
+		rates[i].UserID, rates[i].Source = uid, source
+		rates[i].CreatedAt, rates[i].UpdatedAt = now, now
+	}
+	var reconciled []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}, {Name: "base"}, {Name: "quote"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+		}).CreateInBatches(&rates, 500).Error; err != nil {
+			return err
+		}
+		// Adding rates can only make more conversions possible, so nothing
+		// that converted before can go missing here.
+		missing, r, err := reconvertForeignTransactions(tx, uid, userBaseCurrency(tx, uid))
+		if len(missing) > 0 {
+			log.Printf("fx upsert: user=%v still missing=%v", uid, missing)
+		}
+		reconciled = r
+		return err
+	})
+	if err == nil {
+		InvalidateCycleCache(uid)
+		ScheduleBrainResync(uid)
+	}
+	return reconciled, err
+}
+
+// ── Handlers ─────────────────────────────────────────────────────────────────
+
+// GetExchangeRates → GET /api/fx/rates
+// Filters: base, quote (ISO codes), begin_date, end_date (YYYY-MM-DD).
+// Newest first.
+func GetExchangeRates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	q := database.DB.Where("user_id = ?", uid)
+	for _, f := range []string{"base", "quote"} {
+		if v := c.Query(f); v != "" {
+			code, err := normalizeCurrency(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			q = q.Where(f+" = ?", code)
+		}
+	}
+	if v := c.Query("begin_date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid begin_date format. Use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("date >= ?", d)
+	}
+	if v := c.Query("end_date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("date <= ?", d)
+	}
+
+	var rates []models.ExchangeRate
+	if err := q.Order("date DESC").Order("base").Order("quote").Find(&rates).Error; err != nil {
+		log.Printf("get exchange rates: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rates": rates, "base_currency": userBaseCurrency(database.DB, uid)})
+}
+
+// SetExchangeRate → POST /api/fx/rates
+// Enters (or corrects) one rate by hand: 1 base = rate quote on date.
+func SetExchangeRate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var req struct {
+		Date  string  `json:"date" binding:"required"`
+		Base  string  `json:"base" binding:"required"`
+		Quote string  `json:"quote" binding:"required"`
+		Rate  float64 `json:"rate" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	day, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	base, errBase := normalizeCurrency(req.Base)
+	quote, errQuote := normalizeCurrency(req.Quote)
+	if errBase != nil || errQuote != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency code. Use a 3-letter ISO code such as EUR"})
+		return
+	}
+	if base == quote {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "base and quote must differ"})
+		return
+	}
+
+	rate := models.ExchangeRate{Date: day, Base: base, Quote: quote, Rate: req.Rate}
+	reconciled, err := upsertExchangeRates(uid, []models.ExchangeRate{rate}, "manual")
+	if errors.Is(err, errRefundCap) {
+		c.JSON(http.StatusConflict, gin.H{"error": errRefundCap.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("set exchange rate: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rate"})
+		return
+	}
+	database.DB.Where("user_id = ? AND date = ? AND base = ? AND quote = ?", uid, day, base, quote).First(&rate)
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate saved", "rate": rate, "reconciled_unchanged": reconciled})
+}
+
+// ImportExchangeRates → POST /api/fx/rates/import
+// Body: {"data": "<file contents>", "format": "csv" | "xml"}. Accepts the
+// ECB euro reference rate files (daily or full history) in either format;
+// format defaults to sniffing the content.
+func ImportExchangeRates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var req struct {
+		Data   string `json:"data" binding:"required"`
+		Format string `json:"format"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = "csv"
+		if strings.HasPrefix(strings.TrimSpace(req.Data), "<") {
+			format = "xml"
+		}
+	}
+
+	var rates []models.ExchangeRate
+	var err error
+	switch format {
+	case "csv":
+		rates, err = parseECBCSV(req.Data)
+	case "xml":
+		rates, err = parseECBXML(req.Data)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Allowed values: csv, xml"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The file contains no rates"})
+		return
+	}
+
+	reconciled, err := upsertExchangeRates(uid, rates, "ecb")
+	if errors.Is(err, errRefundCap) {
+		c.JSON(http.StatusConflict, gin.H{"error": errRefundCap.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("import exchange rates: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import exchange rates"})
+		return
+	}
+	days := map[time.Time]bool{}
+	for _, r := range rates {
+		days[r.Date] = true
+	}
+	keys := make([]time.Time, 0, len(days))
+	for d := range days {
+		keys = append(keys, d)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Before(keys[j]) })
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Exchange rates imported",
+		"imported":   len(rates),
+		"days":       len(keys),
+		"first_date": keys[0].Format("2006-01-02"),
+		"last_date":  keys[len(keys)-1].Format("2006-01-02"),
+		// Reconciled rows keep the amounts they were reconciled at.
+		"reconciled_unchanged": reconciled,
+	})
+}
+
+// DeleteExchangeRate → DELETE /api/fx/rates/:id
+// Refused (409) while a transaction has no other rate to fall back on.
+func DeleteExchangeRate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange rate ID format"})
+		return
+	}
+
+	var missing []missingRate
+	found := true
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, uid).Delete(&models.ExchangeRate{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			found = false
+			return nil
+		}
+		m, _, err := reconvertForeignTransactions(tx, uid, userBaseCurrency(tx, uid))
+		if err != nil {
+			return err
+		}
+		if len(m) > 0 {
+			missing = m
+			return errFXMissing
+		}
+		return nil
+	})
+	if errors.Is(err, errFXMissing) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Transactions still depend on this rate — add a replacement first",
+			"missing_rates": missing,
+		})
+		return
+	}
+	if errors.Is(err, errRefundCap) {
+		c.JSON(http.StatusConflict, gin.H{"error": errRefundCap.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("delete exchange rate: user=%v id=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exchange rate"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// The daily ECB CSV: human dates, a space after each comma, a trailing comma.
+const ecbDailyCSV = "Date, USD, JPY, GBP, \n15 May 2026, 1.1000, 170.00, 0.8500, \n"
+
+func TestParseECBFiles(t *testing.T) {
+	rates, err := parseECBCSV("Date,USD,GBP,RUB,\n2026-05-15,1.1,0.85,N/A,\n2026-05-14,1.2,0.8,N/A,\n")
+	if err != nil || len(rates) != 4 {
+		t.Fatalf("history csv: %v %+v", err, rates)
+	}
+	if r := rates[0]; r.Base != "EUR" || r.Quote != "USD" || r.Rate != 1.1 || !r.Date.Equal(utcDay(2026, 5, 15)) {
+		t.Errorf("first rate: %+v", r)
+	}
+
+	xmlDoc := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-05-15">
+			<Cube currency="USD" rate="1.1000"/>
+			<Cube currency="GBP" rate="0.8500"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+	rates, err = parseECBXML(xmlDoc)
+	if err != nil || len(rates) != 2 {
+		t.Fatalf("xml: %v %+v", err, rates)
+	}
+	// GBP → USD goes through EUR.
+	if r, ok := crossRate(rates, "GBP", "USD"); !ok || math.Abs(r-1.1/0.85) > 1e-12 {
+		t.Errorf("cross rate: %v %v", r, ok)
+	}
+}
+
+// A foreign expense is refused until a rate exists, then converted at the
+// rate of its date (the Friday fixing for a Saturday), split lines included,
+// and counted in base currency by the budget window.
+func TestForeignCurrencyTransaction(t *testing.T) {
+	setupFlowDB(t)
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	body := map[string]any{
+		"amount": 100, "currency": "gbp", "date": "2026-05-16", "type": "expense",
+		"splits": []map[string]any{{"category_id": f.food.ID, "amount": 60}, {"category_id": f.beauty.ID, "amount": 40}},
+	}
+
+	w := callHandler(uid, body, CreateTransaction)
+	var refused struct {
+		Missing []missingRate `json:"missing_rates"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &refused)
+	if w.Code != http.StatusUnprocessableEntity || len(refused.Missing) != 1 ||
+		refused.Missing[0] != (missingRate{From: "GBP", To: "USD", Date: "2026-05-16"}) {
+		t.Fatalf("without rates: want 422 naming GBP→USD, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := callHandler(uid, map[string]any{"data": ecbDailyCSV}, ImportExchangeRates); w.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+	w = callHandler(uid, body, CreateTransaction)
+	var created struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	tx := created.Transaction
+	if tx.Currency != "GBP" || tx.OriginalAmount == nil || *tx.OriginalAmount != 100 || tx.Amount != 129.41 {
+		t.Errorf("conversion: %+v", tx)
+	}
+	if len(tx.Splits) != 2 || round2(tx.Splits[0].Amount+tx.Splits[1].Amount) != tx.Amount {
+		t.Errorf("split lines must add up to the converted amount: %+v", tx.Splits)
+	}
+	if bw := computeBudgetWindow(uid, 1000, time.Now()); bw.SpentThisWindow != 129.41 {
+		t.Errorf("budget window should count the USD value, got %.2f", bw.SpentThisWindow)
+	}
+
+	// Switching the base currency re-converts; a base without rates is refused.
+	if w := callHandler(uid, map[string]any{"currency": "EUR"}, UpdateProfile); w.Code != http.StatusOK {
+		t.Fatalf("rebase to EUR: %d %s", w.Code, w.Body.String())
+	}
+	var stored models.Transaction
+	database.DB.First(&stored, tx.ID)
+	if stored.Amount != 117.65 {
+		t.Errorf("want 100 GBP = 117.65 EUR, got %.2f", stored.Amount)
+	}
+	if w := callHandler(uid, map[string]any{"currency": "UAH"}, UpdateProfile); w.Code != http.StatusConflict {
+		t.Errorf("rebase without UAH rates: want 409, got %d", w.Code)
+	}
+	if base := userBaseCurrency(database.DB, uid); base != "EUR" {
+		t.Errorf("refused rebase must keep EUR, got %s", base)
+	}
+
+	// The GBP rate is in use; the JPY one is not.
+	var gbp, jpy models.ExchangeRate
+	database.DB.Where("user_id = ? AND quote = ?", uid, "GBP").First(&gbp)
+	database.DB.Where("user_id = ? AND quote = ?", uid, "JPY").First(&jpy)
+	if w := callHandlerParam(uid, catParam(gbp.ID), DeleteExchangeRate); w.Code != http.StatusConflict {
+		t.Errorf("deleting a rate in use: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, catParam(jpy.ID), DeleteExchangeRate); w.Code != http.StatusOK {
+		t.Errorf("deleting an unused rate: want 200, got %d", w.Code)
+	}
+}
+
+// A new rate re-converts open foreign rows but never the amount a locked
+// reconciliation signed off on.
+func TestExchangeRate_ReconciledRowsKeepTheirAmount(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	if w := callHandler(uid, map[string]any{"data": ecbDailyCSV}, ImportExchangeRates); w.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+	create := func(date string) models.Transaction {
+		w := callHandler(uid, map[string]any{"category_id": f.food.ID, "amount": 100, "currency": "GBP", "date": date, "type": "expense"}, CreateTransaction)
+		var created struct {
+			Transaction models.Transaction `json:"transaction"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body.String())
+		}
+		return created.Transaction
+	}
+	locked, open := create("2026-05-15"), create("2026-05-18")
+	if locked.Amount != 129.41 || open.Amount != 129.41 {
+		t.Fatalf("conversion: %.2f / %.2f", locked.Amount, open.Amount)
+	}
+
+	def, _ := defaultAccount(database.DB, uid)
+	w := callHandler(uid, map[string]any{"account_id": def.ID, "statement_date": "2026-05-15", "statement_balance": -129.41}, CreateReconciliation)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("reconciliation: %d %s", w.Code, w.Body.String())
+	}
+	rec := decodeReconciliation(t, w.Body.Bytes()).Reconciliation
+	if w := callHandlerParamBody(uid, catParam(rec.ID), map[string]any{"transaction_ids": []uint{locked.ID}}, ClearReconciliationRows); w.Code != http.StatusOK {
+		t.Fatalf("clear: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("lock: %d %s", w.Code, w.Body.String())
+	}
+
+	w = callHandler(uid, map[string]any{"data": "Date,USD,GBP,\n2026-05-15,1.1,0.8,\n"}, ImportExchangeRates)
+	var resp struct {
+		Reconciled []uint `json:"reconciled_unchanged"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("re-import: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.Reconciled) != 1 || resp.Reconciled[0] != locked.ID {
+		t.Errorf("the locked row should be reported, got %v", resp.Reconciled)
+	}
+	var got models.Transaction
+	database.DB.First(&got, locked.ID)
+	if got.Amount != 129.41 || got.FXRate == nil || math.Abs(*got.FXRate-1.1/0.85) > 1e-9 {
+		t.Errorf("reconciled row changed: amount %.2f rate %v", got.Amount, got.FXRate)
+	}
+	var reconverted models.Transaction
+	database.DB.First(&reconverted, open.ID)
+	if reconverted.Amount != 137.5 {
+		t.Errorf("open row should pick up the new rate: want 137.50, got %.2f", reconverted.Amount)
+	}
+}
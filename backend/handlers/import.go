@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Shared statement-import pipeline ─────────────────────────────────────────
+//
+// Every importer (CSV, OFX, …) parses its source format into []importRow and
+// then goes through the same two steps:
+//
+//  1. resolveImportCategories — map each row's category name to one of the
+//     user's categories (or mark it to be auto-created). Read-only, so a
+//     dry-run preview never writes anything.
+//  2. checkImportRates — flag rows in a foreign currency that have no rate
+//     into the base currency on their date. Also read-only.
+//  3. commitImportRows — inside ONE DB transaction, create the missing
+//     categories and insert every row, converted like CreateTransaction does. Either the whole batch lands or none
+//     of it does; the caller then invalidates the cycle cache and schedules a
+//     brain resync exactly once.
+
+// maxImportRows bounds a single import so one request can't tie up the DB.
+const maxImportRows = 5000
+
+// importRow is one parsed statement line plus its validation outcome.
+type importRow struct {
+	Line         int       `json:"line"` // 1-based position in the source file
+	Date         time.Time `json:"date"`
+	Amount       float64   `json:"amount"`
+	Currency     string    `json:"currency,omitempty"` // Amount's currency; empty = the base currency
+	Type         string    `json:"type"`               // expense | income
+	Description  string    `json:"description"`
+	CategoryName string    `json:"category_name"`
+	CategoryID   uint      `json:"category_id"`  // 0 while NewCategory is pending
+	NewCategory  bool      `json:"new_category"` // will be auto-created on commit
+	FITID        string    `json:"fitid,omitempty"`
+	AccountID    *uint     `json:"-"`                 // nil = the default account
+	RuleID       uint      `json:"rule_id,omitempty"` // the rule that categorized the row
+	Tags         []string  `json:"tags,omitempty"`    // added by that rule
+	Duplicate    bool      `json:"duplicate"`         // already imported — skipped, not an error
+	Errors       []string  `json:"errors,omitempty"`
+
+	ruleCategorized bool // CategoryID came from RuleID
+}
+
+func (r *importRow) addError(msg string) { r.Errors = append(r.Errors, msg) }
+
+func (r *importRow) valid() bool { return len(r.Errors) == 0 }
+
+// importable reports whether the row will be inserted on commit.
+func (r *importRow) importable() bool { return r.valid() && !r.Duplicate }
+
+// importSummary is the preview payload shared by every importer.
+type importSummary struct {
+	Rows           []importRow `json:"rows"`
+	ValidCount     int         `json:"valid_count"`
+	ErrorCount     int         `json:"error_count"`
+	DuplicateCount int         `json:"duplicate_count"`
+	NewCategories  []string    `json:"new_categories"`
+	// MissingRates lists the conversions the invalid rows are waiting for.
+	MissingRates []missingRate `json:"missing_rates,omitempty"`
+}
+
+func summarizeImport(rows []importRow) importSummary {
+	s := importSummary{Rows: rows, NewCategories: []string{}}
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if !r.valid() {
+			s.ErrorCount++
+			continue
+		}
+		if r.Duplicate {
+			s.DuplicateCount++
+			continue
+		}
+		s.ValidCount++
+		if r.NewCategory && !seen[strings.ToLower(r.CategoryName)] {
+			seen[strings.ToLower(r.CategoryName)] = true
+			s.NewCategories = append(s.NewCategories, r.CategoryName)
+		}
+	}
+	return s
+}
+
+// truncateDescription clips bank descriptions to the 255-byte limit the
+// transaction handlers enforce, without splitting a multi-byte rune.
+func truncateDescription(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= 255 {
+		return s
+	}
+	cut := 255
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// importDateToCreatedAt anchors an imported row at noon on its own date in
+// the user's zone. Every cycle and budget window is scoped by created_at, so
+// stamping the import time instead would pile months of history into the
+// current cycle.
+func importDateToCreatedAt(d time.Time, loc *time.Location) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 12, 0, 0, 0, loc)
+}
+
+// resolveImportCategories assigns a category to every valid row. A row's
+// category name is matched, in order, against:
+//
+//  1. explicit overrides in categoryMap (source name → category id),
+//  2. the user's category names (case-insensitive),
+//  3. the translation key of a known default name — so "Essen" in a German
+//     export lands in the user's "Food" category,
+//
+// and is otherwise flagged NewCategory for auto-creation on commit. Rows with
+// no category name go through the user's rules (which may also add tags and
+// rewrite the description) and fall back to defaultCategoryID. Nothing is
+// written.
+func resolveImportCategories(uid uint, rows []importRow, defaultCategoryID uint, categoryMap map[string]uint) error {
+	var cats []models.Category
+	if err := database.DB.Where("user_id = ?", uid).Find(&cats).Error; err != nil {
+		return err
+	}
+	rules, err := loadRules(database.DB, uid)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uint]bool, len(cats))
+	byName := make(map[string]uint, len(cats))
+	byKey := make(map[string]uint, len(cats))
+	for _, c := range cats {
+		owned[c.ID] = true
+		if _, ok := byName[strings.ToLower(c.Name)]; !ok {
+			byName[strings.ToLower(c.Name)] = c.ID
+		}
+		if c.TranslationKey != "" {
+			if _, ok := byKey[c.TranslationKey]; !ok {
+				byKey[c.TranslationKey] = c.ID
+			}
+		}
+	}
+	overrides := make(map[string]uint, len(categoryMap))
+	for name, id := range categoryMap {
+		overrides[strings.ToLower(strings.TrimSpace(name))] = id
+	}
+
+	for i := range rows {
+		r := &rows[i]
+		if !r.valid() {
+			continue
+		}
+		name := strings.TrimSpace(r.CategoryName)
+		lower := strings.ToLower(name)
+		if name == "" {
+			if out, ok := rules.first(r.Description, r.Amount, r.Type); ok {
+				r.RuleID, r.Description, r.Tags = out.RuleID, out.Description, out.Tags
+				if out.CategoryID != 0 && owned[out.CategoryID] {
+					r.CategoryID, r.ruleCategorized = out.CategoryID, true
+					continue
+				}
+			}
+		}
+		switch {
+		case name == "":
+			if defaultCategoryID == 0 {
+				r.addError("category is empty and no default_category_id was given")
+			} else if !owned[defaultCategoryID] {
+				r.addError("default_category_id does not belong to you")
+			} else {
+				r.CategoryID = defaultCategoryID
+			}
+		case overrides[lower] != 0:
+			if !owned[overrides[lower]] {
+				r.addError("category_map points at a category that does not belong to you")
+			} else {
+				r.CategoryID = overrides[lower]
+			}
+		case byName[lower] != 0:
+			r.CategoryID = byName[lower]
+		case defaultCategoryKey(name) != "" && byKey[defaultCategoryKey(name)] != 0:
+			r.CategoryID = byKey[defaultCategoryKey(name)]
+		default:
+			if len(name) > 100 {
+				r.addError("category name must be 100 characters or fewer")
+			} else {
+				r.NewCategory = true
+			}
+		}
+	}
+	return nil
+}
+
+// checkImportRates flags every importable foreign-currency row that has no
+// rate into the base currency on its date, and returns each missing
+// conversion once. Nothing is written; commitImportRows converts.
+func checkImportRates(uid uint, rows []importRow) ([]missingRate, error) {
+	base := userBaseCurrency(database.DB, uid)
+	var missing []missingRate
+	seen := map[missingRate]bool{}
+	for i := range rows {
+		r := &rows[i]
+		if !r.importable() || r.Currency == "" || r.Currency == base {
+			continue
+		}
+		_, ok, err := fxRateOn(database.DB, uid, r.Currency, base, r.Date)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+		m := missingRate{From: r.Currency, To: base, Date: toDateOnly(r.Date).Format("2006-01-02")}
+		r.addError(m.message())
+		if !seen[m] {
+			seen[m] = true
+			missing = append(missing, m)
+		}
+	}
+	return missing, nil
+}
+
+// commitImportRows inserts every importable row (and any categories they need)
+// inside tx. Invalid and duplicate rows are skipped; callers decide beforehand
+// whether that is acceptable. Returns the created transactions and categories.
+func commitImportRows(tx *gorm.DB, uid uint, rows []importRow, now time.Time) ([]models.Transaction, []models.Category, error) {
+	created := []models.Category{}
+	newIDs := map[string]uint{}
+	txs := make([]models.Transaction, 0, len(rows))
+	var tags [][]string
+	def, err := defaultAccount(tx, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := userBaseCurrency(tx, uid)
+
+	for i := range rows {
+		r := &rows[i]
+		if !r.importable() {
+			continue
+		}
+		if r.NewCategory {
+			key := strings.ToLower(strings.TrimSpace(r.CategoryName))
+			id, ok := newIDs[key]
+			if !ok {
+				cat := models.Category{
+					UserID: uid, Name: strings.TrimSpace(r.CategoryName),
+					TranslationKey: defaultCategoryKey(r.CategoryName),
+					Bucket:         defaultCategoryBucket(defaultCategoryKey(r.CategoryName)),
+					CreatedAt:      now, UpdatedAt: now,
+				}
+				if err := tx.Create(&cat).Error; err != nil {
+					return nil, nil, err
+				}
+				created = append(created, cat)
+				id = cat.ID
+				newIDs[key] = id
+			}
+			r.CategoryID = id
+		}
+
+		t := models.Transaction{
+			UserID:      uid,
+			CategoryID:  r.CategoryID,
+			Amount:      r.Amount,
+			Description: r.Description,
+			Date:        r.Date,
+			Type:        r.Type,
+			IncomeType:  "one_time",
+			FITID:       r.FITID,
+			AccountID:   &def.ID,
+			CreatedAt:   importDateToCreatedAt(r.Date, now.Location()),
+			UpdatedAt:   now,
+		}
+		if r.AccountID != nil {
+			t.AccountID = r.AccountID
+		}
+		// checkImportRates vouched for the rate; one deleted since aborts.
+		if m, err := applyFX(tx, &t, base, r.Currency, r.Amount); err != nil {
+			return nil, nil, err
+		} else if m != nil {
+			return nil, nil, errFXMissing
+		}
+		txs = append(txs, t)
+		tags = append(tags, r.Tags)
+	}
+
+	if len(txs) > 0 {
+		if err := tx.Omit("Tags").CreateInBatches(&txs, 200).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range txs {
+		if len(tags[i]) == 0 {
+			continue
+		}
+		if err := setTransactionTags(tx, &txs[i], tags[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return txs, created, nil
+}
+
+// respondImport finishes every importer's request: a preview when !commit,
+// otherwise an all-or-nothing insert followed by ONE cache invalidation and
+// ONE brain resync for the whole batch.
+func respondImport(c *gin.Context, uid uint, rows []importRow, commit, skipInvalid bool, logTag string) {
+	missing, err := checkImportRates(uid, rows)
+	if err != nil {
+		log.Printf("%s: rates user=%v err=%v", logTag, uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up exchange rates"})
+		return
+	}
+	summary := summarizeImport(rows)
+	summary.MissingRates = missing
+	if !commit {
+		c.JSON(http.StatusOK, gin.H{"preview": summary, "committed": false})
+		return
+	}
+	if summary.ErrorCount > 0 && !skipInvalid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     "Some rows are invalid — fix the mapping or set skip_invalid",
+			"preview":   summary,
+			"committed": false,
+		})
+		return
+	}
+	if summary.ValidCount == 0 && summary.DuplicateCount > 0 {
+		// Re-importing a statement that was fully imported before is a no-op.
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Nothing new to import", "committed": true,
+			"imported": 0, "skipped": summary.ErrorCount, "duplicates": summary.DuplicateCount,
+		})
+		return
+	}
+	if summary.ValidCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid rows to import", "preview": summary, "committed": false})
+		return
+	}
+
+	var imported []models.Transaction
+	var createdCats []models.Category
+	now := userNow(uid)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		imported, createdCats, err = commitImportRows(tx, uid, rows, now)
+		return err
+	})
+	if err != nil {
+		log.Printf("%s: commit user=%v err=%v", logTag, uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import transactions"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":            "Transactions imported successfully",
+		"committed":          true,
+		"imported":           len(imported),
+		"skipped":            summary.ErrorCount,
+		"duplicates":         summary.DuplicateCount,
+		"created_categories": createdCats,
+	})
+}
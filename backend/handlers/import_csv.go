@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvImportMapping tells the importer how to read one bank's CSV export.
+// Column specs are header names (matched case-insensitively) or, for files
+// without a header row, 0-based column indexes.
+type csvImportMapping struct {
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Debit       string `json:"debit"`  // debit_credit convention: money out
+	Credit      string `json:"credit"` // debit_credit convention: money in
+	Description string `json:"description"`
+	Category    string `json:"category"`
+
+	// SignConvention: negative_expense (default — "-12.50" is money out),
+	// positive_expense (credit-card style — positive rows are purchases) or
+	// debit_credit (separate debit / credit columns).
+	SignConvention   string `json:"sign_convention"`
+	DateFormat       string `json:"date_format"`       // YYYY-MM-DD (default), DD.MM.YYYY, MM/DD/YYYY, … or a Go layout
+	DecimalSeparator string `json:"decimal_separator"` // "." (default) or ","
+	Delimiter        string `json:"delimiter"`         // "," (default), ";", "\t" or "|"
+	HasHeader        *bool  `json:"has_header"`        // default true
+}
+
+const (
+	signNegativeExpense = "negative_expense"
+	signPositiveExpense = "positive_expense"
+	signDebitCredit     = "debit_credit"
+)
+
+// importDateLayouts maps the human-friendly date_format tokens the UI offers to
+// Go layouts. Anything else containing a Go year token ("2006"/"06") is taken
+// as a Go layout verbatim.
+var importDateLayouts = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"YYYY/MM/DD": "2006/01/02",
+	"DD.MM.YYYY": "02.01.2006",
+	"DD/MM/YYYY": "02/01/2006",
+	"DD-MM-YYYY": "02-01-2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"DD.MM.YY":   "02.01.06",
+	"MM/DD/YY":   "01/02/06",
+}
+
+func resolveDateLayout(format string) (string, error) {
+	if format == "" {
+		return "2006-01-02", nil
+	}
+	if layout, ok := importDateLayouts[strings.ToUpper(strings.TrimSpace(format))]; ok {
+		return layout, nil
+	}
+	if strings.Contains(format, "06") { // Go layouts always carry the year as 2006 or 06
+		return format, nil
+	}
+	return "", errors.New("Unsupported date_format")
+}
+
+// parseImportAmount turns a bank-formatted number into a float. It accepts
+// currency symbols and spaces ("€ 1.234,56"), accounting negatives
+// ("(12.00)") and trailing minus signs ("12,50-"), and rounds to the cent.
+func parseImportAmount(raw, decimalSep string) (float64, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, errors.New("empty")
+	}
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = !negative
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+', r == '.', r == ',':
+			b.WriteRune(r)
+		}
+	}
+	s = b.String()
+	if decimalSep == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("not a number")
+	}
+	if negative {
+		v = -v
+	}
+	return round2(v), nil
+}
+
+// csvColumns resolves the mapping's column specs against the header row.
+type csvColumns struct {
+	date, amount, debit, credit, description, category int
+}
+
+func resolveCSVColumn(spec string, header []string) (int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return -1, nil
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), spec) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(spec); err == nil && n >= 0 {
+		return n, nil
+	}
+	return -1, errors.New("column " + strconv.Quote(spec) + " not found")
+}
+
+func csvCell(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
+
+// parseCSVImport reads the CSV into import rows. Per-row problems are recorded
+// on the row; only a structural problem (bad mapping, unreadable CSV) is
+// returned as an error, and its message is safe to send to the client.
+func parseCSVImport(data string, m csvImportMapping) ([]importRow, error) {
+	layout, err := resolveDateLayout(m.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+	decimalSep := m.DecimalSeparator
+	if decimalSep == "" {
+		decimalSep = "."
+	}
+	if decimalSep != "." && decimalSep != "," {
+		return nil, errors.New(`decimal_separator must be "." or ","`)
+	}
+	delim := ','
+	if m.Delimiter != "" {
+		if m.Delimiter == `\t` {
+			m.Delimiter = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(m.Delimiter)
+		if size != len(m.Delimiter) || !strings.ContainsRune(",;\t|", r) {
+			return nil, errors.New(`delimiter must be one of "," ";" "\t" "|"`)
+		}
+		delim = r
+	}
+	convention := m.SignConvention
+	if convention == "" {
+		convention = signNegativeExpense
+		if m.Amount == "" && (m.Debit != "" || m.Credit != "") {
+			convention = signDebitCredit
+		}
+	}
+	switch convention {
+	case signNegativeExpense, signPositiveExpense:
+		if m.Amount == "" {
+			return nil, errors.New("mapping.amount is required")
+		}
+	case signDebitCredit:
+		if m.Debit == "" && m.Credit == "" {
+			return nil, errors.New("mapping.debit or mapping.credit is required for debit_credit")
+		}
+	default:
+		return nil, errors.New("Invalid sign_convention. Allowed values: negative_expense, positive_expense, debit_credit")
+	}
+	if m.Date == "" {
+		return nil, errors.New("mapping.date is required")
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(data, "\ufeff")))
+	reader.Comma = delim
+	reader.FieldsPerRecord = -1 // bank exports are often ragged (summary lines)
+	reader.LazyQuotes = true
+
+	hasHeader := m.HasHeader == nil || *m.HasHeader
+	var header []string
+	if hasHeader {
+		header, err = reader.Read()
+		if err != nil {
+			return nil, errors.New("CSV is empty or unreadable")
+		}
+	}
+
+	var cols csvColumns
+	for _, c := range []struct {
+		spec string
+		dst  *int
+	}{
+		{m.Date, &cols.date}, {m.Amount, &cols.amount}, {m.Debit, &cols.debit},
+		{m.Credit, &cols.credit}, {m.Description, &cols.description}, {m.Category, &cols.category},
+	} {
+		idx, err := resolveCSVColumn(c.spec, header)
+		if err != nil {
+			return nil, err
+		}
+		*c.dst = idx
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("CSV could not be parsed: " + err.Error())
+		}
+		if len(rows) >= maxImportRows {
+			return nil, errors.New("CSV has more than " + strconv.Itoa(maxImportRows) + " rows")
+		}
+		line, _ := reader.FieldPos(0)
+		row := importRow{
+			Line:         line,
+			Description:  truncateDescription(csvCell(record, cols.description)),
+			CategoryName: csvCell(record, cols.category),
+		}
+
+		if d, err := time.Parse(layout, csvCell(record, cols.date)); err != nil {
+			row.addError("invalid date " + strconv.Quote(csvCell(record, cols.date)))
+		} else {
+			row.Date = d
+		}
+
+		switch convention {
+		case signDebitCredit:
+			debitRaw, creditRaw := csvCell(record, cols.debit), csvCell(record, cols.credit)
+			debit, debitErr := parseImportAmount(debitRaw, decimalSep)
+			credit, creditErr := parseImportAmount(creditRaw, decimalSep)
+			hasDebit := debitErr == nil && debit != 0
+			hasCredit := creditErr == nil && credit != 0
+			switch {
+			case debitRaw != "" && debitErr != nil, creditRaw != "" && creditErr != nil:
+				row.addError("invalid amount")
+			case hasDebit && hasCredit:
+				row.addError("row has both a debit and a credit amount")
+			case hasDebit:
+				row.Amount, row.Type = math.Abs(debit), "expense"
+			case hasCredit:
+				row.Amount, row.Type = math.Abs(credit), "income"
+			default:
+				row.addError("amount is zero")
+			}
+		default:
+			raw := csvCell(record, cols.amount)
+			v, err := parseImportAmount(raw, decimalSep)
+			switch {
+			case err != nil:
+				row.addError("invalid amount " + strconv.Quote(raw))
+			case v == 0:
+				row.addError("amount is zero")
+			default:
+				outflow := v < 0
+				if convention == signPositiveExpense {
+					outflow = v > 0
+				}
+				row.Amount, row.Type = math.Abs(v), "income"
+				if outflow {
+					row.Type = "expense"
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("CSV contains no data rows")
+	}
+	return rows, nil
+}
+
+// ImportTransactionsCSV → POST /api/transactions/import
+//
+// Without "commit" it is a dry run: the CSV is parsed with the given column
+// mapping and every row comes back with its parsed values, resolved category
+// and validation errors — nothing is written. Re-sending the same payload with
+// "commit": true inserts the batch in one DB transaction. A commit with invalid
+// rows is refused (422) unless "skip_invalid" is set. Rows land in account_id
+// (the default account if omitted) and are in currency, defaulting to the
+// account's; foreign amounts are converted to the base currency.
+func ImportTransactionsCSV(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var req struct {
+		CSV               string           `json:"csv" binding:"required"`
+		Mapping           csvImportMapping `json:"mapping"`
+		DefaultCategoryID uint             `json:"default_category_id"`
+		CategoryMap       map[string]uint  `json:"category_map"`
+		AccountID         *uint            `json:"account_id"` // nil = the default account
+		Currency          string           `json:"currency"`   // "" = the account's currency
+		Commit            bool             `json:"commit"`
+		SkipInvalid       bool             `json:"skip_invalid"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := parseCSVImport(req.CSV, req.Mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := resolveImportCategories(uid, rows, req.DefaultCategoryID, req.CategoryMap); err != nil {
+		log.Printf("csv import: categories user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load categories"})
+		return
+	}
+	account, status, err := resolveTxAccount(uid, req.AccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = account.Currency
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range rows {
+		rows[i].AccountID = &account.ID
+		rows[i].Currency = currency
+	}
+
+	respondImport(c, uid, rows, req.Commit, req.SkipInvalid, "csv import")
+}
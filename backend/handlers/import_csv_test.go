@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func TestParseImportAmount(t *testing.T) {
+	cases := []struct {
+		raw, sep string
+		want     float64
+	}{
+		{"-12.50", ".", -12.5},
+		{"1,234.56", ".", 1234.56},
+		{"€ 1.234,56", ",", 1234.56},
+		{"-1.234,56", ",", -1234.56},
+		{"12,50-", ",", -12.5},
+		{"(99.99)", ".", -99.99},
+		{"+3", ".", 3},
+	}
+	for _, tc := range cases {
+		got, err := parseImportAmount(tc.raw, tc.sep)
+		if err != nil || got != tc.want {
+			t.Errorf("parseImportAmount(%q, %q) = %v, %v; want %v", tc.raw, tc.sep, got, err, tc.want)
+		}
+	}
+	if _, err := parseImportAmount("abc", "."); err == nil {
+		t.Error("expected an error for a non-number")
+	}
+}
+
+// A German-style export (";" delimiter, DD.MM.YYYY, "," decimals) parses with
+// the right sign → type mapping, and a broken row is reported per line.
+func TestParseCSVImport_GermanExport(t *testing.T) {
+	data := "Buchungstag;Betrag;Verwendungszweck;Kategorie\n" +
+		"03.02.2026;-12,50;REWE SAGT DANKE;Essen\n" +
+		"04.02.2026;2.500,00;Gehalt;\n" +
+		"31.02.2026;-1,00;bad date;\n"
+	rows, err := parseCSVImport(data, csvImportMapping{
+		Date: "Buchungstag", Amount: "betrag", Description: "Verwendungszweck", Category: "Kategorie",
+		DateFormat: "DD.MM.YYYY", DecimalSeparator: ",", Delimiter: ";",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("want 3 rows, got %d", len(rows))
+	}
+	if rows[0].Type != "expense" || rows[0].Amount != 12.5 || rows[0].CategoryName != "Essen" || rows[0].Line != 2 {
+		t.Errorf("row 1 parsed wrong: %+v", rows[0])
+	}
+	if rows[1].Type != "income" || rows[1].Amount != 2500 {
+		t.Errorf("row 2 parsed wrong: %+v", rows[1])
+	}
+	if rows[2].valid() {
+		t.Errorf("row 3 (31 Feb) should be invalid")
+	}
+}
+
+func TestParseCSVImport_RejectsBadMapping(t *testing.T) {
+	data := "date,amount\n2026-01-01,-1\n"
+	for name, m := range map[string]csvImportMapping{
+		"missing date":   {Amount: "amount"},
+		"unknown column": {Date: "date", Amount: "value"},
+		"bad convention": {Date: "date", Amount: "amount", SignConvention: "sideways"},
+		"bad separator":  {Date: "date", Amount: "amount", DecimalSeparator: "'"},
+	} {
+		if _, err := parseCSVImport(data, m); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// Preview writes nothing; commit inserts the batch, auto-creates unknown
+// categories, maps known default names by translation key, and windows the
+// rows by their own date rather than the import time.
+func TestImportTransactionsCSV_PreviewThenCommit(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "importer", Password: "x"}
+	database.DB.Create(&user)
+	food := models.Category{UserID: user.ID, Name: "Food", TranslationKey: "category.food"}
+	database.DB.Create(&food)
+
+	body := map[string]any{
+		"csv": "date,amount,memo,cat\n" +
+			"2026-01-10,-20.00,Lidl,Essen\n" +
+			"2026-01-11,-5.00,Cinema,Fun stuff\n" +
+			"2026-01-12,-7.00,Cinema again,fun stuff\n",
+		"mapping": map[string]any{"date": "date", "amount": "amount", "description": "memo", "category": "cat"},
+	}
+
+	w := callHandler(user.ID, body, ImportTransactionsCSV)
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: %d %s", w.Code, w.Body.String())
+	}
+	var preview struct {
+		Preview importSummary `json:"preview"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &preview)
+	if preview.Preview.ValidCount != 3 || len(preview.Preview.NewCategories) != 1 {
+		t.Errorf("preview: want 3 valid rows and 1 new category, got %+v", preview.Preview)
+	}
+	if preview.Preview.Rows[0].CategoryID != food.ID {
+		t.Errorf("\"Essen\" should map to Food via translation key, got category %d", preview.Preview.Rows[0].CategoryID)
+	}
+	var count int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("preview must not write, found %d rows", count)
+	}
+
+	body["commit"] = true
+	if w := callHandler(user.ID, body, ImportTransactionsCSV); w.Code != http.StatusCreated {
+		t.Fatalf("commit: %d %s", w.Code, w.Body.String())
+	}
+	var txs []models.Transaction
+	database.DB.Where("user_id = ?", user.ID).Order("date asc").Find(&txs)
+	if len(txs) != 3 {
+		t.Fatalf("want 3 imported rows, got %d", len(txs))
+	}
+	if txs[1].CategoryID != txs[2].CategoryID || txs[1].CategoryID == food.ID {
+		t.Errorf("both \"fun stuff\" rows should share one auto-created category")
+	}
+	if txs[0].CreatedAt.Year() != 2026 || txs[0].CreatedAt.Month() != 1 || txs[0].CreatedAt.Day() != 10 {
+		t.Errorf("created_at should follow the row date, got %v", txs[0].CreatedAt)
+	}
+}
+
+// A commit with invalid rows is refused as a whole unless skip_invalid is set.
+func TestImportTransactionsCSV_CommitIsAllOrNothing(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "strict", Password: "x"}
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Misc"}
+	database.DB.Create(&cat)
+
+	body := map[string]any{
+		"csv":                 "date,amount\n2026-01-10,-20.00\nnope,-3\n",
+		"mapping":             map[string]any{"date": "date", "amount": "amount"},
+		"default_category_id": cat.ID,
+		"commit":              true,
+	}
+	if w := callHandler(user.ID, body, ImportTransactionsCSV); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("want 422, got %d %s", w.Code, w.Body.String())
+	}
+	var count int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("refused commit must write nothing, found %d rows", count)
+	}
+
+	body["skip_invalid"] = true
+	if w := callHandler(user.ID, body, ImportTransactionsCSV); w.Code != http.StatusCreated {
+		t.Fatalf("skip_invalid commit: %d %s", w.Code, w.Body.String())
+	}
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("want 1 imported row, got %d", count)
+	}
+}
+
+// Rows land in the chosen account, in its currency, converted to the base
+// currency; an unknown or archived account is refused.
+func TestImportTransactionsCSV_IntoForeignAccount(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "traveller", Password: "x"} // base currency USD
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Misc"}
+	database.DB.Create(&cat)
+	card := models.Account{UserID: user.ID, Name: "Euro card", Type: "credit_card", Currency: "EUR"}
+	old := models.Account{UserID: user.ID, Name: "Old bank", Type: "checking", Archived: true}
+	database.DB.Create(&card)
+	database.DB.Create(&old)
+	if w := callHandler(user.ID, map[string]any{"data": "Date,USD,\n2026-01-09,1.1,\n"}, ImportExchangeRates); w.Code != http.StatusOK {
+		t.Fatalf("rates: %d %s", w.Code, w.Body.String())
+	}
+
+	body := map[string]any{
+		"csv":                 "date,amount,memo\n2026-01-10,-20.00,Metro\n",
+		"mapping":             map[string]any{"date": "date", "amount": "amount", "description": "memo"},
+		"default_category_id": cat.ID,
+		"account_id":          old.ID,
+		"commit":              true,
+	}
+	if w := callHandler(user.ID, body, ImportTransactionsCSV); w.Code != http.StatusConflict {
+		t.Errorf("archived account: want 409, got %d", w.Code)
+	}
+	body["account_id"] = card.ID
+	if w := callHandler(user.ID, body, ImportTransactionsCSV); w.Code != http.StatusCreated {
+		t.Fatalf("commit: %d %s", w.Code, w.Body.String())
+	}
+	var tx models.Transaction
+	database.DB.Where("user_id = ?", user.ID).First(&tx)
+	if tx.AccountID == nil || *tx.AccountID != card.ID || tx.Currency != "EUR" || tx.Amount != 22 {
+		t.Errorf("row should sit in the EUR card as 22.00 USD: %+v", tx)
+	}
+}
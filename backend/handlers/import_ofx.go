@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── OFX / QFX statement import ────────────────────────────────────────────────
+//
+// OFX 1.x is SGML: leaf elements are NOT closed ("<TRNAMT>-12.50"), only
+// aggregates such as <STMTTRN>…</STMTTRN> are. OFX 2.x is well-formed XML.
+// Rather than two parsers, a single tolerant scanner handles both: it walks each
+// <STMTTRN> block and reads every "<TAG>value" pair up to the next '<', so a
+// closing "</TAG>" (2.x) or the next opening tag (1.x) both end the value.
+// QFX is Quicken-branded OFX and parses identically.
+
+// ofxTxn is one raw <STMTTRN> record.
+type ofxTxn struct {
+	Line   int
+	Fields map[string]string // upper-cased tag → unescaped, trimmed value
+}
+
+// scanOFXTransactions extracts every <STMTTRN> block from an OFX document.
+func scanOFXTransactions(data string) ([]ofxTxn, error) {
+	upper := strings.ToUpper(data)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, errors.New("Not an OFX/QFX statement")
+	}
+
+	var txns []ofxTxn
+	pos := 0
+	for {
+		start := strings.Index(upper[pos:], "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		start += pos + len("<STMTTRN>")
+		end := strings.Index(upper[start:], "</STMTTRN>")
+		if end < 0 {
+			return nil, errors.New("Unterminated <STMTTRN> block")
+		}
+		end += start
+
+		t := ofxTxn{Line: strings.Count(data[:start], "\n") + 1, Fields: map[string]string{}}
+		block := data[start:end]
+		for i := 0; i < len(block); {
+			lt := strings.IndexByte(block[i:], '<')
+			if lt < 0 {
+				break
+			}
+			lt += i
+			gt := strings.IndexByte(block[lt:], '>')
+			if gt < 0 {
+				break
+			}
+			gt += lt
+			tag := strings.ToUpper(strings.TrimSpace(block[lt+1 : gt]))
+			next := strings.IndexByte(block[gt+1:], '<')
+			if next < 0 {
+				next = len(block)
+			} else {
+				next += gt + 1
+			}
+			if tag != "" && !strings.HasPrefix(tag, "/") {
+				if v := strings.TrimSpace(html.UnescapeString(block[gt+1 : next])); v != "" {
+					t.Fields[tag] = v
+				}
+			}
+			i = next
+		}
+		txns = append(txns, t)
+		pos = end + len("</STMTTRN>")
+	}
+	if len(txns) == 0 {
+		return nil, errors.New("Statement contains no transactions")
+	}
+	if len(txns) > maxImportRows {
+		return nil, errors.New("Statement has more than " + strconv.Itoa(maxImportRows) + " transactions")
+	}
+	return txns, nil
+}
+
+// scanOFXCurrency returns the statement's <CURDEF> (the currency every TRNAMT
+// is in), or "" when the statement does not say.
+func scanOFXCurrency(data string) string {
+	upper := strings.ToUpper(data)
+	start := strings.Index(upper, "<CURDEF>")
+	if start < 0 {
+		return ""
+	}
+	start += len("<CURDEF>")
+	end := strings.IndexByte(data[start:], '<')
+	if end < 0 {
+		end = len(data) - start
+	}
+	return strings.TrimSpace(data[start : start+end])
+}
+
+// parseOFXDate reads DTPOSTED ("YYYYMMDD[HHMMSS[.XXX]][[-5:EST]]"). Only the
+// calendar date is kept, matching every other transaction in the app.
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, errors.New("short date")
+	}
+	return time.Parse("20060102", s[:8])
+}
+
+// ofxRowFromTxn converts one STMTTRN into an import row. DEBIT/CREDIT decide
+// the type outright; every other TRNTYPE (POS, ATM, FEE, DEP, XFER, …) falls
+// back to the sign of TRNAMT.
+func ofxRowFromTxn(t ofxTxn) importRow {
+	row := importRow{Line: t.Line, FITID: t.Fields["FITID"]}
+
+	desc := t.Fields["NAME"]
+	if desc == "" {
+		desc = t.Fields["PAYEE"]
+	}
+	if memo := t.Fields["MEMO"]; memo != "" && !strings.EqualFold(memo, desc) {
+		if desc == "" {
+			desc = memo
+		} else {
+			desc += " — " + memo
+		}
+	}
+	row.Description = truncateDescription(desc)
+
+	if row.FITID == "" {
+		row.addError("missing FITID")
+	} else if len(row.FITID) > 255 {
+		row.addError("FITID is too long")
+	}
+
+	if d, err := parseOFXDate(t.Fields["DTPOSTED"]); err != nil {
+		row.addError("invalid DTPOSTED " + strconv.Quote(t.Fields["DTPOSTED"]))
+	} else {
+		row.Date = d
+	}
+
+	raw := t.Fields["TRNAMT"]
+	sep := "."
+	if strings.Contains(raw, ",") && !strings.Contains(raw, ".") {
+		sep = "," // some European banks write TRNAMT with a decimal comma
+	}
+	amount, err := parseImportAmount(raw, sep)
+	switch {
+	case err != nil:
+		row.addError("invalid TRNAMT " + strconv.Quote(raw))
+	case amount == 0:
+		row.addError("amount is zero")
+	default:
+		row.Amount = math.Abs(amount)
+		switch strings.ToUpper(t.Fields["TRNTYPE"]) {
+		case "DEBIT":
+			row.Type = "expense"
+		case "CREDIT":
+			row.Type = "income"
+		default:
+			row.Type = "income"
+			if amount < 0 {
+				row.Type = "expense"
+			}
+		}
+	}
+	return row
+}
+
+// markDuplicateFITIDs flags rows whose FITID the account already has —
+// including soft-deleted rows, so a transaction the user deliberately removed
+// does not reappear on the next import — and repeats of a FITID within the
+// same file. Banks only keep FITIDs unique per account, so another account's
+// rows never count.
+func markDuplicateFITIDs(uid, accountID uint, rows []importRow) error {
+	ids := make([]string, 0, len(rows))
+	for _, r := range rows {
+		if r.FITID != "" {
+			ids = append(ids, r.FITID)
+		}
+	}
+	existing := map[string]bool{}
+	for start := 0; start < len(ids); start += 500 {
+		chunk := ids[start:min(start+500, len(ids))]
+		var found []string
+		if err := database.DB.Unscoped().Model(&models.Transaction{}).
+			Where("user_id = ? AND fitid IN ?", uid, chunk).
+			Where("(account_id = ? OR (account_id IS NULL AND EXISTS (SELECT 1 FROM accounts a WHERE a.id = ? AND a.is_default = ?)))",
+				accountID, accountID, true).
+			Pluck("fitid", &found).Error; err != nil {
+			return err
+		}
+		for _, f := range found {
+			existing[f] = true
+		}
+	}
+	for i := range rows {
+		if rows[i].FITID == "" {
+			continue
+		}
+		if existing[rows[i].FITID] {
+			rows[i].Duplicate = true
+		}
+		existing[rows[i].FITID] = true
+	}
+	return nil
+}
+
+// ImportTransactionsOFX → POST /api/transactions/import/ofx
+//
+// Same preview/commit flow as the CSV importer. OFX carries no categories, so
+// rows land in default_category_id (income rows in income_category_id when
+// given). Rows whose FITID was imported before are reported as duplicates and
+// skipped, so overlapping statements can be re-imported safely. Amounts are in
+// the statement's CURDEF, falling back to the account's currency, and are
+// converted to the base currency like any other foreign transaction.
+func ImportTransactionsOFX(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var req struct {
+		OFX               string `json:"ofx" binding:"required"`
+		DefaultCategoryID uint   `json:"default_category_id" binding:"required"`
+		IncomeCategoryID  uint   `json:"income_category_id"`
+		AccountID         *uint  `json:"account_id"` // nil = the default account
+		Commit            bool   `json:"commit"`
+		SkipInvalid       bool   `json:"skip_invalid"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	txns, err := scanOFXTransactions(req.OFX)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currency, err := normalizeCurrency(scanOFXCurrency(req.OFX))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CURDEF in the statement"})
+		return
+	}
+	rows := make([]importRow, len(txns))
+	for i, t := range txns {
+		rows[i] = ofxRowFromTxn(t)
+	}
+
+	if req.IncomeCategoryID != 0 {
+		var n int64
+		database.DB.Model(&models.Category{}).
+			Where("id = ? AND user_id = ?", req.IncomeCategoryID, uid).Count(&n)
+		if n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Income category not found or does not belong to you"})
+			return
+		}
+	}
+	if err := resolveImportCategories(uid, rows, req.DefaultCategoryID, nil); err != nil {
+		log.Printf("ofx import: categories user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load categories"})
+		return
+	}
+	if req.IncomeCategoryID != 0 {
+		for i := range rows {
+			// Income a rule already categorized keeps the rule's category.
+			if rows[i].valid() && rows[i].Type == "income" && !rows[i].ruleCategorized {
+				rows[i].CategoryID = req.IncomeCategoryID
+			}
+		}
+	}
+	account, status, err := resolveTxAccount(uid, req.AccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if currency == "" {
+		currency = account.Currency
+	}
+	for i := range rows {
+		rows[i].AccountID = &account.ID
+		rows[i].Currency = currency
+	}
+	if err := markDuplicateFITIDs(uid, account.ID, rows); err != nil {
+		log.Printf("ofx import: fitid lookup user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for already imported transactions"})
+		return
+	}
+
+	respondImport(c, uid, rows, req.Commit, req.SkipInvalid, "ofx import")
+}
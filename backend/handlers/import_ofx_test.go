@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// OFX 1.x: SGML header, unclosed leaf elements.
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260203120000[-5:EST]
+<TRNAMT>-12.50
+<FITID>A-1001
+<NAME>REWE &amp; CO
+<MEMO>Card 1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEP
+<DTPOSTED>20260204
+<TRNAMT>2500,00
+<FITID>A-1002
+<NAME>Salary
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// OFX 2.x: XML with closed elements.
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20260210</DTPOSTED><TRNAMT>-4.20</TRNAMT><FITID>X-1</FITID><NAME>Coffee</NAME><MEMO>Coffee</MEMO></STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20260211</DTPOSTED><TRNAMT>15.00</TRNAMT><FITID>X-2</FITID><NAME>Refund</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>2026</DTPOSTED><TRNAMT>-1.00</TRNAMT><NAME>Broken</NAME></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+func parseOFXRows(t *testing.T, data string) []importRow {
+	t.Helper()
+	txns, err := scanOFXTransactions(data)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	rows := make([]importRow, len(txns))
+	for i, tx := range txns {
+		rows[i] = ofxRowFromTxn(tx)
+	}
+	return rows
+}
+
+func TestParseOFX_SGML(t *testing.T) {
+	rows := parseOFXRows(t, sgmlStatement)
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %d", len(rows))
+	}
+	r := rows[0]
+	if !r.valid() || r.Type != "expense" || r.Amount != 12.5 || r.FITID != "A-1001" ||
+		r.Description != "REWE & CO — Card 1234" || r.Date.Format("2006-01-02") != "2026-02-03" {
+		t.Errorf("row 1 parsed wrong: %+v", r)
+	}
+	if !rows[1].valid() || rows[1].Type != "income" || rows[1].Amount != 2500 {
+		t.Errorf("row 2 (decimal comma, sign-typed DEP) parsed wrong: %+v", rows[1])
+	}
+}
+
+func TestParseOFX_XML(t *testing.T) {
+	rows := parseOFXRows(t, xmlStatement)
+	if len(rows) != 3 {
+		t.Fatalf("want 3 rows, got %d", len(rows))
+	}
+	if rows[0].Type != "expense" || rows[0].Description != "Coffee" {
+		t.Errorf("row 1 parsed wrong: %+v", rows[0])
+	}
+	if rows[1].Type != "income" || rows[1].Amount != 15 {
+		t.Errorf("row 2 parsed wrong: %+v", rows[1])
+	}
+	if rows[2].valid() || len(rows[2].Errors) != 2 {
+		t.Errorf("row 3 should report a missing FITID and a bad date, got %v", rows[2].Errors)
+	}
+	if _, err := scanOFXTransactions("date,amount\n"); err == nil {
+		t.Error("a CSV body must be rejected as not OFX")
+	}
+}
+
+// Importing an overlapping statement a second time inserts nothing and reports
+// every row as a duplicate — including one the user has since deleted.
+func TestImportTransactionsOFX_ReimportIsDeduplicated(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "ofx", Password: "x"}
+	database.DB.Create(&user)
+	misc := models.Category{UserID: user.ID, Name: "Misc"}
+	income := models.Category{UserID: user.ID, Name: "Income"}
+	database.DB.Create(&misc)
+	database.DB.Create(&income)
+
+	body := map[string]any{
+		"ofx":                 sgmlStatement,
+		"default_category_id": misc.ID,
+		"income_category_id":  income.ID,
+		"commit":              true,
+	}
+	if w := callHandler(user.ID, body, ImportTransactionsOFX); w.Code != http.StatusCreated {
+		t.Fatalf("first import: %d %s", w.Code, w.Body.String())
+	}
+	var txs []models.Transaction
+	database.DB.Where("user_id = ?", user.ID).Order("date asc").Find(&txs)
+	if len(txs) != 2 || txs[0].CategoryID != misc.ID || txs[1].CategoryID != income.ID {
+		t.Fatalf("want expense in Misc and income in Income, got %+v", txs)
+	}
+
+	database.DB.Delete(&txs[0])
+
+	w := callHandler(user.ID, body, ImportTransactionsOFX)
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-import: want 200, got %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Imported   int `json:"imported"`
+		Duplicates int `json:"duplicates"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Imported != 0 || resp.Duplicates != 2 {
+		t.Errorf("want 0 imported / 2 duplicates, got %+v", resp)
+	}
+	var count int64
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("re-import must not insert, found %d rows", count)
+	}
+}
+
+// FITIDs are only unique per bank account: the same statement imported into
+// a second account lands there, and only a re-import into the same account is
+// a duplicate.
+func TestImportTransactionsOFX_FITIDIsPerAccount(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "twobanks", Password: "x"}
+	database.DB.Create(&user)
+	misc := models.Category{UserID: user.ID, Name: "Misc"}
+	database.DB.Create(&misc)
+	bankA := models.Account{UserID: user.ID, Name: "Bank A", Type: "checking", IsDefault: true}
+	bankB := models.Account{UserID: user.ID, Name: "Bank B", Type: "checking"}
+	database.DB.Create(&bankA)
+	database.DB.Create(&bankB)
+
+	importInto := func(accountID uint) (int, int) {
+		w := callHandler(user.ID, map[string]any{
+			"ofx": sgmlStatement, "default_category_id": misc.ID, "account_id": accountID, "commit": true,
+		}, ImportTransactionsOFX)
+		var resp struct {
+			Imported   int `json:"imported"`
+			Duplicates int `json:"duplicates"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Imported, resp.Duplicates
+	}
+	if imported, _ := importInto(bankA.ID); imported != 2 {
+		t.Fatalf("bank A: want 2 imported, got %d", imported)
+	}
+	if imported, dups := importInto(bankB.ID); imported != 2 || dups != 0 {
+		t.Errorf("bank B shares the FITIDs but is another account: %d imported / %d duplicates", imported, dups)
+	}
+	if imported, dups := importInto(bankA.ID); imported != 0 || dups != 2 {
+		t.Errorf("re-import into bank A: %d imported / %d duplicates", imported, dups)
+	}
+	var inB int64
+	database.DB.Model(&models.Transaction{}).Where("account_id = ? AND fitid <> ''", bankB.ID).Count(&inB)
+	if inB != 2 {
+		t.Errorf("bank B should hold its own rows, has %d", inB)
+	}
+}
+
+// A statement for a foreign-currency account is in that currency unless its
+// CURDEF says otherwise; the preview names the missing rates, and the commit
+// books base-currency amounts.
+func TestImportTransactionsOFX_ForeignAccount(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "eurocard", Password: "x"} // base currency USD
+	database.DB.Create(&user)
+	misc := models.Category{UserID: user.ID, Name: "Misc"}
+	database.DB.Create(&misc)
+	card := models.Account{UserID: user.ID, Name: "Euro card", Type: "credit_card", Currency: "EUR"}
+	database.DB.Create(&card)
+
+	importOFX := func(data string, commit bool) (int, importSummary) {
+		w := callHandler(user.ID, map[string]any{
+			"ofx": data, "default_category_id": misc.ID, "account_id": card.ID, "commit": commit,
+		}, ImportTransactionsOFX)
+		var resp struct {
+			Preview importSummary `json:"preview"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Preview
+	}
+
+	code, preview := importOFX(sgmlStatement, false)
+	if code != http.StatusOK || preview.ErrorCount != 2 || len(preview.MissingRates) != 2 ||
+		preview.MissingRates[0] != (missingRate{From: "EUR", To: "USD", Date: "2026-02-03"}) {
+		t.Fatalf("dry-run without rates: %d %+v", code, preview)
+	}
+	if code, _ := importOFX(sgmlStatement, true); code != http.StatusUnprocessableEntity {
+		t.Errorf("commit without rates: want 422, got %d", code)
+	}
+	gbp := strings.Replace(sgmlStatement, "<BANKTRANLIST>", "<CURDEF>GBP\n<BANKTRANLIST>", 1)
+	if _, preview := importOFX(gbp, false); len(preview.Rows) != 2 || preview.Rows[0].Currency != "GBP" {
+		t.Errorf("CURDEF should win over the account currency: %+v", preview.Rows)
+	}
+
+	if w := callHandler(user.ID, map[string]any{"data": "Date,USD,\n2026-02-03,1.1,\n"}, ImportExchangeRates); w.Code != http.StatusOK {
+		t.Fatalf("rates: %d %s", w.Code, w.Body.String())
+	}
+	if code, _ := importOFX(sgmlStatement, true); code != http.StatusCreated {
+		t.Fatalf("commit: want 201, got %d", code)
+	}
+	var rows []models.Transaction
+	database.DB.Where("user_id = ?", user.ID).Order("date").Find(&rows)
+	if len(rows) != 2 || rows[0].Currency != "EUR" || rows[0].OriginalAmount == nil || *rows[0].OriginalAmount != 12.5 ||
+		rows[0].Amount != 13.75 || rows[1].Amount != 2750 {
+		t.Errorf("imported rows should be converted to USD: %+v", rows)
+	}
+}
@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Statement reconciliation ─────────────────────────────────────────────────
+//
+// A session checks one account against a bank statement: the user gives the
+// statement's closing date and balance, ticks off the rows the statement
+// shows (pending → cleared) and watches the difference between the statement
+// balance and the cleared balance — the opening balance plus every cleared or
+// reconciled row up to the statement date. At a difference of zero the
+// session can be locked: its cleared rows become reconciled and refuse edits
+// and deletes until the session is explicitly unlocked.
+
+const (
+	txStatusPending    = "pending"
+	txStatusCleared    = "cleared"
+	txStatusReconciled = "reconciled"
+
+	reconciliationOpen   = "open"
+	reconciliationLocked = "locked"
+)
+
+// errReconciled is returned when a write would touch a reconciled row.
+var errReconciled = errors.New("The transaction is reconciled — unlock its reconciliation first")
+
+// whereAccountRows limits q to the transactions of account a; the default
+// account also owns rows without account_id.
+func whereAccountRows(q *gorm.DB, a models.Account) *gorm.DB {
+	if a.IsDefault {
+		return q.Where("(account_id = ? OR account_id IS NULL)", a.ID)
+	}
+	return q.Where("account_id = ?", a.ID)
+}
+
+// anyReconciled reports whether any of the given transactions is reconciled.
+func anyReconciled(db *gorm.DB, uid uint, ids []uint) (bool, error) {
+	var n int64
+	err := db.Unscoped().Model(&models.Transaction{}).
+		Where("user_id = ? AND id IN ? AND status = ?", uid, ids, txStatusReconciled).Count(&n).Error
+	return n > 0, err
+}
+
+// reconciliationView is the state of a session as the client sees it.
+type reconciliationView struct {
+	Reconciliation   models.Reconciliation `json:"reconciliation"`
+	Account          models.Account        `json:"account"`
+	StatementBalance float64               `json:"statement_balance"`
+	ClearedBalance   float64               `json:"cleared_balance"`
+	Difference       float64               `json:"difference"`
+	ClearedCount     int                   `json:"cleared_count"`
+	// Transactions are the rows this session works on: the account's rows up
+	// to the statement date that no other session has reconciled.
+	Transactions []models.Transaction `json:"transactions"`
+	// MissingRates lists conversions into the account's currency that have
+	// no rate; the session cannot be locked until they are known.
+	MissingRates []missingRate `json:"missing_rates,omitempty"`
+}
+
+// asH is v as a gin.H, for the 412 answer to a stale If-Match.
+func (v reconciliationView) asH() gin.H {
+	h := gin.H{
+		"reconciliation":    v.Reconciliation,
+		"account":           v.Account,
+		"statement_balance": v.StatementBalance,
+		"cleared_balance":   v.ClearedBalance,
+		"difference":        v.Difference,
+		"cleared_count":     v.ClearedCount,
+		"transactions":      v.Transactions,
+	}
+	if len(v.MissingRates) > 0 {
+		h["missing_rates"] = v.MissingRates
+	}
+	return h
+}
+
+// computeReconciliation builds the view of rec for account a.
+func computeReconciliation(db *gorm.DB, uid uint, rec models.Reconciliation, a models.Account) (reconciliationView, error) {
+	v := reconciliationView{Reconciliation: rec, Account: a, StatementBalance: rec.StatementBalance, Transactions: []models.Transaction{}}
+
+	var txs []models.Transaction
+	q := whereAccountRows(db.Preload("Category").Where("user_id = ? AND date < ?", uid, rec.StatementDate.AddDate(0, 0, 1)), a)
+	if err := q.Order("date").Order("id").Find(&txs).Error; err != nil {
+		return v, err
+	}
+
+	base := userBaseCurrency(db, uid)
+	accCur := orBase(a.Currency, base)
+	cleared := a.OpeningBalance
+	for _, t := range txs {
+		ours := t.ReconciliationID != nil && *t.ReconciliationID == rec.ID
+		// Rows settled by an earlier statement count toward the balance but
+		// are no longer part of the work list.
+		if t.Status != txStatusReconciled || ours {
+			v.Transactions = append(v.Transactions, t)
+		}
+		if t.Status == txStatusPending {
+			continue
+		}
+		amount, missing, err := accountAmount(db, uid, t, accCur, base)
+		if err != nil {
+			return v, err
+		}
+		if missing != nil {
+			v.MissingRates = append(v.MissingRates, *missing)
+			continue
+		}
+		cleared += accountTxSign(t.Type) * amount
+		if t.Status == txStatusCleared || ours {
+			v.ClearedCount++
+		}
+	}
+	v.ClearedBalance = round2(cleared)
+	v.Difference = round2(rec.StatementBalance - v.ClearedBalance)
+	return v, nil
+}
+
+// loadReconciliation fetches session id of uid together with its account.
+// The error is safe to send to the client with the returned status.
+func loadReconciliation(uid uint, idParam string) (models.Reconciliation, models.Account, int, error) {
+	var rec models.Reconciliation
+	var account models.Account
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return rec, account, http.StatusBadRequest, errors.New("Invalid reconciliation ID format")
+	}
+	if err := database.DB.Where("id = ? AND user_id = ?", uint(id), uid).First(&rec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return rec, account, http.StatusNotFound, errors.New("Reconciliation not found or does not belong to you")
+		}
+		log.Printf("load reconciliation: user=%v rec=%v err=%v", uid, id, err)
+		return rec, account, http.StatusInternalServerError, errors.New("Failed to fetch reconciliation")
+	}
+	if err := database.DB.Where("id = ? AND user_id = ?", rec.AccountID, uid).First(&account).Error; err != nil {
+		log.Printf("load reconciliation: account user=%v rec=%v err=%v", uid, id, err)
+		return rec, account, http.StatusInternalServerError, errors.New("Failed to fetch reconciliation")
+	}
+	return rec, account, http.StatusOK, nil
+}
+
+// staleReconciliation answers a write on session id whose If-Match is out of
+// date: 412 with the session's current view.
+func staleReconciliation(c *gin.Context, uid, id uint) {
+	respondStale(c, "Reconciliation not found or does not belong to you", func() (string, gin.H, error) {
+		return currentReconciliation(uid, id)
+	})
+}
+
+// GetReconciliations → GET /api/reconciliations?account_id=N
+// Lists sessions, newest statement first.
+func GetReconciliations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	q := database.DB.Where("user_id = ?", uid)
+	if s := c.Query("account_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account_id format"})
+			return
+		}
+		q = q.Where("account_id = ?", uint(id))
+	}
+	var recs []models.Reconciliation
+	if err := q.Order("statement_date DESC").Order("id DESC").Find(&recs).Error; err != nil {
+		log.Printf("get reconciliations: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reconciliations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reconciliations": recs})
+}
+
+// CreateReconciliation → POST /api/reconciliations
+// Opens a session for {account_id, statement_date, statement_balance}. An
+// account has at most one open session, and a statement may not end before
+// one that is already locked.
+func CreateReconciliation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		AccountID        uint     `json:"account_id" binding:"required"`
+		StatementDate    string   `json:"statement_date" binding:"required"`
+		StatementBalance *float64 `json:"statement_balance" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	statementDate, err := time.Parse("2006-01-02", input.StatementDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement_date format. Use YYYY-MM-DD"})
+		return
+	}
+	account, status, err := resolveTxAccount(uid, &input.AccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var open int64
+	database.DB.Model(&models.Reconciliation{}).
+		Where("user_id = ? AND account_id = ? AND status = ?", uid, account.ID, reconciliationOpen).Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This account already has an open reconciliation"})
+		return
+	}
+	var later int64
+	database.DB.Model(&models.Reconciliation{}).
+		Where("user_id = ? AND account_id = ? AND status = ? AND statement_date > ?", uid, account.ID, reconciliationLocked, statementDate).
+		Count(&later)
+	if later > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A later statement of this account is already reconciled"})
+		return
+	}
+
+	rec := models.Reconciliation{
+		UserID: uid, AccountID: account.ID, StatementDate: statementDate, StatementBalance: round2(*input.StatementBalance),
+		Status: reconciliationOpen, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := database.DB.Create(&rec).Error; err != nil {
+		log.Printf("create reconciliation: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reconciliation"})
+		return
+	}
+	view, err := computeReconciliation(database.DB, uid, rec, account)
+	if err != nil {
+		log.Printf("create reconciliation: view user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute reconciliation"})
+		return
+	}
+	c.JSON(http.StatusCreated, view)
+}
+
+// GetReconciliation → GET /api/reconciliations/:id
+func GetReconciliation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	rec, account, status, err := loadReconciliation(uid, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	view, err := computeReconciliation(database.DB, uid, rec, account)
+	if err != nil {
+		log.Printf("get reconciliation: user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute reconciliation"})
+		return
+	}
+	c.Header("ETag", reconciliationETag(rec))
+	c.JSON(http.StatusOK, view)
+}
+
+// ClearReconciliationRows → POST /api/reconciliations/:id/clear
+// Marks {transaction_ids} cleared, or pending again with "cleared": false.
+// Every row must belong to the session's account, fall on or before the
+// statement date and not be reconciled.
+func ClearReconciliationRows(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	rec, account, status, err := loadReconciliation(uid, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if ifMatchFails(c, reconciliationETag(rec)) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if rec.Status != reconciliationOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "The reconciliation is locked"})
+		return
+	}
+	var input struct {
+		TransactionIDs []uint `json:"transaction_ids" binding:"required,min=1"`
+		Cleared        *bool  `json:"cleared"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids := uniqueUints(input.TransactionIDs)
+	newStatus := txStatusCleared
+	if input.Cleared != nil && !*input.Cleared {
+		newStatus = txStatusPending
+	}
+
+	var n int64
+	q := whereAccountRows(database.DB.Model(&models.Transaction{}).
+		Where("user_id = ? AND id IN ? AND date < ? AND status <> ?", uid, ids, rec.StatementDate.AddDate(0, 0, 1), txStatusReconciled), account)
+	if err := q.Count(&n).Error; err != nil {
+		log.Printf("clear reconciliation rows: verify user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transactions"})
+		return
+	}
+	if int(n) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Every transaction must be an unreconciled row of this account dated on or before the statement date"})
+		return
+	}
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Reconciliation{}, rec.ID, rec.UpdatedAt); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Transaction{}).Where("user_id = ? AND id IN ?", uid, ids).
+			Updates(map[string]any{"status": newStatus, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		// The session's ETag covers its cleared marks.
+		rec.UpdatedAt = now
+		return tx.Model(&models.Reconciliation{}).Where("id = ?", rec.ID).UpdateColumn("updated_at", now).Error
+	})
+	if errors.Is(err, errStale) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("clear reconciliation rows: user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transactions"})
+		return
+	}
+
+	view, err := computeReconciliation(database.DB, uid, rec, account)
+	if err != nil {
+		log.Printf("clear reconciliation rows: view user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute reconciliation"})
+		return
+	}
+	c.Header("ETag", reconciliationETag(rec))
+	c.JSON(http.StatusOK, view)
+}
+
+// LockReconciliation → POST /api/reconciliations/:id/lock
+// Refused (409) unless the cleared balance matches the statement.
+func LockReconciliation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	rec, account, status, err := loadReconciliation(uid, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if ifMatchFails(c, reconciliationETag(rec)) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if rec.Status != reconciliationOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "The reconciliation is already locked"})
+		return
+	}
+	view, err := computeReconciliation(database.DB, uid, rec, account)
+	if err != nil {
+		log.Printf("lock reconciliation: view user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute reconciliation"})
+		return
+	}
+	if len(view.MissingRates) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": view.MissingRates[0].message(), "missing_rates": view.MissingRates})
+		return
+	}
+	if math.Abs(view.Difference) >= 0.005 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The cleared balance does not match the statement", "difference": view.Difference})
+		return
+	}
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Reconciliation{}, rec.ID, rec.UpdatedAt); err != nil {
+			return err
+		}
+		q := whereAccountRows(tx.Model(&models.Transaction{}).
+			Where("user_id = ? AND date < ? AND status = ?", uid, rec.StatementDate.AddDate(0, 0, 1), txStatusCleared), account)
+		if err := q.Updates(map[string]any{"status": txStatusReconciled, "reconciliation_id": rec.ID, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		rec.Status, rec.LockedAt, rec.UpdatedAt = reconciliationLocked, &now, now
+		return tx.Save(&rec).Error
+	})
+	if errors.Is(err, errStale) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("lock reconciliation: user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock reconciliation"})
+		return
+	}
+
+	c.Header("ETag", reconciliationETag(rec))
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation locked", "reconciliation": rec})
+}
+
+// UnlockReconciliation → POST /api/reconciliations/:id/unlock
+// Reopens the account's latest locked session; its rows go back to cleared
+// and can be edited again.
+func UnlockReconciliation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	rec, _, status, err := loadReconciliation(uid, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if ifMatchFails(c, reconciliationETag(rec)) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if rec.Status != reconciliationLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": "The reconciliation is not locked"})
+		return
+	}
+	var blocking int64
+	database.DB.Model(&models.Reconciliation{}).
+		Where("user_id = ? AND account_id = ? AND id <> ? AND (status = ? OR statement_date > ?)",
+			uid, rec.AccountID, rec.ID, reconciliationOpen, rec.StatementDate).
+		Count(&blocking)
+	if blocking > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only the account's latest reconciliation can be unlocked, with no other session open"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Reconciliation{}, rec.ID, rec.UpdatedAt); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Transaction{}).Where("user_id = ? AND reconciliation_id = ?", uid, rec.ID).
+			Updates(map[string]any{"status": txStatusCleared, "reconciliation_id": nil, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		rec.Status, rec.LockedAt, rec.UpdatedAt = reconciliationOpen, nil, time.Now()
+		return tx.Save(&rec).Error
+	})
+	if errors.Is(err, errStale) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("unlock reconciliation: user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock reconciliation"})
+		return
+	}
+
+	c.Header("ETag", reconciliationETag(rec))
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation unlocked", "reconciliation": rec})
+}
+
+// DeleteReconciliation → DELETE /api/reconciliations/:id
+// Abandons an open session. Rows keep their cleared marks; locked sessions
+// must be unlocked first.
+func DeleteReconciliation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	rec, _, status, err := loadReconciliation(uid, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if ifMatchFails(c, reconciliationETag(rec)) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if rec.Status != reconciliationOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unlock the reconciliation before deleting it"})
+		return
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Reconciliation{}, rec.ID, rec.UpdatedAt); err != nil {
+			return err
+		}
+		return tx.Delete(&rec).Error
+	})
+	if errors.Is(err, errStale) {
+		staleReconciliation(c, uid, rec.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("delete reconciliation: user=%v rec=%v err=%v", uid, rec.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reconciliation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation deleted successfully"})
+}
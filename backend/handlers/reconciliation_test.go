@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func decodeReconciliation(t *testing.T, body []byte) reconciliationView {
+	t.Helper()
+	var v reconciliationView
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("decode reconciliation: %v %s", err, body)
+	}
+	return v
+}
+
+// Clearing rows closes the gap to the statement; only a zero difference
+// locks, and locked rows refuse edits and deletes until the unlock.
+func TestReconciliationWorkflow(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	if w := callHandlerParamBody(uid, catParam(def.ID), map[string]any{"opening_balance": 100}, UpdateAccount); w.Code != http.StatusOK {
+		t.Fatalf("opening balance: %d %s", w.Code, w.Body.String())
+	}
+
+	// Rows without account_id belong to the default account.
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 2))
+	addExpense(t, uid, f.food.ID, 20, utcDay(2026, 5, 5))
+	addExpense(t, uid, f.food.ID, 10, utcDay(2026, 5, 20))
+	salary := models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 500, Type: "income", Date: utcDay(2026, 5, 3)}
+	database.DB.Create(&salary)
+	var rows []models.Transaction
+	database.DB.Where("user_id = ? AND type = ?", uid, "expense").Order("date").Find(&rows)
+	if rows[0].Status != txStatusPending {
+		t.Fatalf("new rows should be pending, got %q", rows[0].Status)
+	}
+
+	w := callHandler(uid, map[string]any{"account_id": def.ID, "statement_date": "2026-05-15", "statement_balance": 550}, CreateReconciliation)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	v := decodeReconciliation(t, w.Body.Bytes())
+	rec := v.Reconciliation
+	if len(v.Transactions) != 3 || v.ClearedBalance != 100 || v.Difference != 450 {
+		t.Errorf("fresh session: %d rows, cleared %.2f, difference %.2f", len(v.Transactions), v.ClearedBalance, v.Difference)
+	}
+	if w := callHandler(uid, map[string]any{"account_id": def.ID, "statement_date": "2026-05-31", "statement_balance": 0}, CreateReconciliation); w.Code != http.StatusConflict {
+		t.Errorf("second open session: want 409, got %d", w.Code)
+	}
+
+	clearRows := func(ids ...uint) int {
+		w := callHandlerParamBody(uid, catParam(rec.ID), map[string]any{"transaction_ids": ids}, ClearReconciliationRows)
+		if w.Code == http.StatusOK {
+			v = decodeReconciliation(t, w.Body.Bytes())
+		}
+		return w.Code
+	}
+	if code := clearRows(rows[2].ID); code != http.StatusBadRequest {
+		t.Errorf("clearing a row after the statement date: want 400, got %d", code)
+	}
+	if code := clearRows(rows[0].ID, salary.ID); code != http.StatusOK || v.Difference != -20 {
+		t.Fatalf("after clearing two rows: %d, difference %.2f", code, v.Difference)
+	}
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusConflict {
+		t.Errorf("lock with a difference: want 409, got %d", w.Code)
+	}
+	if code := clearRows(rows[1].ID); code != http.StatusOK || v.Difference != 0 || v.ClearedCount != 3 {
+		t.Fatalf("after clearing all: %d, difference %.2f, cleared %d", code, v.Difference, v.ClearedCount)
+	}
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("lock: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := callHandlerParamBody(uid, txParam(rows[0].ID), map[string]any{"amount": 31}, UpdateTransaction); w.Code != http.StatusConflict {
+		t.Errorf("editing a reconciled row: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, txParam(rows[0].ID), DeleteTransaction); w.Code != http.StatusConflict {
+		t.Errorf("deleting a reconciled row: want 409, got %d", w.Code)
+	}
+	// The row after the statement date stays editable.
+	if w := callHandlerParamBody(uid, txParam(rows[2].ID), map[string]any{"status": "cleared"}, UpdateTransaction); w.Code != http.StatusOK {
+		t.Errorf("editing an unreconciled row: want 200, got %d", w.Code)
+	}
+
+	if w := callHandlerParam(uid, catParam(rec.ID), UnlockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("unlock: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParamBody(uid, txParam(rows[0].ID), map[string]any{"amount": 31}, UpdateTransaction); w.Code != http.StatusOK {
+		t.Errorf("editing after the unlock: want 200, got %d", w.Code)
+	}
+	var after models.Transaction
+	database.DB.First(&after, rows[0].ID)
+	if after.Status != txStatusCleared || after.ReconciliationID != nil {
+		t.Errorf("unlocked rows go back to cleared: %q %v", after.Status, after.ReconciliationID)
+	}
+}
+
+// openSession starts a reconciliation of account at statement date day.
+func openSession(t *testing.T, uid, account uint, day string, balance float64) models.Reconciliation {
+	t.Helper()
+	w := callHandler(uid, map[string]any{"account_id": account, "statement_date": day, "statement_balance": balance}, CreateReconciliation)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create %s: %d %s", day, w.Code, w.Body.String())
+	}
+	return decodeReconciliation(t, w.Body.Bytes()).Reconciliation
+}
+
+// clearAndLock clears ids in rec and locks it.
+func clearAndLock(t *testing.T, uid uint, rec models.Reconciliation, ids ...uint) {
+	t.Helper()
+	if w := callHandlerParamBody(uid, catParam(rec.ID), map[string]any{"transaction_ids": ids}, ClearReconciliationRows); w.Code != http.StatusOK {
+		t.Fatalf("clear: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("lock: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// A session with a difference stays open and reconciles nothing.
+func TestReconciliation_LockNeedsZeroDifference(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 2))
+	var row models.Transaction
+	database.DB.Where("user_id = ?", uid).First(&row)
+	rec := openSession(t, uid, def.ID, "2026-05-15", -30.5)
+	callHandlerParamBody(uid, catParam(rec.ID), map[string]any{"transaction_ids": []uint{row.ID}}, ClearReconciliationRows)
+
+	if w := callHandlerParam(uid, catParam(rec.ID), LockReconciliation); w.Code != http.StatusConflict {
+		t.Fatalf("lock 0.50 off: want 409, got %d", w.Code)
+	}
+	var got models.Reconciliation
+	database.DB.First(&got, rec.ID)
+	database.DB.First(&row, row.ID)
+	if got.Status != reconciliationOpen || row.Status != txStatusCleared || row.ReconciliationID != nil {
+		t.Errorf("a refused lock must not reconcile: session %q, row %q %v", got.Status, row.Status, row.ReconciliationID)
+	}
+}
+
+// Only the account's latest session unlocks, and only while no other one is
+// open.
+func TestReconciliation_UnlockOnlyTheLatest(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 2))
+	addExpense(t, uid, f.food.ID, 20, utcDay(2026, 5, 20))
+	var rows []models.Transaction
+	database.DB.Where("user_id = ?", uid).Order("date").Find(&rows)
+
+	may := openSession(t, uid, def.ID, "2026-05-15", -30)
+	clearAndLock(t, uid, may, rows[0].ID)
+	june := openSession(t, uid, def.ID, "2026-05-31", -50)
+
+	if w := callHandlerParam(uid, catParam(may.ID), UnlockReconciliation); w.Code != http.StatusConflict {
+		t.Errorf("unlock while a later session is open: want 409, got %d", w.Code)
+	}
+	clearAndLock(t, uid, june, rows[1].ID)
+	if w := callHandlerParam(uid, catParam(may.ID), UnlockReconciliation); w.Code != http.StatusConflict {
+		t.Errorf("unlock behind a later locked session: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, catParam(june.ID), UnlockReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("unlock the latest: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, catParam(june.ID), DeleteReconciliation); w.Code != http.StatusOK {
+		t.Fatalf("delete the reopened session: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, catParam(may.ID), UnlockReconciliation); w.Code != http.StatusOK {
+		t.Errorf("the earlier session is the latest again: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// Every write path refuses a reconciled row: single edit and delete, bulk,
+// and a merge on either side.
+func TestReconciliation_ReconciledRowsRefuseEveryWrite(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 2))
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 3))
+	var rows []models.Transaction
+	database.DB.Where("user_id = ?", uid).Order("date").Find(&rows)
+	locked, twin := rows[0], rows[1]
+	clearAndLock(t, uid, openSession(t, uid, def.ID, "2026-05-02", -30), locked.ID)
+
+	checks := map[string]int{
+		"update":            callHandlerParamBody(uid, txParam(locked.ID), map[string]any{"description": "x"}, UpdateTransaction).Code,
+		"delete":            callHandlerParam(uid, txParam(locked.ID), DeleteTransaction).Code,
+		"bulk set_category": callHandler(uid, map[string]any{"ids": []uint{locked.ID}, "operation": "set_category", "category_id": f.rent.ID}, BulkTransactions).Code,
+		"bulk delete":       callHandler(uid, map[string]any{"ids": []uint{twin.ID, locked.ID}, "operation": "delete"}, BulkTransactions).Code,
+		"merge away":        callHandler(uid, map[string]any{"keep_id": twin.ID, "merge_ids": []uint{locked.ID}}, MergeTransactions).Code,
+		"merge into":        callHandler(uid, map[string]any{"keep_id": locked.ID, "merge_ids": []uint{twin.ID}}, MergeTransactions).Code,
+	}
+	for name, code := range checks {
+		if code != http.StatusConflict {
+			t.Errorf("%s: want 409, got %d", name, code)
+		}
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("id IN ? AND category_id = ?", []uint{locked.ID, twin.ID}, f.food.ID).Count(&live)
+	if live != 2 {
+		t.Errorf("both rows should be untouched, %d left", live)
+	}
+}
+
+// Rows without account_id belong to the default account's sessions and no
+// other's, and stay with the session's account when the default moves.
+func TestReconciliation_DefaultAccountTakesRowsWithoutAccount(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	def, _ := defaultAccount(database.DB, uid)
+	w := callHandler(uid, map[string]any{"name": "Wallet", "type": "cash"}, CreateAccount)
+	var wallet struct {
+		Account models.Account `json:"account"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &wallet)
+	addExpense(t, uid, f.food.ID, 30, utcDay(2026, 5, 2)) // no account_id
+	var legacy models.Transaction
+	database.DB.Where("user_id = ?", uid).First(&legacy)
+
+	v := decodeReconciliation(t, callHandler(uid, map[string]any{"account_id": wallet.Account.ID, "statement_date": "2026-05-15", "statement_balance": 0}, CreateReconciliation).Body.Bytes())
+	if len(v.Transactions) != 0 {
+		t.Errorf("the wallet session should not list the default account's rows: %d", len(v.Transactions))
+	}
+	callHandlerParam(uid, catParam(v.Reconciliation.ID), DeleteReconciliation)
+
+	rec := openSession(t, uid, def.ID, "2026-05-15", -30)
+	if v := decodeReconciliation(t, callHandlerParam(uid, catParam(rec.ID), GetReconciliation).Body.Bytes()); len(v.Transactions) != 1 || v.Transactions[0].ID != legacy.ID {
+		t.Fatalf("the default session should list the row without an account: %+v", v.Transactions)
+	}
+	clearAndLock(t, uid, rec, legacy.ID)
+
+	if w := callHandlerParamBody(uid, catParam(wallet.Account.ID), map[string]any{"is_default": true}, UpdateAccount); w.Code != http.StatusOK {
+		t.Fatalf("switch default: %d %s", w.Code, w.Body.String())
+	}
+	v = decodeReconciliation(t, callHandlerParam(uid, catParam(rec.ID), GetReconciliation).Body.Bytes())
+	if v.ClearedBalance != -30 || v.Difference != 0 {
+		t.Errorf("the locked session must not lose its row to the new default: cleared %.2f, difference %.2f", v.ClearedBalance, v.Difference)
+	}
+	if got := accountBalancesAsOf(t, uid, "2026-05-31"); got["Wallet"] != 0 || got[defaultAccountName] != -30 {
+		t.Errorf("balances after the switch: %v", got)
+	}
+}
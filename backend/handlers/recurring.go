@@ -0,0 +1,615 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Recurring transactions ───────────────────────────────────────────────────
+//
+// A RecurringTransaction is a template; the scheduler below turns each due
+// occurrence into a normal transaction. Exactly-once posting rests on two
+// guarantees:
+//
+//  1. Claiming an occurrence is a conditional UPDATE ("… WHERE next_date =
+//     <the occurrence>") in the same DB transaction as the insert, so two
+//     runs racing on one template cannot both advance it.
+//  2. transactions (recurring_id, date) is unique; an occurrence that already
+//     exists is skipped rather than re-inserted.
+//
+// next_date is persisted, so after downtime the first run simply catches up on
+// everything that fell due in between.
+
+const (
+	recurringSchedulerInterval = 15 * time.Minute
+	// maxRecurringCatchUp bounds one run per template (a daily template left
+	// alone for years). The remainder is posted on the following runs.
+	maxRecurringCatchUp  = 400
+	maxRecurringInterval = 365
+)
+
+var recurringFrequencies = map[string]bool{"daily": true, "weekly": true, "monthly": true}
+
+var errRecurringClaimed = errors.New("occurrence already claimed")
+
+// lastBusinessDayOf returns the last Monday–Friday of the month.
+func lastBusinessDayOf(y int, m time.Month) time.Time {
+	d := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// monthlyOccurrence is the template's occurrence within the given month.
+func monthlyOccurrence(r *models.RecurringTransaction, y int, m time.Month) time.Time {
+	if r.LastBusinessDay {
+		return lastBusinessDayOf(y, m)
+	}
+	day := r.DayOfMonth
+	if day == 0 {
+		day = r.StartDate.Day()
+	}
+	if last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+		day = last // "31st" → last day of shorter months
+	}
+	return time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+}
+
+// firstRecurringOccurrence is the earliest occurrence on or after StartDate.
+func firstRecurringOccurrence(r *models.RecurringTransaction) time.Time {
+	start := toDateOnly(r.StartDate)
+	if r.Frequency != "monthly" {
+		return start
+	}
+	occ := monthlyOccurrence(r, start.Year(), start.Month())
+	if occ.Before(start) {
+		m := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		occ = monthlyOccurrence(r, m.Year(), m.Month())
+	}
+	return occ
+}
+
+// nextRecurringOccurrence is the occurrence following d. Monthly steps are
+// computed from the month, not from d's day, so a 31st clamped to Feb 28 goes
+// back to the 31st in March.
+func nextRecurringOccurrence(r *models.RecurringTransaction, d time.Time) time.Time {
+	switch r.Frequency {
+	case "daily":
+		return d.AddDate(0, 0, r.Interval)
+	case "weekly":
+		return d.AddDate(0, 0, 7*r.Interval)
+	default:
+		m := time.Date(d.Year(), d.Month()+time.Month(r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		return monthlyOccurrence(r, m.Year(), m.Month())
+	}
+}
+
+// recurringEnded reports whether occurrence d would fall outside the series,
+// given that `posted` occurrences already exist.
+func recurringEnded(r *models.RecurringTransaction, d time.Time, posted int) bool {
+	if r.Count > 0 && posted >= r.Count {
+		return true
+	}
+	return r.EndDate != nil && d.After(*r.EndDate)
+}
+
+// recurringNextDate returns the first occurrence strictly after `after` (or
+// the very first occurrence when after is nil), or nil when the series is over.
+func recurringNextDate(r *models.RecurringTransaction, after *time.Time) *time.Time {
+	d := firstRecurringOccurrence(r)
+	for after != nil && !d.After(*after) {
+		d = nextRecurringOccurrence(r, d)
+	}
+	if recurringEnded(r, d, r.Occurrences) {
+		return nil
+	}
+	return &d
+}
+
+// materializeRecurring posts every occurrence of r due on or before today and
+// advances r in place. Returns the number of transactions inserted.
+func materializeRecurring(r *models.RecurringTransaction, today time.Time) (int, error) {
+	loc := userLocation(r.UserID)
+	posted := 0
+	for steps := 0; steps < maxRecurringCatchUp && !r.Paused && r.NextDate != nil && !r.NextDate.After(today); steps++ {
+		occ := *r.NextDate
+		next := nextRecurringOccurrence(r, occ)
+		var nextDate *time.Time
+		if !recurringEnded(r, next, r.Occurrences+1) {
+			nextDate = &next
+		}
+
+		inserted := false
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			res := tx.Model(&models.RecurringTransaction{}).
+				Where("id = ? AND next_date = ?", r.ID, occ).
+				Updates(map[string]any{
+					"next_date":   nextDate,
+					"occurrences": gorm.Expr("occurrences + 1"),
+					"updated_at":  now,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errRecurringClaimed
+			}
+
+			var existing int64
+			if err := tx.Unscoped().Model(&models.Transaction{}).
+				Where("recurring_id = ? AND date = ?", r.ID, occ).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return nil // already posted (e.g. the schedule was edited back) — just advance
+			}
+
+			def, err := defaultAccount(tx, r.UserID)
+			if err != nil {
+				return err
+			}
+			id := r.ID
+			t := models.Transaction{
+				UserID:      r.UserID,
+				AccountID:   &def.ID,
+				CategoryID:  r.CategoryID,
+				Amount:      r.Amount,
+				Description: r.Description,
+				Date:        occ,
+				Type:        r.Type,
+				IncomeType:  "one_time",
+				RecurringID: &id,
+				CreatedAt:   importDateToCreatedAt(occ, loc),
+				UpdatedAt:   now,
+			}
+			if err := tx.Create(&t).Error; err != nil {
+				return err
+			}
+			inserted = true
+			return nil
+		})
+		if errors.Is(err, errRecurringClaimed) {
+			return posted, nil // another run got there first; it owns the rest
+		}
+		if err != nil {
+			return posted, err
+		}
+		r.NextDate = nextDate
+		r.Occurrences++
+		if inserted {
+			posted++
+		}
+	}
+	return posted, nil
+}
+
+// runRecurringScheduler posts everything due as of now and invalidates the
+// cycle cache once per affected user. Returns the number of instances posted.
+// "Due" is judged by each user's own calendar day, so an occurrence posts at
+// the user's local midnight.
+func runRecurringScheduler(now time.Time) int {
+	// The furthest-ahead calendar day anywhere on Earth (UTC+14) bounds the
+	// candidates; each one is then checked against its owner's today.
+	latest := toDateOnly(now).AddDate(0, 0, 1)
+	var due []models.RecurringTransaction
+	if err := database.DB.
+		Where("paused = ? AND next_date IS NOT NULL AND next_date <= ?", false, latest).
+		Find(&due).Error; err != nil {
+		log.Printf("recurring scheduler: load err=%v", err)
+		return 0
+	}
+
+	total := 0
+	affected := map[uint]bool{}
+	locs := map[uint]*time.Location{}
+	for i := range due {
+		loc, ok := locs[due[i].UserID]
+		if !ok {
+			loc = userLocation(due[i].UserID)
+			locs[due[i].UserID] = loc
+		}
+		n, err := materializeRecurring(&due[i], localDate(now, loc))
+		if err != nil {
+			log.Printf("recurring scheduler: user=%v recurring=%v err=%v", due[i].UserID, due[i].ID, err)
+		}
+		if n > 0 {
+			total += n
+			affected[due[i].UserID] = true
+		}
+	}
+	for uid := range affected {
+		InvalidateCycleCache(uid)
+		ScheduleBrainResync(uid)
+	}
+	if total > 0 {
+		log.Printf("recurring scheduler: posted %d transaction(s) for %d user(s)", total, len(affected))
+	}
+	return total
+}
+
+// StartRecurringScheduler runs the scheduler once immediately — catching up on
+// anything that fell due while the server was down — and then every
+// recurringSchedulerInterval.
+func StartRecurringScheduler() {
+	go func() {
+		for {
+			runRecurringScheduler(time.Now())
+			time.Sleep(recurringSchedulerInterval)
+		}
+	}()
+}
+
+// recurringInput is shared by create (all required fields set) and update
+// (every field optional). An empty end_date clears it.
+type recurringInput struct {
+	CategoryID      *uint    `json:"category_id"`
+	Amount          *float64 `json:"amount"`
+	Description     *string  `json:"description"`
+	Type            *string  `json:"type"`
+	Frequency       *string  `json:"frequency"`
+	Interval        *int     `json:"interval"`
+	DayOfMonth      *int     `json:"day_of_month"`
+	LastBusinessDay *bool    `json:"last_business_day"`
+	StartDate       *string  `json:"start_date"`
+	EndDate         *string  `json:"end_date"`
+	Count           *int     `json:"count"`
+	Paused          *bool    `json:"paused"`
+}
+
+// apply copies the given fields onto r. It reports whether any field that
+// shapes the schedule changed; the returned error is safe to send to the client.
+func (in recurringInput) apply(uid uint, r *models.RecurringTransaction) (bool, int, error) {
+	scheduleChanged := false
+	if in.CategoryID != nil {
+		var n int64
+		if err := database.DB.Model(&models.Category{}).
+			Where("id = ? AND user_id = ?", *in.CategoryID, uid).Count(&n).Error; err != nil {
+			return false, http.StatusInternalServerError, errors.New("Failed to verify category")
+		}
+		if n == 0 {
+			return false, http.StatusNotFound, errors.New("Category not found or does not belong to you")
+		}
+		r.CategoryID = *in.CategoryID
+	}
+	if in.Amount != nil {
+		if *in.Amount <= 0 {
+			return false, http.StatusBadRequest, errors.New("Amount must be greater than 0")
+		}
+		r.Amount = *in.Amount
+	}
+	if in.Description != nil {
+		desc := strings.TrimSpace(*in.Description)
+		if len(desc) > 255 {
+			return false, http.StatusBadRequest, errors.New("Description must be 255 characters or fewer")
+		}
+		r.Description = desc
+	}
+	if in.Type != nil {
+		if *in.Type != "expense" && *in.Type != "income" {
+			return false, http.StatusBadRequest, errors.New("Invalid type. Allowed values: expense, income")
+		}
+		r.Type = *in.Type
+	}
+	if in.Frequency != nil {
+		if !recurringFrequencies[*in.Frequency] {
+			return false, http.StatusBadRequest, errors.New("Invalid frequency. Allowed values: daily, weekly, monthly")
+		}
+		scheduleChanged = scheduleChanged || r.Frequency != *in.Frequency
+		r.Frequency = *in.Frequency
+	}
+	if in.Interval != nil {
+		if *in.Interval < 1 || *in.Interval > maxRecurringInterval {
+			return false, http.StatusBadRequest, errors.New("Interval must be between 1 and 365")
+		}
+		scheduleChanged = scheduleChanged || r.Interval != *in.Interval
+		r.Interval = *in.Interval
+	}
+	if in.DayOfMonth != nil {
+		if *in.DayOfMonth < 0 || *in.DayOfMonth > 31 {
+			return false, http.StatusBadRequest, errors.New("day_of_month must be between 1 and 31 (0 = the start date's day)")
+		}
+		scheduleChanged = scheduleChanged || r.DayOfMonth != *in.DayOfMonth
+		r.DayOfMonth = *in.DayOfMonth
+	}
+	if in.LastBusinessDay != nil {
+		scheduleChanged = scheduleChanged || r.LastBusinessDay != *in.LastBusinessDay
+		r.LastBusinessDay = *in.LastBusinessDay
+	}
+	if in.StartDate != nil {
+		d, err := time.Parse("2006-01-02", *in.StartDate)
+		if err != nil {
+			return false, http.StatusBadRequest, errors.New("Invalid start_date format. Use YYYY-MM-DD")
+		}
+		scheduleChanged = scheduleChanged || !r.StartDate.Equal(d)
+		r.StartDate = d
+	}
+	if in.EndDate != nil {
+		if *in.EndDate == "" {
+			r.EndDate = nil
+		} else {
+			d, err := time.Parse("2006-01-02", *in.EndDate)
+			if err != nil {
+				return false, http.StatusBadRequest, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+			}
+			r.EndDate = &d
+		}
+		scheduleChanged = true
+	}
+	if in.Count != nil {
+		if *in.Count < 0 {
+			return false, http.StatusBadRequest, errors.New("Count cannot be negative")
+		}
+		r.Count = *in.Count
+		scheduleChanged = true
+	}
+	if in.Paused != nil {
+		r.Paused = *in.Paused
+	}
+
+	if r.LastBusinessDay && r.Frequency != "monthly" {
+		return false, http.StatusBadRequest, errors.New("last_business_day requires frequency monthly")
+	}
+	if r.EndDate != nil && r.EndDate.Before(r.StartDate) {
+		return false, http.StatusBadRequest, errors.New("end_date cannot be before start_date")
+	}
+	return scheduleChanged, http.StatusOK, nil
+}
+
+// loadRecurring loads the caller's template named by :id, writing the
+// error response itself when it fails.
+func loadRecurring(c *gin.Context, uid uint, r *models.RecurringTransaction) bool {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring transaction ID format"})
+		return false
+	}
+	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", uint(id), uid).First(r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recurring transaction not found or does not belong to you"})
+		} else {
+			log.Printf("recurring fetch: user=%v id=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring transaction"})
+		}
+		return false
+	}
+	return true
+}
+
+// postDueNow materializes r immediately so a template whose start date is
+// today or in the past shows its instances without waiting for the next tick.
+func postDueNow(uid uint, r *models.RecurringTransaction) int {
+	n, err := materializeRecurring(r, localDate(time.Now(), userLocation(uid)))
+	if err != nil {
+		log.Printf("recurring post: user=%v recurring=%v err=%v", uid, r.ID, err)
+	}
+	if n > 0 {
+		InvalidateCycleCache(uid)
+		ScheduleBrainResync(uid)
+	}
+	return n
+}
+
+// CreateRecurring → POST /api/recurring
+func CreateRecurring(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input recurringInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.CategoryID == nil || input.Amount == nil || input.Frequency == nil || input.StartDate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id, amount, frequency and start_date are required"})
+		return
+	}
+
+	r := models.RecurringTransaction{UserID: uid, Type: "expense", Interval: 1}
+	if _, status, err := input.apply(uid, &r); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	r.NextDate = recurringNextDate(&r, nil)
+	r.CreatedAt, r.UpdatedAt = time.Now(), time.Now()
+
+	if err := database.DB.Create(&r).Error; err != nil {
+		log.Printf("create recurring: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring transaction"})
+		return
+	}
+	posted := postDueNow(uid, &r)
+	database.DB.Preload("Category").First(&r, r.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Recurring transaction created successfully", "recurring": r, "posted": posted})
+}
+
+// GetRecurring → GET /api/recurring
+func GetRecurring(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var list []models.RecurringTransaction
+	if err := database.DB.Preload("Category").Where("user_id = ?", userID).Order("id asc").Find(&list).Error; err != nil {
+		log.Printf("get recurring: user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recurring": list})
+}
+
+// GetRecurringByID → GET /api/recurring/:id
+// One template, with the ETag a later update or delete can send as If-Match.
+func GetRecurringByID(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var r models.RecurringTransaction
+	if !loadRecurring(c, userID.(uint), &r) {
+		return
+	}
+	c.Header("ETag", recurringETag(r))
+	c.JSON(http.StatusOK, gin.H{"recurring": r})
+}
+
+// UpdateRecurring → PUT /api/recurring/:id
+//
+// Already-posted instances are never touched. A schedule change re-plans from
+// the last posted occurrence, so nothing is posted twice. Un-pausing skips the
+// occurrences that fell due while paused instead of back-filling them.
+func UpdateRecurring(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var r models.RecurringTransaction
+	if !loadRecurring(c, uid, &r) {
+		return
+	}
+	if etag := recurringETag(r); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"recurring": r})
+		return
+	}
+	seen := r.UpdatedAt
+	wasPaused := r.Paused
+
+	var input recurringInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scheduleChanged, status, err := input.apply(uid, &r)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if scheduleChanged {
+		var last models.Transaction
+		if err := database.DB.Unscoped().Where("recurring_id = ?", r.ID).
+			Order("date desc").Limit(1).Find(&last).Error; err != nil {
+			log.Printf("update recurring: last posted user=%v id=%v err=%v", uid, r.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring transaction"})
+			return
+		}
+		var after *time.Time
+		if last.ID != 0 {
+			after = &last.Date
+		}
+		r.NextDate = recurringNextDate(&r, after)
+	}
+	if wasPaused && !r.Paused {
+		today := localDate(time.Now(), userLocation(uid))
+		for r.NextDate != nil && r.NextDate.Before(today) {
+			next := nextRecurringOccurrence(&r, *r.NextDate)
+			r.NextDate = &next
+			if recurringEnded(&r, next, r.Occurrences) {
+				r.NextDate = nil
+			}
+		}
+	}
+	r.UpdatedAt = time.Now()
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.RecurringTransaction{}, r.ID, seen); err != nil {
+			return err
+		}
+		return tx.Omit("Category").Save(&r).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Recurring transaction not found or does not belong to you", func() (string, gin.H, error) {
+			return currentRecurring(uid, r.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update recurring: user=%v id=%v err=%v", uid, r.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring transaction"})
+		return
+	}
+	posted := postDueNow(uid, &r)
+	database.DB.Preload("Category").First(&r, r.ID)
+
+	c.Header("ETag", recurringETag(r))
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring transaction updated successfully", "recurring": r, "posted": posted})
+}
+
+// DeleteRecurring → DELETE /api/recurring/:id
+// Stops the series. Instances already posted stay as ordinary transactions.
+func DeleteRecurring(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring transaction ID format"})
+		return
+	}
+	// Posted instances stay as ordinary transactions. They are detached first
+	// (trash included): a reused template id must not find them and skip its
+	// own occurrences as already posted.
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var r models.RecurringTransaction
+		if err := tx.Where("id = ? AND user_id = ?", uint(id), userID).First(&r).Error; err != nil {
+			return err
+		}
+		if ifMatchFails(c, recurringETag(r)) {
+			return errStale
+		}
+		if err := claimIfMatch(c, tx, &models.RecurringTransaction{}, r.ID, r.UpdatedAt); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Transaction{}).
+			Where("recurring_id = ? AND user_id = ?", r.ID, userID).
+			UpdateColumn("recurring_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&r).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring transaction not found or does not belong to you"})
+		return
+	}
+	if errors.Is(err, errStale) {
+		respondStale(c, "Recurring transaction not found or does not belong to you", func() (string, gin.H, error) {
+			return currentRecurring(userID.(uint), uint(id))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete recurring: user=%v id=%v err=%v", userID, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring transaction deleted successfully"})
+}
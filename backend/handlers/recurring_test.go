@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// callHandlerParamBody invokes a handler with both path params and a JSON body
+// (PUT/PATCH-style endpoints).
+func callHandlerParamBody(uid uint, params gin.Params, body any, h gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(body)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", &buf)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("userID", uid)
+	h(c)
+	return w
+}
+
+func utcDay(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+func TestRecurringSchedule(t *testing.T) {
+	walk := func(r models.RecurringTransaction, n int) []string {
+		var out []string
+		d := firstRecurringOccurrence(&r)
+		for i := 0; i < n; i++ {
+			out = append(out, d.Format("2006-01-02"))
+			d = nextRecurringOccurrence(&r, d)
+		}
+		return out
+	}
+	cases := []struct {
+		name string
+		r    models.RecurringTransaction
+		want []string
+	}{
+		{"31st clamps and recovers", models.RecurringTransaction{Frequency: "monthly", Interval: 1, DayOfMonth: 31, StartDate: utcDay(2026, 1, 1)},
+			[]string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"}},
+		{"day before start rolls to next month", models.RecurringTransaction{Frequency: "monthly", Interval: 1, DayOfMonth: 5, StartDate: utcDay(2026, 1, 20)},
+			[]string{"2026-02-05", "2026-03-05"}},
+		{"last business day", models.RecurringTransaction{Frequency: "monthly", Interval: 1, LastBusinessDay: true, StartDate: utcDay(2026, 1, 1)},
+			[]string{"2026-01-30", "2026-02-27", "2026-03-31", "2026-04-30", "2026-05-29"}},
+		{"every second month", models.RecurringTransaction{Frequency: "monthly", Interval: 2, StartDate: utcDay(2026, 11, 15)},
+			[]string{"2026-11-15", "2027-01-15", "2027-03-15"}},
+		{"biweekly", models.RecurringTransaction{Frequency: "weekly", Interval: 2, StartDate: utcDay(2026, 3, 2)},
+			[]string{"2026-03-02", "2026-03-16", "2026-03-30"}},
+	}
+	for _, tc := range cases {
+		got := walk(tc.r, len(tc.want))
+		for i := range tc.want {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+				break
+			}
+		}
+	}
+}
+
+func seedRecurringUser(t *testing.T) (models.User, models.Category) {
+	t.Helper()
+	setupFlowDB(t)
+	user := models.User{Username: "renter", Password: "x"}
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Housing"}
+	database.DB.Create(&cat)
+	return user, cat
+}
+
+func countRecurringInstances(uid uint) int64 {
+	var n int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ? AND recurring_id IS NOT NULL", uid).Count(&n)
+	return n
+}
+
+// A template starting in the past catches up on creation; further scheduler
+// runs (a restart, a second tick) post nothing new, and Count ends the series.
+func TestRecurring_CatchUpPostsExactlyOnce(t *testing.T) {
+	user, cat := seedRecurringUser(t)
+	start := toDateOnly(time.Now()).AddDate(0, 0, -9)
+
+	w := callHandler(user.ID, map[string]any{
+		"category_id": cat.ID, "amount": 3.5, "description": "Coffee",
+		"frequency": "daily", "interval": 3, "start_date": start.Format("2006-01-02"), "count": 5,
+	}, CreateRecurring)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Recurring models.RecurringTransaction `json:"recurring"`
+		Posted    int                         `json:"posted"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Posted != 4 { // start-9, -6, -3, today
+		t.Fatalf("want 4 catch-up instances, got %d", resp.Posted)
+	}
+
+	if n := runRecurringScheduler(time.Now()); n != 0 {
+		t.Errorf("second run must not repost, posted %d", n)
+	}
+	if n := runRecurringScheduler(time.Now().AddDate(0, 0, 30)); n != 1 {
+		t.Errorf("only the 5th (count-limited) occurrence is left, posted %d", n)
+	}
+	if n := countRecurringInstances(user.ID); n != 5 {
+		t.Errorf("want 5 instances in total, got %d", n)
+	}
+	var r models.RecurringTransaction
+	database.DB.First(&r, resp.Recurring.ID)
+	if r.NextDate != nil || r.Occurrences != 5 {
+		t.Errorf("series should be finished: next=%v occurrences=%d", r.NextDate, r.Occurrences)
+	}
+
+	var tx models.Transaction
+	database.DB.Where("user_id = ?", user.ID).Order("date asc").First(&tx)
+	if !toDateOnly(tx.CreatedAt).Equal(start) {
+		t.Errorf("created_at should follow the occurrence date %v, got %v", start, tx.CreatedAt)
+	}
+}
+
+// Editing the schedule re-plans from the last posted occurrence and never
+// re-posts a date that already has an instance.
+func TestRecurring_ScheduleEditDoesNotRepost(t *testing.T) {
+	user, cat := seedRecurringUser(t)
+	today := toDateOnly(time.Now())
+
+	w := callHandler(user.ID, map[string]any{
+		"category_id": cat.ID, "amount": 10, "frequency": "weekly",
+		"start_date": today.AddDate(0, 0, -14).Format("2006-01-02"),
+	}, CreateRecurring)
+	var resp struct {
+		Recurring models.RecurringTransaction `json:"recurring"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if n := countRecurringInstances(user.ID); n != 3 {
+		t.Fatalf("want 3 weekly instances, got %d", n)
+	}
+
+	id := gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(resp.Recurring.ID), 10)}}
+	w = callHandlerParamBody(user.ID, id, map[string]any{"frequency": "daily"}, UpdateRecurring)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	if n := countRecurringInstances(user.ID); n != 3 {
+		t.Errorf("switching to daily must not back-fill, got %d instances", n)
+	}
+	var r models.RecurringTransaction
+	database.DB.First(&r, resp.Recurring.ID)
+	if r.NextDate == nil || !r.NextDate.Equal(today.AddDate(0, 0, 1)) {
+		t.Errorf("next occurrence should be tomorrow, got %v", r.NextDate)
+	}
+}
+
+func TestRecurring_RejectsBadInput(t *testing.T) {
+	user, cat := seedRecurringUser(t)
+	other := models.Category{UserID: user.ID + 1, Name: "Not mine"}
+	database.DB.Create(&other)
+
+	base := func() map[string]any {
+		return map[string]any{"category_id": cat.ID, "amount": 1, "frequency": "monthly", "start_date": "2026-01-01"}
+	}
+	cases := map[string]func(map[string]any){
+		"missing frequency":   func(b map[string]any) { delete(b, "frequency") },
+		"bad frequency":       func(b map[string]any) { b["frequency"] = "yearly" },
+		"zero interval":       func(b map[string]any) { b["interval"] = 0 },
+		"end before start":    func(b map[string]any) { b["end_date"] = "2025-12-31" },
+		"weekly last bday":    func(b map[string]any) { b["frequency"], b["last_business_day"] = "weekly", true },
+		"foreign category":    func(b map[string]any) { b["category_id"] = other.ID },
+		"non-positive amount": func(b map[string]any) { b["amount"] = -5 },
+	}
+	for name, mutate := range cases {
+		b := base()
+		mutate(b)
+		if w := callHandler(user.ID, b, CreateRecurring); w.Code < 400 {
+			t.Errorf("%s: want an error, got %d", name, w.Code)
+		}
+	}
+}
+
+// Deleting a template detaches what it posted, so a new template — even one
+// that gets the old id back — posts its own occurrences.
+func TestRecurring_DeleteDetachesInstances(t *testing.T) {
+	user, cat := seedRecurringUser(t)
+	start := toDateOnly(time.Now()).AddDate(0, 0, -2)
+	create := map[string]any{
+		"category_id": cat.ID, "amount": 5, "frequency": "daily", "start_date": start.Format("2006-01-02"),
+	}
+
+	w := callHandler(user.ID, create, CreateRecurring)
+	var resp struct {
+		Recurring models.RecurringTransaction `json:"recurring"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	oldID := resp.Recurring.ID
+	var first models.Transaction
+	database.DB.Where("recurring_id = ?", oldID).First(&first)
+	database.DB.Delete(&first) // one instance already in the trash
+
+	id := gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(oldID), 10)}}
+	if w := callHandlerParam(user.ID, id, DeleteRecurring); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	var attached int64
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("recurring_id = ?", oldID).Count(&attached)
+	if attached != 0 {
+		t.Fatalf("posted instances should be detached, %d still point at the template", attached)
+	}
+
+	// The replacement reuses the id, as SQLite may do for a deleted row.
+	again := models.RecurringTransaction{ID: oldID, UserID: user.ID, CategoryID: cat.ID, Amount: 7, Type: "expense",
+		Frequency: "daily", Interval: 1, StartDate: start}
+	again.NextDate = recurringNextDate(&again, nil)
+	database.DB.Create(&again)
+	if n, err := materializeRecurring(&again, toDateOnly(time.Now())); err != nil || n != 3 {
+		t.Errorf("the new template should post its 3 occurrences, posted %d (%v)", n, err)
+	}
+}
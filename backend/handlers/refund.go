@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Refunds ──────────────────────────────────────────────────────────────────
+//
+// A "refund" row gives back part or all of an earlier expense (RefundOfID). It
+// is never income: it sits in one of the original's categories and counts as
+// negative spend there. Cycle-window code, which windows by created_at, nets a
+// refund against the cycle its original fell in; calendar reports (period
+// summaries, the PDF) show it on its own date. Refunds are in the original's
+// currency and never exceed what is left of it.
+
+const txTypeRefund = "refund"
+
+// refundedTotal sums the live refunds of originalID in the original's
+// currency, leaving out exceptID (the refund being edited, or 0).
+func refundedTotal(db *gorm.DB, originalID, exceptID uint) (float64, error) {
+	var total float64
+	err := db.Model(&models.Transaction{}).
+		Where("refund_of_id = ? AND type = ? AND id <> ?", originalID, txTypeRefund, exceptID).
+		Select("COALESCE(SUM(COALESCE(original_amount, amount)), 0)").Scan(&total).Error
+	return total, err
+}
+
+// hasLiveRefunds reports whether any of the given transactions has a live
+// refund pointing at it.
+func hasLiveRefunds(db *gorm.DB, ids []uint) (bool, error) {
+	var n int64
+	err := db.Model(&models.Transaction{}).
+		Where("refund_of_id IN ? AND type = ?", ids, txTypeRefund).Count(&n).Error
+	return n > 0, err
+}
+
+// errHasRefunds is returned when a change would orphan or outgrow refunds.
+var errHasRefunds = errors.New("The expense has refunds — delete them first")
+
+// loadRefundable loads the live expense a refund points at, with its split
+// lines. The error is safe to send to the client with the returned status.
+func loadRefundable(db *gorm.DB, uid, id uint) (models.Transaction, int, error) {
+	var original models.Transaction
+	if err := db.Preload("Splits").Where("id = ? AND user_id = ?", id, uid).First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return original, http.StatusNotFound, errors.New("Original transaction not found or does not belong to you")
+		}
+		return original, http.StatusInternalServerError, errors.New("Failed to fetch the original transaction")
+	}
+	if original.Type != "expense" {
+		return original, http.StatusBadRequest, errors.New("Only expenses can be refunded")
+	}
+	return original, 0, nil
+}
+
+// refundCategory picks the category a refund nets against: categoryID when
+// it is one the original spent in, the original's own (largest-line) category
+// when categoryID is 0.
+func refundCategory(original models.Transaction, categoryID uint) (uint, error) {
+	if categoryID == 0 {
+		return original.CategoryID, nil
+	}
+	for _, l := range txCategoryLines(original) {
+		if l.CategoryID == categoryID {
+			return categoryID, nil
+		}
+	}
+	return 0, errors.New("A refund must go to one of the original expense's categories")
+}
+
+// checkRefundAmount rejects a refund of amount (in the original's currency)
+// that would take the original's refunds past its own amount. exceptID is the
+// refund being edited, or 0.
+func checkRefundAmount(db *gorm.DB, original models.Transaction, amount float64, exceptID uint) (int, error) {
+	refunded, err := refundedTotal(db, original.ID, exceptID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to check earlier refunds")
+	}
+	left := round2(txOriginalAmount(original) - refunded)
+	if round2(amount) > left {
+		return http.StatusBadRequest, fmt.Errorf("Refunds would exceed the original expense (%.2f left to refund)", left)
+	}
+	return 0, nil
+}
+
+// withRefunds returns txs plus, for every expense among them, each of its
+// live refunds as a negative expense line in the refund's category, stamped
+// with the original's created_at so window filters keep it in the original's
+// cycle. Refund rows already in txs are left for callers to skip by type.
+func withRefunds(db *gorm.DB, txs []models.Transaction) ([]models.Transaction, error) {
+	created := map[uint]models.Transaction{}
+	ids := make([]uint, 0)
+	for _, t := range txs {
+		if t.Type == "expense" && t.ID != 0 {
+			created[t.ID] = t
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return txs, nil
+	}
+	var refunds []models.Transaction
+	if err := db.Where("refund_of_id IN ? AND type = ?", ids, txTypeRefund).Find(&refunds).Error; err != nil {
+		return txs, err
+	}
+	out := txs
+	for _, r := range refunds {
+		line := r
+		line.Type = "expense"
+		line.Amount = -r.Amount
+		line.CreatedAt = created[*r.RefundOfID].CreatedAt
+		line.Splits = nil
+		out = append(out, line)
+	}
+	return out, nil
+}
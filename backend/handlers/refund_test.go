@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// Partial refunds net against the original's category as negative spend,
+// never as income, and can neither exceed the expense nor outlive it.
+func TestRefunds(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	now := time.Now()
+	today := now.UTC().Format("2006-01-02")
+	cycle := models.SalaryCycle{UserID: uid, TotalIncome: 1000, SavingsPct: 10, CycleStartAt: now.AddDate(0, 0, -2)}
+	database.DB.Create(&cycle)
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 1000, Type: "income", Date: now, CreatedAt: now})
+	salary := models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 5, Type: "income", Date: now, CreatedAt: now}
+	database.DB.Create(&salary)
+	shoes := models.Transaction{UserID: uid, CategoryID: f.beauty.ID, Amount: 100, Type: "expense", Date: toDateOnly(now), CreatedAt: now}
+	database.DB.Create(&shoes)
+
+	refund := func(amount float64, of uint) int {
+		body := map[string]any{"type": "refund", "refund_of_id": of, "amount": amount, "date": today, "description": "Returned"}
+		return callHandler(uid, body, CreateTransaction).Code
+	}
+	if code := refund(10, salary.ID); code != http.StatusBadRequest {
+		t.Errorf("refunding income: want 400, got %d", code)
+	}
+	if code := refund(30, shoes.ID); code != http.StatusCreated {
+		t.Fatalf("first partial refund: got %d", code)
+	}
+	if code := refund(80, shoes.ID); code != http.StatusBadRequest {
+		t.Errorf("refunding more than is left: want 400, got %d", code)
+	}
+	if code := refund(20, shoes.ID); code != http.StatusCreated {
+		t.Fatalf("second partial refund: got %d", code)
+	}
+
+	s := computeCycleStats(uid, cycle)
+	if s.CycleIncome != 1005 || s.CycleExpenses != 50 || s.DynamicSavings != 100.5 {
+		t.Errorf("refunds are not income: income %.2f, expenses %.2f, savings %.2f", s.CycleIncome, s.CycleExpenses, s.DynamicSavings)
+	}
+
+	w := callHandlerGET(uid, "begin_date="+today+"&end_date="+today, GetPeriodSummary)
+	var summary struct {
+		Summary []struct {
+			Category    struct{ ID uint } `json:"category"`
+			TotalAmount float64           `json:"total_amount"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("summary: %d %s", w.Code, w.Body.String())
+	}
+	beauty := -1.0
+	for _, row := range summary.Summary {
+		if row.Category.ID == f.beauty.ID {
+			beauty = row.TotalAmount
+		}
+	}
+	if beauty != 50 {
+		t.Errorf("refunds should net in the original's category: %.2f", beauty)
+	}
+
+	if w := callHandlerParamBody(uid, txParam(shoes.ID), map[string]any{"amount": 40}, UpdateTransaction); w.Code != http.StatusConflict {
+		t.Errorf("shrinking below the refunds: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, txParam(shoes.ID), DeleteTransaction); w.Code != http.StatusConflict {
+		t.Errorf("deleting a refunded expense: want 409, got %d", w.Code)
+	}
+}
+
+// seedRefundable stores a 100.00 expense on 2026-05-10 and returns it.
+func seedRefundable(t *testing.T, f splitFixture) models.Transaction {
+	t.Helper()
+	shoes := models.Transaction{UserID: f.user.ID, CategoryID: f.beauty.ID, Amount: 100, Type: "expense", Date: utcDay(2026, 5, 10), CreatedAt: utcDay(2026, 5, 10)}
+	database.DB.Create(&shoes)
+	return shoes
+}
+
+func createRefund(t *testing.T, uid, of uint, amount float64, date string) (int, models.Transaction) {
+	t.Helper()
+	w := callHandler(uid, map[string]any{"type": "refund", "refund_of_id": of, "amount": amount, "date": date}, CreateTransaction)
+	var resp struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Transaction
+}
+
+// The cap covers edits too: a refund can grow only into what the other
+// refunds leave over.
+func TestRefund_CapAppliesToEdits(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	shoes := seedRefundable(t, f)
+	_, first := createRefund(t, uid, shoes.ID, 60, "2026-05-12")
+	if code, _ := createRefund(t, uid, shoes.ID, 40.01, "2026-05-12"); code != http.StatusBadRequest {
+		t.Errorf("one cent over the cap: want 400, got %d", code)
+	}
+	code, second := createRefund(t, uid, shoes.ID, 40, "2026-05-12")
+	if code != http.StatusCreated {
+		t.Fatalf("the rest of the expense: got %d", code)
+	}
+	if w := callHandlerParamBody(uid, txParam(first.ID), map[string]any{"amount": 61}, UpdateTransaction); w.Code != http.StatusBadRequest {
+		t.Errorf("growing a refund past the cap: want 400, got %d", w.Code)
+	}
+	if w := callHandlerParamBody(uid, txParam(second.ID), map[string]any{"amount": 30}, UpdateTransaction); w.Code != http.StatusOK {
+		t.Fatalf("shrinking a refund: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParamBody(uid, txParam(first.ID), map[string]any{"amount": 70}, UpdateTransaction); w.Code != http.StatusOK {
+		t.Errorf("growing into the freed amount: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// Money cannot come back before it was spent, on create or on edit.
+func TestRefund_CannotPredateTheOriginal(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	shoes := seedRefundable(t, f)
+	if code, _ := createRefund(t, uid, shoes.ID, 10, "2026-05-09"); code != http.StatusBadRequest {
+		t.Errorf("refund the day before: want 400, got %d", code)
+	}
+	code, refund := createRefund(t, uid, shoes.ID, 10, "2026-05-10")
+	if code != http.StatusCreated {
+		t.Fatalf("refund on the same day: got %d", code)
+	}
+	if w := callHandlerParamBody(uid, txParam(refund.ID), map[string]any{"date": "2026-05-01"}, UpdateTransaction); w.Code != http.StatusBadRequest {
+		t.Errorf("moving the refund before the original: want 400, got %d", w.Code)
+	}
+}
+
+// An expense with live refunds cannot be deleted; once they are gone it can.
+func TestRefund_ProtectsTheOriginalFromDeletion(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	shoes := seedRefundable(t, f)
+	_, refund := createRefund(t, uid, shoes.ID, 25, "2026-05-11")
+	if w := callHandlerParam(uid, txParam(shoes.ID), DeleteTransaction); w.Code != http.StatusConflict {
+		t.Errorf("deleting a refunded expense: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, txParam(refund.ID), DeleteTransaction); w.Code != http.StatusOK {
+		t.Fatalf("delete the refund: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, txParam(shoes.ID), DeleteTransaction); w.Code != http.StatusOK {
+		t.Errorf("deleting the expense once its refund is trashed: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// A trashed refund only comes back after the expense it refunds.
+func TestRefund_RestoreNeedsTheOriginal(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	shoes := seedRefundable(t, f)
+	_, refund := createRefund(t, uid, shoes.ID, 25, "2026-05-11")
+	callHandlerParam(uid, txParam(refund.ID), DeleteTransaction)
+	callHandlerParam(uid, txParam(shoes.ID), DeleteTransaction)
+
+	if w := callHandlerParam(uid, txParam(refund.ID), RestoreTransaction); w.Code != http.StatusConflict {
+		t.Errorf("restoring a refund of a trashed expense: want 409, got %d", w.Code)
+	}
+	if w := callHandlerParam(uid, txParam(shoes.ID), RestoreTransaction); w.Code != http.StatusOK {
+		t.Fatalf("restore the expense: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandlerParam(uid, txParam(refund.ID), RestoreTransaction); w.Code != http.StatusOK {
+		t.Errorf("restore the refund after its expense: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"math"
 	"net/http"
@@ -125,24 +126,54 @@ type CycleStats struct {
 	CurrentWeekAllowance float64 `json:"current_week_allowance"`
 	CurrentWeekSpent     float64 `json:"current_week_spent"`
 	Rollover             float64 `json:"rollover"`
+
+	// Needs / Wants tracking by category bucket. Spent = the fixed expenses
+	// declared for that bucket + variable lines in categories of that bucket;
+	// Remaining is measured against NeedsLimit / WantsLimit and the weekly
+	// allowance spreads it over the days left in the cycle.
+	NeedsSpent           float64 `json:"needs_spent"`
+	NeedsRemaining       float64 `json:"needs_remaining"`
+	NeedsWeeklyAllowance float64 `json:"needs_weekly_allowance"`
+	WantsSpent           float64 `json:"wants_spent"`
+	WantsRemaining       float64 `json:"wants_remaining"`
+	WantsWeeklyAllowance float64 `json:"wants_weekly_allowance"`
+	// Variable spending in "savings" categories, and in categories with no
+	// bucket yet (so the client can nudge the user to classify them).
+	SavingsBucketSpent float64 `json:"savings_bucket_spent"`
+	UnclassifiedSpent  float64 `json:"unclassified_spent"`
+
+	// Envelope state for the cycle window — only for users in envelope mode.
+	Envelopes *envelopeState `json:"envelopes,omitempty"`
 }
 
 // computeCycleStats builds the full CycleStats for one salary cycle.
 // It reads ONLY live (soft-delete-safe) transactions from the DB and performs
 // all arithmetic here, so React never needs to do client-side date math.
+// Day and week boundaries are the user's local midnights.
 func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
-	since := cycle.CycleStartAt
-
-	// Load all live transactions in the cycle window.
-	// Upper bound = next_payday_at when set (inclusive) — prevents pre/post
-	// cycle data from polluting the rollover calculation.
+	now := userNow(uid)
+	loc := now.Location()
+	startDate := toDateOnly(cycle.CycleStartAt)
+	window := cycleSpendWindow(cycle, now)
+
+	// Load all live transactions in the cycle window: from the start of the
+	// first day through the end of payday, when set — prevents pre/post cycle
+	// data from polluting the rollover calculation.
 	var txs []models.Transaction
-	q := database.DB.Where("user_id = ? AND created_at >= ?", uid, since)
+	q := database.DB.Where("user_id = ? AND created_at >= ?", uid, window.From)
 	if cycle.NextPaydayAt != nil {
-		q = q.Where("created_at <= ?", *cycle.NextPaydayAt)
+		q = q.Where("created_at < ?", window.To)
+	}
+	q.Preload("Splits").Find(&txs) // GORM v2: deleted_at IS NULL added automatically
+	// Refunds net against the cycle of the expense they give back for.
+	if withRefs, err := withRefunds(database.DB, txs); err != nil {
+		log.Printf("cycle stats: refunds user=%v err=%v", uid, err)
+	} else {
+		txs = withRefs
 	}
-	q.Find(&txs) // GORM v2: deleted_at IS NULL added automatically
 
+	buckets := bucketsByCategory(uid)
+	var spend bucketSpend
 	var income, expenses, fixedExp, variableExp float64
 	for _, tx := range txs {
 		// Savings-pool transactions are tracked separately, never mixed into
@@ -157,10 +188,14 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 			income += tx.Amount
 		case "expense":
 			expenses += tx.Amount
-			if cycle.FixedExpCategoryID > 0 && tx.CategoryID == cycle.FixedExpCategoryID {
-				fixedExp += tx.Amount
-			} else {
-				variableExp += tx.Amount
+			// Per category line: a split receipt can be partly fixed, partly variable.
+			for _, l := range txCategoryLines(tx) {
+				if cycle.FixedExpCategoryID > 0 && l.CategoryID == cycle.FixedExpCategoryID {
+					fixedExp += l.Amount
+				} else {
+					variableExp += l.Amount
+					spend.add(buckets[l.CategoryID], l.Amount)
+				}
 			}
 		}
 	}
@@ -180,7 +215,7 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 			Where("user_id = ? AND category_id = ?", uid, cycle.SavedMoneyCategoryID).
 			Find(&pool)
 		for _, p := range pool {
-			if p.Type == "income" || p.Type == "savings_deposit" {
+			if p.Type == "income" || p.Type == "savings_deposit" || p.Type == txTypeRefund {
 				savedMoneyBalance += p.Amount
 			} else {
 				savedMoneyBalance -= p.Amount
@@ -194,20 +229,19 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 		Where("user_id = ? AND type IN ('income', 'savings_deposit')", uid).
 		Select("COALESCE(SUM(amount), 0)").Scan(&allIncome)
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type IN ('expense', 'savings_withdrawal')", uid).
-		Select("COALESCE(SUM(amount), 0)").Scan(&allExpense)
+		Where("user_id = ? AND type IN ('expense', 'savings_withdrawal', 'refund')", uid).
+		Select("COALESCE(SUM(CASE WHEN type = 'refund' THEN -amount ELSE amount END), 0)").Scan(&allExpense)
 	previousSavings := (allIncome - allExpense) - (income - expenses)
 
-	// Cycle timing
-	now := time.Now()
+	// Cycle timing, in calendar days of the user's zone.
 	daysTotal := 30
 	if cycle.NextPaydayAt != nil {
-		d := int(cycle.NextPaydayAt.Sub(cycle.CycleStartAt).Hours() / 24)
+		d := daysBetween(startDate, toDateOnly(*cycle.NextPaydayAt))
 		if d >= 7 {
 			daysTotal = d
 		}
 	}
-	daysElapsed := int(now.Sub(cycle.CycleStartAt).Hours() / 24)
+	daysElapsed := daysBetween(startDate, localDate(now, loc))
 	if daysElapsed < 0 {
 		daysElapsed = 0
 	}
@@ -221,17 +255,18 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 		baseWeekly = variableAllowance / float64(daysTotal) * 7
 	}
 
-	// Rolling cycle-weeks: 7-day chunks from cycle_start_at.
+	// Rolling cycle-weeks: 7-day chunks from the cycle's first local midnight.
 	// Surplus / deficit from completed weeks carry into the next week's limit.
+	weekStart := func(w int) time.Time { return dayStartIn(startDate.AddDate(0, 0, w*7), loc) }
 	currentWeekIndex := daysElapsed / 7
 	rollover := 0.0
 	for w := 0; w < currentWeekIndex; w++ {
-		wFrom := cycle.CycleStartAt.AddDate(0, 0, w*7)
-		wTo := cycle.CycleStartAt.AddDate(0, 0, (w+1)*7)
+		wFrom := weekStart(w)
+		wTo := weekStart(w + 1)
 		rollover += baseWeekly - sumVariableInRange(txs, cycle.FixedExpCategoryID, cycle.SavedMoneyCategoryID, wFrom, wTo)
 	}
-	currentWeekFrom := cycle.CycleStartAt.AddDate(0, 0, currentWeekIndex*7)
-	currentWeekTo := currentWeekFrom.AddDate(0, 0, 7)
+	currentWeekFrom := weekStart(currentWeekIndex)
+	currentWeekTo := weekStart(currentWeekIndex + 1)
 	currentWeekSpent := sumVariableInRange(txs, cycle.FixedExpCategoryID, cycle.SavedMoneyCategoryID, currentWeekFrom, currentWeekTo)
 	currentWeekAllowance := baseWeekly + rollover
 
@@ -250,7 +285,10 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 		currentWeekAllowance = 0
 	}
 
-	return CycleStats{
+	needsSpent := cycle.FixedNeedsTotal + spend.Needs
+	wantsSpent := cycle.FixedWantsTotal + spend.Wants
+
+	stats := CycleStats{
 		CycleIncome:            income,
 		CycleExpenses:          expenses,
 		CycleFixedExpenses:     fixedExp,
@@ -268,25 +306,45 @@ func computeCycleStats(uid uint, cycle models.SalaryCycle) CycleStats {
 		CurrentWeekAllowance:   currentWeekAllowance,
 		CurrentWeekSpent:       currentWeekSpent,
 		Rollover:               rollover,
+		NeedsSpent:             needsSpent,
+		NeedsRemaining:         cycle.NeedsLimit - needsSpent,
+		NeedsWeeklyAllowance:   weeklyShare(cycle.NeedsLimit-needsSpent, daysRemaining),
+		WantsSpent:             wantsSpent,
+		WantsRemaining:         cycle.WantsLimit - wantsSpent,
+		WantsWeeklyAllowance:   weeklyShare(cycle.WantsLimit-wantsSpent, daysRemaining),
+		SavingsBucketSpent:     spend.Savings,
+		UnclassifiedSpent:      spend.Unclassified,
+	}
+
+	var mode string
+	database.DB.Model(&models.User{}).Where("id = ?", uid).Select("budget_mode").Scan(&mode)
+	if mode == budgetModeEnvelope {
+		st, err := computeEnvelopeState(database.DB, uid, window)
+		if err != nil {
+			log.Printf("cycle stats: envelopes user=%v err=%v", uid, err)
+		} else {
+			stats.Envelopes = &st
+		}
 	}
+	return stats
 }
 
-// sumVariableInRange sums variable expenses in [from, to), excluding the
+// sumVariableInRange sums variable expense lines in [from, to), excluding the
 // fixed-payments category AND the savings-pool category.
 func sumVariableInRange(txs []models.Transaction, fixedCatID uint, savedMoneyCatID uint, from, to time.Time) float64 {
 	var sum float64
 	for _, tx := range txs {
-		if tx.Type != "expense" {
+		if tx.Type != "expense" || tx.CreatedAt.Before(from) || !tx.CreatedAt.Before(to) {
 			continue
 		}
-		if fixedCatID > 0 && tx.CategoryID == fixedCatID {
-			continue
-		}
-		if savedMoneyCatID > 0 && tx.CategoryID == savedMoneyCatID {
-			continue
-		}
-		if !tx.CreatedAt.Before(from) && tx.CreatedAt.Before(to) {
-			sum += tx.Amount
+		for _, l := range txCategoryLines(tx) {
+			if fixedCatID > 0 && l.CategoryID == fixedCatID {
+				continue
+			}
+			if savedMoneyCatID > 0 && l.CategoryID == savedMoneyCatID {
+				continue
+			}
+			sum += l.Amount
 		}
 	}
 	return sum
@@ -331,21 +389,26 @@ func StartSalaryCycle(c *gin.Context) {
 		return
 	}
 
-	var receivedAt time.Time
+	// The payday is a calendar date — today in the user's zone unless given.
+	loc := userLocation(uid)
+	payday := localDate(time.Now(), loc)
 	if req.ReceivedAtDate != "" {
 		parsed, err := time.Parse("2006-01-02", req.ReceivedAtDate)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid received_at_date format. Use YYYY-MM-DD"})
 			return
 		}
-		y, m, d := parsed.Date()
-		receivedAt = time.Date(y, m, d, 12, 0, 0, 0, time.Local)
-	} else {
-		receivedAt = time.Now()
+		payday = parsed
 	}
 
-	cycleStart := receivedAt
-	txDate := receivedAt.Truncate(24 * time.Hour)
+	// cycleStart anchors the cycle's first calendar day (read back with
+	// toDateOnly); receivedAt is when the money arrived — noon of that day in
+	// the user's zone — and stamps the provisioned transactions and the cycle
+	// row's created_at.
+	y, m, d := payday.Date()
+	cycleStart := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	receivedAt := time.Date(y, m, d, 12, 0, 0, 0, loc)
+	txDate := payday
 
 	totalIncome := req.BaseSalary + req.Bonuses
 	fw := ComputeBudgetFramework(totalIncome, req.NeedsPct, req.WantsPct, req.SavingsPct, req.FixedExpenses)
@@ -496,6 +559,11 @@ func StartSalaryCycle(c *gin.Context) {
 		if err := tx.Create(&cycle).Error; err != nil {
 			return err
 		}
+		// Every posting below goes to the default account.
+		def, err := defaultAccount(tx, uid)
+		if err != nil {
+			return err
+		}
 
 		// Close any still-open-ended prior cycle so the newly created cycle is the
 		// sole one covering today. GetCurrentSalaryCycle returns the EARLIEST
@@ -536,7 +604,7 @@ func StartSalaryCycle(c *gin.Context) {
 			Where("LOWER(name) IN ('income','доход','дохід','einkommen','salary')").
 			First(&incomeCat).Error; err != nil {
 			if err2 := tx.Where("user_id = ?", uid).First(&incomeCat).Error; err2 != nil {
-				incomeCat = models.Category{UserID: uid, Name: "Income", TranslationKey: "category.income", CreatedAt: receivedAt, UpdatedAt: receivedAt}
+				incomeCat = models.Category{UserID: uid, Name: "Income", TranslationKey: "category.income", Bucket: bucketIgnore, CreatedAt: receivedAt, UpdatedAt: receivedAt}
 				if err3 := tx.Create(&incomeCat).Error; err3 != nil {
 					return err3
 				}
@@ -554,7 +622,7 @@ func StartSalaryCycle(c *gin.Context) {
 			}
 		}
 		if !found {
-			fixedCat = models.Category{UserID: uid, Name: fixedCatName, TranslationKey: "category.fixed_payments", CreatedAt: receivedAt, UpdatedAt: receivedAt}
+			fixedCat = models.Category{UserID: uid, Name: fixedCatName, TranslationKey: "category.fixed_payments", Bucket: bucketNeed, CreatedAt: receivedAt, UpdatedAt: receivedAt}
 			if err := tx.Create(&fixedCat).Error; err != nil {
 				return err
 			}
@@ -575,7 +643,7 @@ func StartSalaryCycle(c *gin.Context) {
 			}
 		}
 		if !savedFound {
-			savedCat = models.Category{UserID: uid, Name: savedCatName, TranslationKey: "category.saved_money", CreatedAt: receivedAt, UpdatedAt: receivedAt}
+			savedCat = models.Category{UserID: uid, Name: savedCatName, TranslationKey: "category.saved_money", Bucket: bucketSavings, CreatedAt: receivedAt, UpdatedAt: receivedAt}
 			if err := tx.Create(&savedCat).Error; err != nil {
 				return err
 			}
@@ -593,8 +661,12 @@ func StartSalaryCycle(c *gin.Context) {
 			// Re-query previous cycle's transactions (closed window)
 			var prevTxs []models.Transaction
 			tx.Where("user_id = ? AND created_at >= ? AND created_at < ?",
-				uid, prevCycle.CycleStartAt, cycleStart).
+				uid, dayStartIn(toDateOnly(prevCycle.CycleStartAt), loc), dayStartIn(payday, loc)).
 				Find(&prevTxs)
+			prevTxs, err := withRefunds(tx, prevTxs)
+			if err != nil {
+				return err
+			}
 
 			var prevIncome, prevFixed, prevVariable float64
 			for _, pt := range prevTxs {
@@ -627,6 +699,7 @@ func StartSalaryCycle(c *gin.Context) {
 				}
 				savingsTx := models.Transaction{
 					UserID:      uid,
+					AccountID:   &def.ID,
 					CategoryID:  savedCat.ID,
 					Amount:      math.Abs(remainingBalance),
 					Description: transferDesc,
@@ -645,6 +718,7 @@ func StartSalaryCycle(c *gin.Context) {
 		// ── Income transaction ─────────────────────────────────────────────
 		incomeTxn := models.Transaction{
 			UserID:      uid,
+			AccountID:   &def.ID,
 			CategoryID:  incomeCat.ID,
 			Amount:      totalIncome,
 			Description: "Salary",
@@ -668,6 +742,7 @@ func StartSalaryCycle(c *gin.Context) {
 			savingsAmount := totalIncome * req.SavingsPct / 100
 			autoSavingsTx := models.Transaction{
 				UserID:      uid,
+				AccountID:   &def.ID,
 				CategoryID:  savedCat.ID,
 				Amount:      savingsAmount,
 				Description: "Planned savings allocation",
@@ -693,6 +768,7 @@ func StartSalaryCycle(c *gin.Context) {
 			}
 			expTx := models.Transaction{
 				UserID:      uid,
+				AccountID:   &def.ID,
 				CategoryID:  fixedCat.ID,
 				Amount:      fe.Amount,
 				Description: desc,
@@ -754,6 +830,7 @@ func GetCurrentSalaryCycle(c *gin.Context) {
 
 	// Fast path: serve the cached payload when fresh (invalidated on any write).
 	if cached, ok := getCachedCycle(uid); ok {
+		setCycleETag(c, cached)
 		c.JSON(http.StatusOK, cached)
 		return
 	}
@@ -784,7 +861,7 @@ func GetCurrentSalaryCycle(c *gin.Context) {
 		return
 	}
 
-	today := toDateOnly(time.Now())
+	today := localDate(time.Now(), userLocation(uid))
 	var activeCycle *models.SalaryCycle
 	hasActive := false
 	for i := range allCycles {
@@ -846,6 +923,7 @@ func GetCurrentSalaryCycle(c *gin.Context) {
 		"resumable_cycle":  resumable, // nil → JSON null when nothing is resumable
 	}
 	setCachedCycle(uid, payload)
+	setCycleETag(c, payload)
 	c.JSON(http.StatusOK, payload)
 }
 
@@ -918,31 +996,33 @@ func validateCycleEnd(cycle models.SalaryCycle, newEnd time.Time, lastTx *time.T
 	return ""
 }
 
-// latestTxInCycle returns the created_at of the most recent live transaction
-// that belongs to the cycle window [start, nextCycleStart), or nil if none.
+// latestTxInCycle returns the local calendar day of the most recent live
+// transaction that belongs to the cycle window [start, nextCycleStart), or nil
+// if none.
 func latestTxInCycle(uid uint, cycle models.SalaryCycle, allCycles []models.SalaryCycle) *time.Time {
-	start := cycle.CycleStartAt
+	loc := userLocation(uid)
+	start := toDateOnly(cycle.CycleStartAt)
 	q := database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND created_at >= ?", uid, start)
+		Where("user_id = ? AND created_at >= ?", uid, dayStartIn(start, loc))
 	// Cap at the next cycle's start so we only consider this cycle's own tx.
 	var upper *time.Time
 	for _, other := range allCycles {
 		if other.ID == cycle.ID {
 			continue
 		}
-		if other.CycleStartAt.After(start) && (upper == nil || other.CycleStartAt.Before(*upper)) {
-			cs := other.CycleStartAt
-			upper = &cs
+		if os := toDateOnly(other.CycleStartAt); os.After(start) && (upper == nil || os.Before(*upper)) {
+			upper = &os
 		}
 	}
 	if upper != nil {
-		q = q.Where("created_at < ?", *upper)
+		q = q.Where("created_at < ?", dayStartIn(*upper, loc))
 	}
 	var latest models.Transaction
 	if err := q.Order("created_at DESC").First(&latest).Error; err != nil {
 		return nil // no transaction in the window (or not found)
 	}
-	return &latest.CreatedAt
+	day := localDate(latest.CreatedAt, loc)
+	return &day
 }
 
 func validationMessage(code string) string {
@@ -1011,7 +1091,7 @@ func UpdateCycleNextPayday(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cycles"})
 		return
 	}
-	today := toDateOnly(time.Now())
+	today := localDate(time.Now(), userLocation(uid))
 	var cycle *models.SalaryCycle
 	for i := range allCycles {
 		if isDateInCycleWindow(today, allCycles[i]) {
@@ -1023,6 +1103,11 @@ func UpdateCycleNextPayday(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": validationMessage(errNoActiveCycle), "code": errNoActiveCycle})
 		return
 	}
+	// A preview writes nothing, so only the real change is conditional.
+	if etag := cycleETag(*cycle); !req.Preview && ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"cycle": *cycle})
+		return
+	}
 
 	lastTx := latestTxInCycle(uid, *cycle, allCycles)
 	minEnd, maxEnd := cycleEndBounds(*cycle, allCycles, lastTx)
@@ -1044,8 +1129,9 @@ func UpdateCycleNextPayday(c *gin.Context) {
 	projected := computeCycleStats(uid, preview)
 
 	txInWindow := int64(0)
+	previewWindow := cycleSpendWindow(preview, userNow(uid))
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND created_at >= ? AND created_at <= ?", uid, cycle.CycleStartAt, newDate).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", uid, previewWindow.From, previewWindow.To).
 		Count(&txInWindow)
 
 	if req.Preview {
@@ -1063,6 +1149,7 @@ func UpdateCycleNextPayday(c *gin.Context) {
 
 	// Idempotent no-op when unchanged — no write, no audit.
 	if cycle.NextPaydayAt != nil && toDateOnly(*cycle.NextPaydayAt).Equal(toDateOnly(newDate)) {
+		c.Header("ETag", cycleETag(*cycle))
 		c.JSON(http.StatusOK, gin.H{
 			"message":        "Next payday unchanged",
 			"next_payday_at": newDate.Format("2006-01-02"),
@@ -1079,12 +1166,21 @@ func UpdateCycleNextPayday(c *gin.Context) {
 		oldEnd = toDateOnly(*cycle.NextPaydayAt).Format("2006-01-02")
 	}
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.SalaryCycle{}, cycle.ID, cycle.UpdatedAt); err != nil {
+			return err
+		}
 		if err := tx.Model(&models.SalaryCycle{}).Where("id = ?", cycle.ID).
 			Updates(map[string]any{"next_payday_at": newDate, "updated_at": now}).Error; err != nil {
 			return err
 		}
 		return writeCycleAudit(tx, uid, cycle.ID, "next_payday_at", oldEnd, newDate.Format("2006-01-02"), now)
 	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Cycle not found or access denied", func() (string, gin.H, error) {
+			return currentCycle(uid, cycle.ID)
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("patch cycle payday: apply user=%v err=%v", uid, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update next payday"})
@@ -1099,6 +1195,8 @@ func UpdateCycleNextPayday(c *gin.Context) {
 
 	freshCycle := *cycle
 	freshCycle.NextPaydayAt = &newDate
+	freshCycle.UpdatedAt = now
+	c.Header("ETag", cycleETag(freshCycle))
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "Next payday updated",
 		"next_payday_at": newDate.Format("2006-01-02"),
@@ -1128,7 +1226,7 @@ func StopSalaryCycle(c *gin.Context) {
 		return
 	}
 
-	today := toDateOnly(time.Now())
+	today := localDate(time.Now(), userLocation(uid))
 	var active *models.SalaryCycle
 	for i := range cycles {
 		if isDateInCycleWindow(today, cycles[i]) { // already excludes stopped cycles
@@ -1182,10 +1280,11 @@ func windowCoversToday(cycle models.SalaryCycle, today time.Time) bool {
 // it is treated as gone and must NOT be offered for resume — resuming it would
 // revive an empty/inconsistent cycle (income €0 but €X of cycle info).
 func cycleHasLiveIncome(uid uint, cycle models.SalaryCycle) bool {
+	w := cycleSpendWindow(cycle, userNow(uid))
 	q := database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type = ? AND created_at >= ?", uid, "income", cycle.CycleStartAt)
+		Where("user_id = ? AND type = ? AND created_at >= ?", uid, "income", w.From)
 	if cycle.NextPaydayAt != nil {
-		q = q.Where("created_at <= ?", *cycle.NextPaydayAt)
+		q = q.Where("created_at < ?", w.To)
 	}
 	var n int64
 	q.Count(&n)
@@ -1234,7 +1333,7 @@ func ResumeSalaryCycle(c *gin.Context) {
 		return
 	}
 
-	today := toDateOnly(time.Now())
+	today := localDate(time.Now(), userLocation(uid))
 
 	// Is any (non-stopped) cycle active right now?
 	var activeNow *models.SalaryCycle
@@ -1347,7 +1446,7 @@ func AddCycleIncome(c *gin.Context) {
 		Where("LOWER(name) IN ('income','доход','дохід','einkommen','salary')").
 		First(&incomeCat).Error; err != nil {
 		if err2 := database.DB.Where("user_id = ?", uid).First(&incomeCat).Error; err2 != nil {
-			incomeCat = models.Category{UserID: uid, Name: "Income", TranslationKey: "category.income"}
+			incomeCat = models.Category{UserID: uid, Name: "Income", TranslationKey: "category.income", Bucket: bucketIgnore}
 			if err3 := database.DB.Create(&incomeCat).Error; err3 != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find income category"})
 				return
@@ -1356,7 +1455,7 @@ func AddCycleIncome(c *gin.Context) {
 	}
 
 	now := time.Now()
-	txDate := now.Truncate(24 * time.Hour)
+	txDate := localDate(now, userLocation(uid))
 	if req.Date != "" {
 		if parsed, err := time.Parse("2006-01-02", req.Date); err == nil {
 			txDate = parsed
@@ -1367,9 +1466,16 @@ func AddCycleIncome(c *gin.Context) {
 	if desc == "" {
 		desc = "Additional Income"
 	}
+	def, err := defaultAccount(database.DB, uid)
+	if err != nil {
+		log.Printf("add cycle income: default account user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add income"})
+		return
+	}
 
 	newTx := models.Transaction{
 		UserID:      uid,
+		AccountID:   &def.ID,
 		CategoryID:  incomeCat.ID,
 		Amount:      req.Amount,
 		Description: desc,
@@ -1397,7 +1503,8 @@ func AddCycleIncome(c *gin.Context) {
 // ── GetSavingsHistory (Pillar 4) ──────────────────────────────────────────────
 // GET /api/salary-cycle/savings-history
 // Returns all savings-pool transactions for the current user, together with
-// the running pool balance and the savings category ID.
+// the running pool balance and the savings category ID. ?goal_id=N narrows the
+// list and balance to one savings goal.
 
 func GetSavingsHistory(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -1418,15 +1525,22 @@ func GetSavingsHistory(c *gin.Context) {
 		return
 	}
 
+	q := database.DB.Preload("Category").
+		Where("user_id = ? AND category_id = ?", uid, cycle.SavedMoneyCategoryID)
+	if raw := c.Query("goal_id"); raw != "" {
+		goalID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal_id"})
+			return
+		}
+		q = q.Where("savings_goal_id = ?", goalID)
+	}
 	var txs []models.Transaction
-	database.DB.Preload("Category").
-		Where("user_id = ? AND category_id = ?", uid, cycle.SavedMoneyCategoryID).
-		Order("created_at DESC").
-		Find(&txs)
+	q.Order("created_at DESC").Find(&txs)
 
 	var balance float64
 	for _, tx := range txs {
-		if tx.Type == "income" || tx.Type == "savings_deposit" {
+		if tx.Type == "income" || tx.Type == "savings_deposit" || tx.Type == txTypeRefund {
 			balance += tx.Amount
 		} else {
 			balance -= tx.Amount
@@ -1473,38 +1587,67 @@ func DeleteSalaryCycle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cycle"})
 		return
 	}
+	if etag := cycleETag(cycle); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"cycle": cycle})
+		return
+	}
 
 	// 60-second window captures all auto-provisioned transactions (they all
-	// receive CreatedAt == cycleStart during the single atomic write).
-	winEnd := cycle.CycleStartAt.Add(60 * time.Second)
+	// receive the cycle row's CreatedAt — the moment the salary arrived —
+	// during the single atomic write).
+	winStart := cycle.CreatedAt
+	winEnd := winStart.Add(60 * time.Second)
 
-	// Soft-delete the auto-generated Salary income transaction.
-	database.DB.Where(
-		"user_id = ? AND created_at >= ? AND created_at <= ? AND type = 'income' AND description = 'Salary'",
-		uid, cycle.CycleStartAt, winEnd,
-	).Delete(&models.Transaction{})
+	// One transaction, so a cycle that was changed since its ETag was checked
+	// leaves everything in place.
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.SalaryCycle{}, cycle.ID, cycle.UpdatedAt); err != nil {
+			return err
+		}
 
-	// Soft-delete auto-generated fixed-expense transactions.
-	if cycle.FixedExpCategoryID > 0 {
-		database.DB.Where(
-			"user_id = ? AND created_at >= ? AND created_at <= ? AND category_id = ?",
-			uid, cycle.CycleStartAt, winEnd, cycle.FixedExpCategoryID,
-		).Delete(&models.Transaction{})
-	}
+		// Soft-delete the auto-generated Salary income transaction.
+		if err := tx.Where(
+			"user_id = ? AND created_at >= ? AND created_at <= ? AND type = 'income' AND description = 'Salary'",
+			uid, winStart, winEnd,
+		).Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
 
-	// Soft-delete auto-generated savings-transfer transactions.
-	if cycle.SavedMoneyCategoryID > 0 {
-		database.DB.Where(
-			"user_id = ? AND created_at >= ? AND created_at <= ? AND category_id = ?",
-			uid, cycle.CycleStartAt, winEnd, cycle.SavedMoneyCategoryID,
-		).Delete(&models.Transaction{})
-	}
+		// Soft-delete auto-generated fixed-expense transactions.
+		if cycle.FixedExpCategoryID > 0 {
+			if err := tx.Where(
+				"user_id = ? AND created_at >= ? AND created_at <= ? AND category_id = ?",
+				uid, winStart, winEnd, cycle.FixedExpCategoryID,
+			).Delete(&models.Transaction{}).Error; err != nil {
+				return err
+			}
+		}
 
-	// Hard-delete the FixedExpense metadata rows (no DeletedAt column).
-	database.DB.Where("salary_cycle_id = ?", cycle.ID).Delete(&models.FixedExpense{})
+		// Soft-delete auto-generated savings-transfer transactions.
+		if cycle.SavedMoneyCategoryID > 0 {
+			if err := tx.Where(
+				"user_id = ? AND created_at >= ? AND created_at <= ? AND category_id = ?",
+				uid, winStart, winEnd, cycle.SavedMoneyCategoryID,
+			).Delete(&models.Transaction{}).Error; err != nil {
+				return err
+			}
+		}
 
-	// Hard-delete the SalaryCycle row itself.
-	if err := database.DB.Delete(&cycle).Error; err != nil {
+		// Hard-delete the FixedExpense metadata rows (no DeletedAt column).
+		if err := tx.Where("salary_cycle_id = ?", cycle.ID).Delete(&models.FixedExpense{}).Error; err != nil {
+			return err
+		}
+
+		// Hard-delete the SalaryCycle row itself.
+		return tx.Delete(&cycle).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Cycle not found or access denied", func() (string, gin.H, error) {
+			return currentCycle(uid, cycleID)
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("delete cycle: remove user=%v cycle=%v err=%v", uid, cycleID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete cycle"})
 		return
@@ -1536,7 +1679,7 @@ func ensureSavingsCategory(uid uint, cycle *models.SalaryCycle) bool {
 	}
 	if savedCat.ID == 0 {
 		savedCat = models.Category{
-			UserID: uid, Name: "Saved Money", TranslationKey: "category.saved_money",
+			UserID: uid, Name: "Saved Money", TranslationKey: "category.saved_money", Bucket: bucketSavings,
 			CreatedAt: time.Now(), UpdatedAt: time.Now(),
 		}
 		if err := database.DB.Create(&savedCat).Error; err != nil {
@@ -1560,9 +1703,11 @@ func normalizeLang(raw string) string {
 	return lang
 }
 
-// toDateOnly normalises t to midnight UTC so that timezone offsets or
-// time-of-day differences can never make the same calendar day appear to be
-// in a different cycle window.
+// toDateOnly normalises a stored calendar date (cycle start, payday, a
+// transaction's Date) to midnight UTC so that time-of-day differences can never
+// make the same calendar day appear to be in a different cycle window. Such
+// dates are anchored at UTC midnight or noon; an instant (created_at, now) has
+// no calendar day of its own — use localDate with the user's zone for those.
 func toDateOnly(t time.Time) time.Time {
 	y, m, d := t.UTC().Date()
 	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
@@ -1600,7 +1745,7 @@ func findActiveCycle(uid uint) *models.SalaryCycle {
 	if len(cycles) == 0 {
 		return nil
 	}
-	today := toDateOnly(time.Now())
+	today := localDate(time.Now(), userLocation(uid))
 	for i := range cycles {
 		if isDateInCycleWindow(today, cycles[i]) {
 			return &cycles[i]
@@ -1616,6 +1761,10 @@ func findActiveCycle(uid uint) *models.SalaryCycle {
 //	amount > 0  →  deposit  (income transaction)
 //	amount < 0  →  withdrawal (expense transaction)
 //
+// "goal_id" earmarks the entry to a savings goal. Once the user has goals a
+// withdrawal must say where the money comes from — a goal, or 0 for the
+// unallocated part of the pool — and cannot take more than the goal holds.
+//
 // Returns fresh CycleStats so the dashboard updates without a full page reload.
 func AddSavingsManual(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -1629,6 +1778,7 @@ func AddSavingsManual(c *gin.Context) {
 		Amount      float64 `json:"amount"`
 		Description string  `json:"description"`
 		Date        string  `json:"date"` // YYYY-MM-DD; optional
+		GoalID      *uint   `json:"goal_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -1656,8 +1806,40 @@ func AddSavingsManual(c *gin.Context) {
 		txAmount = -txAmount
 	}
 
+	var goalID *uint
+	if req.GoalID != nil && *req.GoalID > 0 {
+		goal, err := loadSavingsGoal(uid, *req.GoalID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found or does not belong to you"})
+			return
+		}
+		goalID = &goal.ID
+	}
+	if txType == "savings_withdrawal" {
+		if req.GoalID == nil {
+			var goals int64
+			database.DB.Model(&models.SavingsGoal{}).Where("user_id = ?", uid).Count(&goals)
+			if goals > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "goal_id is required for withdrawals (0 for unallocated savings)"})
+				return
+			}
+		}
+		if goalID != nil {
+			saved, err := goalSaved(uid, *goalID)
+			if err != nil {
+				log.Printf("add savings manual: goal balance user=%v goal=%v err=%v", uid, *goalID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add savings entry"})
+				return
+			}
+			if txAmount > saved {
+				c.JSON(http.StatusConflict, gin.H{"error": "The goal only holds " + strconv.FormatFloat(max(saved, 0), 'f', 2, 64)})
+				return
+			}
+		}
+	}
+
 	now := time.Now()
-	txDate := now.Truncate(24 * time.Hour)
+	txDate := localDate(now, userLocation(uid))
 	if req.Date != "" {
 		if parsed, err := time.Parse("2006-01-02", req.Date); err == nil {
 			txDate = parsed
@@ -1668,17 +1850,25 @@ func AddSavingsManual(c *gin.Context) {
 	if desc == "" {
 		desc = "Manual savings transfer"
 	}
+	def, err := defaultAccount(database.DB, uid)
+	if err != nil {
+		log.Printf("add savings manual: default account user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add savings entry"})
+		return
+	}
 
 	newTx := models.Transaction{
-		UserID:      uid,
-		CategoryID:  cycle.SavedMoneyCategoryID,
-		Amount:      txAmount,
-		Description: desc,
-		Date:        txDate,
-		Type:        txType,
-		IncomeType:  "one_time",
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		UserID:        uid,
+		AccountID:     &def.ID,
+		CategoryID:    cycle.SavedMoneyCategoryID,
+		Amount:        txAmount,
+		Description:   desc,
+		Date:          txDate,
+		Type:          txType,
+		IncomeType:    "one_time",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		SavingsGoalID: goalID,
 	}
 	if err := database.DB.Create(&newTx).Error; err != nil {
 		log.Printf("add savings manual: user=%v err=%v", uid, err)
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Savings goals ────────────────────────────────────────────────────────────
+//
+// A goal's balance is the net of the savings-pool transactions earmarked to it
+// (POST /salary-cycle/savings with "goal_id"). Progress is projected from the
+// recent contribution rate, and the contribution needed per period is spread
+// over the periods left until the deadline — a period being the length of the
+// active salary cycle, or a 30-day month for users without one.
+
+const (
+	// goalRateWindowDays is how far back contributions count towards the
+	// current contribution rate.
+	goalRateWindowDays = 90
+	// defaultGoalPeriodDays is the period length without a dated salary cycle.
+	defaultGoalPeriodDays = 30
+)
+
+// savingsGoalProgress is a goal together with its derived figures.
+type savingsGoalProgress struct {
+	models.SavingsGoal
+	Saved       float64 `json:"saved"`
+	Remaining   float64 `json:"remaining"`
+	ProgressPct float64 `json:"progress_pct"`
+	Completed   bool    `json:"completed"`
+	// PeriodDays is the length of one contribution period (the salary cycle).
+	PeriodDays int `json:"period_days"`
+	// RatePerPeriod is the average net contribution per period over the last
+	// goalRateWindowDays (or since the goal was created, if later).
+	RatePerPeriod float64 `json:"rate_per_period"`
+	// ProjectedCompletion is when Remaining reaches zero at RatePerPeriod;
+	// nil when the goal is complete or nothing is being contributed.
+	ProjectedCompletion *time.Time `json:"projected_completion"`
+	// RequiredPerPeriod is what each remaining period must contribute to meet
+	// the deadline; nil without a deadline. Past the deadline it is the whole
+	// remainder and Overdue is set.
+	RequiredPerPeriod *float64 `json:"required_per_period"`
+	PeriodsLeft       int      `json:"periods_left"`
+	Overdue           bool     `json:"overdue"`
+}
+
+// savingsTxSign is +1 for money going into the pool and -1 for money leaving it.
+func savingsTxSign(txType string) float64 {
+	if txType == "income" || txType == "savings_deposit" {
+		return 1
+	}
+	return -1
+}
+
+// goalPeriodDays is the contribution period for uid: the active cycle's length
+// when it has a payday, otherwise a 30-day month.
+func goalPeriodDays(uid uint) int {
+	cycle := findActiveCycle(uid)
+	if cycle == nil || cycle.NextPaydayAt == nil {
+		return defaultGoalPeriodDays
+	}
+	days := daysBetween(toDateOnly(cycle.CycleStartAt), toDateOnly(*cycle.NextPaydayAt))
+	if days < 1 {
+		return defaultGoalPeriodDays
+	}
+	return days
+}
+
+// computeGoalProgress derives a goal's figures from its earmarked transactions.
+// Days are counted in now's location.
+func computeGoalProgress(goal models.SavingsGoal, txs []models.Transaction, periodDays int, now time.Time) savingsGoalProgress {
+	p := savingsGoalProgress{SavingsGoal: goal, PeriodDays: periodDays}
+	loc := now.Location()
+	today := localDate(now, loc)
+
+	rateFrom := today.AddDate(0, 0, -goalRateWindowDays)
+	if created := localDate(goal.CreatedAt, loc); created.After(rateFrom) {
+		rateFrom = created
+	}
+	var recent float64
+	for _, tx := range txs {
+		amount := savingsTxSign(tx.Type) * tx.Amount
+		p.Saved += amount
+		if !tx.Date.Before(rateFrom) {
+			recent += amount
+		}
+	}
+	// A young goal is measured over at least one period, so a single deposit
+	// reads as one period's contribution rather than a daily rate.
+	elapsed := max(daysBetween(rateFrom, today), periodDays)
+	ratePerDay := recent / float64(elapsed)
+
+	p.Saved = round2(p.Saved)
+	p.Remaining = round2(math.Max(goal.TargetAmount-p.Saved, 0))
+	p.RatePerPeriod = round2(ratePerDay * float64(periodDays))
+	if goal.TargetAmount > 0 {
+		p.ProgressPct = round2(math.Min(p.Saved/goal.TargetAmount*100, 100))
+	}
+	p.Completed = p.Remaining == 0
+	if p.Completed {
+		return p
+	}
+
+	if ratePerDay > 0 {
+		at := today.AddDate(0, 0, int(math.Ceil(p.Remaining/ratePerDay)))
+		p.ProjectedCompletion = &at
+	}
+	if goal.Deadline != nil {
+		daysLeft := daysBetween(today, toDateOnly(*goal.Deadline))
+		required := p.Remaining
+		if daysLeft <= 0 {
+			p.Overdue = true
+		} else {
+			p.PeriodsLeft = (daysLeft + periodDays - 1) / periodDays
+			required = round2(p.Remaining / float64(p.PeriodsLeft))
+		}
+		p.RequiredPerPeriod = &required
+	}
+	return p
+}
+
+// loadGoalTransactions returns the user's live transactions earmarked to any
+// goal, grouped by goal ID.
+func loadGoalTransactions(uid uint) (map[uint][]models.Transaction, error) {
+	var txs []models.Transaction
+	if err := database.DB.Where("user_id = ? AND savings_goal_id IS NOT NULL", uid).Find(&txs).Error; err != nil {
+		return nil, err
+	}
+	byGoal := make(map[uint][]models.Transaction)
+	for _, tx := range txs {
+		byGoal[*tx.SavingsGoalID] = append(byGoal[*tx.SavingsGoalID], tx)
+	}
+	return byGoal, nil
+}
+
+// goalSaved is the current balance of one goal.
+func goalSaved(uid, goalID uint) (float64, error) {
+	var txs []models.Transaction
+	if err := database.DB.Where("user_id = ? AND savings_goal_id = ?", uid, goalID).Find(&txs).Error; err != nil {
+		return 0, err
+	}
+	var saved float64
+	for _, tx := range txs {
+		saved += savingsTxSign(tx.Type) * tx.Amount
+	}
+	return round2(saved), nil
+}
+
+func loadSavingsGoal(uid, id uint) (models.SavingsGoal, error) {
+	var goal models.SavingsGoal
+	err := database.DB.Where("id = ? AND user_id = ?", id, uid).First(&goal).Error
+	return goal, err
+}
+
+// staleSavingsGoal answers a write on goal id whose If-Match is out of date:
+// 412 with the goal's current progress.
+func staleSavingsGoal(c *gin.Context, uid, id uint) {
+	respondStale(c, "Savings goal not found or does not belong to you", func() (string, gin.H, error) {
+		return currentSavingsGoal(uid, id)
+	})
+}
+
+// parseGoalDeadline accepts "YYYY-MM-DD", or "YYYY-MM" meaning the last day
+// of that month.
+func parseGoalDeadline(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01", raw); err == nil {
+		return t.AddDate(0, 1, -1), nil
+	}
+	return time.Time{}, errors.New("Invalid deadline. Use YYYY-MM-DD or YYYY-MM")
+}
+
+// savingsGoalName validates a goal name.
+func savingsGoalName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" || len(name) > 100 {
+		return "", errors.New("Goal name must be 1–100 characters")
+	}
+	return name, nil
+}
+
+// GetSavingsGoals → GET /api/savings-goals
+// Every goal with its progress, plus the part of the savings pool that is not
+// earmarked to any goal.
+func GetSavingsGoals(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var goals []models.SavingsGoal
+	if err := database.DB.Where("user_id = ?", uid).Order("deadline IS NULL, deadline asc, name asc").Find(&goals).Error; err != nil {
+		log.Printf("get savings goals: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch savings goals"})
+		return
+	}
+	byGoal, err := loadGoalTransactions(uid)
+	if err != nil {
+		log.Printf("get savings goals: txs user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch savings goals"})
+		return
+	}
+
+	periodDays := goalPeriodDays(uid)
+	now := userNow(uid)
+	out := make([]savingsGoalProgress, 0, len(goals))
+	var allocated float64
+	for _, g := range goals {
+		p := computeGoalProgress(g, byGoal[g.ID], periodDays, now)
+		allocated += p.Saved
+		out = append(out, p)
+	}
+
+	var pool float64
+	if cycle := findActiveCycle(uid); cycle != nil && cycle.SavedMoneyCategoryID > 0 {
+		var txs []models.Transaction
+		database.DB.Where("user_id = ? AND category_id = ?", uid, cycle.SavedMoneyCategoryID).Find(&txs)
+		for _, tx := range txs {
+			pool += savingsTxSign(tx.Type) * tx.Amount
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goals":       out,
+		"pool":        round2(pool),
+		"unallocated": round2(pool - allocated),
+		"period_days": periodDays,
+	})
+}
+
+func CreateSavingsGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		Name         string  `json:"name" binding:"required"`
+		TargetAmount float64 `json:"target_amount" binding:"required,gt=0"`
+		Deadline     string  `json:"deadline"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := savingsGoalName(input.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	goal := models.SavingsGoal{UserID: uid, Name: name, TargetAmount: round2(input.TargetAmount), CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if input.Deadline != "" {
+		d, err := parseGoalDeadline(input.Deadline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		goal.Deadline = &d
+	}
+	var n int64
+	database.DB.Model(&models.SavingsGoal{}).Where("user_id = ? AND name = ?", uid, name).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A savings goal with this name already exists"})
+		return
+	}
+
+	if err := database.DB.Create(&goal).Error; err != nil {
+		log.Printf("create savings goal: user=%v err=%v", uid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create savings goal"})
+		return
+	}
+
+	p := computeGoalProgress(goal, nil, goalPeriodDays(uid), userNow(uid))
+	c.JSON(http.StatusCreated, gin.H{"message": "Savings goal created successfully", "goal": p})
+}
+
+// GetSavingsGoal → GET /api/savings-goals/:id
+// One goal with its progress, and the ETag a later update or delete can send
+// as If-Match.
+func GetSavingsGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID format"})
+		return
+	}
+	etag, body, err := currentSavingsGoal(uid, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found or does not belong to you"})
+		} else {
+			log.Printf("get savings goal: user=%v goal=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch savings goal"})
+		}
+		return
+	}
+
+	c.Header("ETag", etag)
+	c.JSON(http.StatusOK, body)
+}
+
+// UpdateSavingsGoal changes name, target and/or deadline; "deadline": ""
+// removes the deadline.
+func UpdateSavingsGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID format"})
+		return
+	}
+	goal, err := loadSavingsGoal(uid, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found or does not belong to you"})
+		} else {
+			log.Printf("update savings goal fetch: user=%v goal=%v err=%v", uid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch savings goal"})
+		}
+		return
+	}
+	if ifMatchFails(c, savingsGoalETag(goal)) {
+		staleSavingsGoal(c, uid, goal.ID)
+		return
+	}
+	seen := goal.UpdatedAt
+
+	var input struct {
+		Name         *string  `json:"name"`
+		TargetAmount *float64 `json:"target_amount"`
+		Deadline     *string  `json:"deadline"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != nil {
+		name, err := savingsGoalName(*input.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var n int64
+		database.DB.Model(&models.SavingsGoal{}).Where("user_id = ? AND name = ? AND id <> ?", uid, name, goal.ID).Count(&n)
+		if n > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A savings goal with this name already exists"})
+			return
+		}
+		goal.Name = name
+	}
+	if input.TargetAmount != nil {
+		if *input.TargetAmount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target amount must be positive"})
+			return
+		}
+		goal.TargetAmount = round2(*input.TargetAmount)
+	}
+	if input.Deadline != nil {
+		if *input.Deadline == "" {
+			goal.Deadline = nil
+		} else {
+			d, err := parseGoalDeadline(*input.Deadline)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			goal.Deadline = &d
+		}
+	}
+	goal.UpdatedAt = time.Now()
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.SavingsGoal{}, goal.ID, seen); err != nil {
+			return err
+		}
+		return tx.Save(&goal).Error
+	})
+	if errors.Is(err, errStale) {
+		staleSavingsGoal(c, uid, goal.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("update savings goal save: user=%v goal=%v err=%v", uid, goal.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update savings goal"})
+		return
+	}
+
+	byGoal, _ := loadGoalTransactions(uid)
+	p := computeGoalProgress(goal, byGoal[goal.ID], goalPeriodDays(uid), userNow(uid))
+	c.Header("ETag", savingsGoalETag(goal))
+	c.JSON(http.StatusOK, gin.H{"message": "Savings goal updated successfully", "goal": p})
+}
+
+// DeleteSavingsGoal removes a goal. Its transactions stay in the pool as
+// unallocated savings.
+func DeleteSavingsGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid goal ID format"})
+		return
+	}
+
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		current, err := loadSavingsGoal(uid, uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found or does not belong to you"})
+			return
+		}
+		if ifMatchFails(c, savingsGoalETag(current)) {
+			staleSavingsGoal(c, uid, current.ID)
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.SavingsGoal{}, uint(id), seen); err != nil {
+			return err
+		}
+		res := tx.Where("id = ? AND user_id = ?", uint(id), uid).Delete(&models.SavingsGoal{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if deleted = res.RowsAffected; deleted == 0 {
+			return nil
+		}
+		// Unscoped so trashed transactions are released too and restore cleanly.
+		return tx.Unscoped().Model(&models.Transaction{}).
+			Where("user_id = ? AND savings_goal_id = ?", uid, uint(id)).
+			Update("savings_goal_id", nil).Error
+	})
+	if errors.Is(err, errStale) {
+		staleSavingsGoal(c, uid, uint(id))
+		return
+	}
+	if err != nil {
+		log.Printf("delete savings goal: user=%v goal=%v err=%v", uid, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete savings goal"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found or does not belong to you"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Savings goal deleted successfully"})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// 1200 of 3000 saved at 200 per 30-day period: 9 periods to go at that rate,
+// and 1800 over the 6 periods left before the deadline.
+func TestComputeGoalProgress(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 11, 28, 0, 0, 0, 0, time.UTC)
+	goal := models.SavingsGoal{TargetAmount: 3000, Deadline: &deadline, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	txs := []models.Transaction{
+		{Type: "savings_deposit", Amount: 700, Date: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, // before the rate window
+		{Type: "savings_deposit", Amount: 200, Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{Type: "savings_deposit", Amount: 250, Date: time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)},
+		{Type: "savings_deposit", Amount: 200, Date: time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)},
+		{Type: "savings_withdrawal", Amount: 50, Date: time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	p := computeGoalProgress(goal, txs, 30, now)
+	if p.Saved != 1300 || p.Remaining != 1700 || p.ProgressPct != 43.33 || p.Completed {
+		t.Errorf("progress: %+v", p)
+	}
+	if p.RatePerPeriod != 200 {
+		t.Errorf("want 200 per period, got %.2f", p.RatePerPeriod)
+	}
+	if p.ProjectedCompletion == nil || !p.ProjectedCompletion.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 255)) {
+		t.Errorf("projected completion: %v", p.ProjectedCompletion)
+	}
+	if p.PeriodsLeft != 6 || p.RequiredPerPeriod == nil || *p.RequiredPerPeriod != 283.33 || p.Overdue {
+		t.Errorf("required: %d periods, %v", p.PeriodsLeft, p.RequiredPerPeriod)
+	}
+
+	past := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	goal.Deadline = &past
+	if p := computeGoalProgress(goal, txs, 30, now); !p.Overdue || *p.RequiredPerPeriod != 1700 {
+		t.Errorf("past deadline: want overdue with the whole remainder, got %+v", p)
+	}
+}
+
+// Deposits are earmarked to a goal; once goals exist a withdrawal must name
+// one and cannot take more than it holds.
+func TestSavingsGoals_EarmarkedDepositsAndWithdrawals(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "saver", Password: "x"}
+	database.DB.Create(&user)
+	now := time.Now()
+	payday := now.AddDate(0, 0, 28)
+	database.DB.Create(&models.SalaryCycle{UserID: user.ID, TotalIncome: 2000, CycleStartAt: now.AddDate(0, 0, -2), NextPaydayAt: &payday})
+
+	w := callHandler(user.ID, map[string]any{"name": "Emergency fund", "target_amount": 3000, "deadline": "2099-06"}, CreateSavingsGoal)
+	var created struct {
+		Goal savingsGoalProgress `json:"goal"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create goal: %d %s", w.Code, w.Body.String())
+	}
+	goalID := created.Goal.ID
+	if d := created.Goal.Deadline; d == nil || d.Format("2006-01-02") != "2099-06-30" {
+		t.Errorf("YYYY-MM deadline should mean the end of the month, got %v", d)
+	}
+
+	for _, body := range []map[string]any{
+		{"amount": 400, "goal_id": goalID},
+		{"amount": 100}, // unallocated
+	} {
+		if w := callHandler(user.ID, body, AddSavingsManual); w.Code != http.StatusCreated {
+			t.Fatalf("deposit %v: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+	if w := callHandler(user.ID, map[string]any{"amount": -50}, AddSavingsManual); w.Code != http.StatusBadRequest {
+		t.Errorf("withdrawal without goal_id: want 400, got %d", w.Code)
+	}
+	if w := callHandler(user.ID, map[string]any{"amount": -500, "goal_id": goalID}, AddSavingsManual); w.Code != http.StatusConflict {
+		t.Errorf("withdrawing more than the goal holds: want 409, got %d", w.Code)
+	}
+	if w := callHandler(user.ID, map[string]any{"amount": -150, "goal_id": goalID}, AddSavingsManual); w.Code != http.StatusCreated {
+		t.Fatalf("goal withdrawal: %d %s", w.Code, w.Body.String())
+	}
+	if w := callHandler(user.ID, map[string]any{"amount": -20, "goal_id": 0}, AddSavingsManual); w.Code != http.StatusCreated {
+		t.Fatalf("unallocated withdrawal: %d %s", w.Code, w.Body.String())
+	}
+
+	w = callHandlerGET(user.ID, "", GetSavingsGoals)
+	var resp struct {
+		Goals       []savingsGoalProgress `json:"goals"`
+		Pool        float64               `json:"pool"`
+		Unallocated float64               `json:"unallocated"`
+		PeriodDays  int                   `json:"period_days"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Goals) != 1 || resp.Goals[0].Saved != 250 || resp.Pool != 330 || resp.Unallocated != 80 || resp.PeriodDays != 30 {
+		t.Errorf("unexpected goals response: %s", w.Body.String())
+	}
+	if g := resp.Goals[0]; g.RequiredPerPeriod == nil || g.RatePerPeriod != 250 || g.ProjectedCompletion == nil {
+		t.Errorf("goal projections missing: %+v", g)
+	}
+
+	// Deleting the goal leaves its money in the pool, unallocated.
+	if w := callHandlerParam(user.ID, catParam(goalID), DeleteSavingsGoal); w.Code != http.StatusOK {
+		t.Fatalf("delete goal: %d %s", w.Code, w.Body.String())
+	}
+	var earmarked int64
+	database.DB.Model(&models.Transaction{}).Where("savings_goal_id IS NOT NULL").Count(&earmarked)
+	if earmarked != 0 {
+		t.Errorf("want no transactions earmarked to the deleted goal, got %d", earmarked)
+	}
+}
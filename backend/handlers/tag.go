@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Tags ─────────────────────────────────────────────────────────────────────
+//
+// Tags are addressed by name everywhere a transaction is written
+// ("tags": ["vacation-2026", "business"]); unknown names are created on the
+// fly, so the client never has to pre-register a tag. The /tags endpoints
+// exist for listing, renaming and deleting them.
+
+const (
+	maxTagNameLen = 50
+	maxTagsPerTx  = 20
+)
+
+// normalizeTagName trims and lower-cases a tag name. Commas are rejected
+// because GET /transactions takes a comma-separated tag list.
+func normalizeTagName(raw string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if name == "" {
+		return "", errors.New("Tag name cannot be empty")
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLen {
+		return "", errors.New("Tag name must be " + strconv.Itoa(maxTagNameLen) + " characters or fewer")
+	}
+	if strings.Contains(name, ",") {
+		return "", errors.New("Tag name cannot contain a comma")
+	}
+	return name, nil
+}
+
+// normalizeTagNames validates and de-duplicates a transaction's tag list.
+func normalizeTagNames(raw []string) ([]string, error) {
+	if len(raw) > maxTagsPerTx {
+		return nil, errors.New("A transaction can have at most " + strconv.Itoa(maxTagsPerTx) + " tags")
+	}
+	seen := map[string]bool{}
+	names := make([]string, 0, len(raw))
+	for _, r := range raw {
+		name, err := normalizeTagName(r)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// resolveTags returns the user's tags for names, creating the missing ones
+// inside db (the caller's DB transaction).
+func resolveTags(db *gorm.DB, uid uint, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if len(names) == 0 {
+		return tags, nil
+	}
+	if err := db.Where("user_id = ? AND name IN ?", uid, names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		have[t.Name] = true
+	}
+	now := time.Now()
+	for _, name := range names {
+		if have[name] {
+			continue
+		}
+		t := models.Tag{UserID: uid, Name: name, CreatedAt: now, UpdatedAt: now}
+		if err := db.Create(&t).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// setTransactionTags replaces parent's tags with names inside db.
+func setTransactionTags(db *gorm.DB, parent *models.Transaction, names []string) error {
+	tags, err := resolveTags(db, parent.UserID, names)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(parent).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	parent.Tags = tags
+	return nil
+}
+
+func CreateTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := normalizeTagName(input.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var n int64
+	database.DB.Model(&models.Tag{}).Where("user_id = ? AND name = ?", userID, name).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists"})
+		return
+	}
+
+	tag := models.Tag{UserID: userID.(uint), Name: name, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := database.DB.Create(&tag).Error; err != nil {
+		log.Printf("create tag: user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Tag created successfully", "tag": tag})
+}
+
+func GetTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var tags []models.Tag
+	if err := database.DB.Where("user_id = ?", userID).Order("name asc").Find(&tags).Error; err != nil {
+		log.Printf("get tags: user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// GetTag → GET /api/tags/:id
+// One tag, with the ETag a later rename or delete can send as If-Match.
+func GetTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var tag models.Tag
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&tag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or does not belong to you"})
+		} else {
+			log.Printf("get tag: user=%v tag=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+		}
+		return
+	}
+
+	c.Header("ETag", tagETag(tag))
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// UpdateTag renames a tag; every transaction carrying it follows along.
+func UpdateTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var tag models.Tag
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&tag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or does not belong to you"})
+		} else {
+			log.Printf("update tag fetch: user=%v tag=%v err=%v", userID, c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+		}
+		return
+	}
+	if etag := tagETag(tag); ifMatchFails(c, etag) {
+		preconditionFailed(c, etag, gin.H{"tag": tag})
+		return
+	}
+	seen := tag.UpdatedAt
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := normalizeTagName(input.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var n int64
+	database.DB.Model(&models.Tag{}).Where("user_id = ? AND name = ? AND id <> ?", userID, name, tag.ID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists"})
+		return
+	}
+
+	tag.Name = name
+	tag.UpdatedAt = time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Tag{}, tag.ID, seen); err != nil {
+			return err
+		}
+		return tx.Save(&tag).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Tag not found or does not belong to you", func() (string, gin.H, error) {
+			return currentTag(userID.(uint), tag.ID)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update tag save: user=%v tag=%v err=%v", userID, tag.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		return
+	}
+
+	c.Header("ETag", tagETag(tag))
+	c.JSON(http.StatusOK, gin.H{"message": "Tag updated successfully", "tag": tag})
+}
+
+// DeleteTag removes the tag from every transaction, then the tag itself. The
+// transactions are untouched otherwise.
+func DeleteTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID format"})
+		return
+	}
+
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.Tag
+		if err := database.DB.Where("id = ? AND user_id = ?", uint(tagID), userID).First(&current).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or does not belong to you"})
+			return
+		}
+		if etag := tagETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"tag": current})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Tag{}, uint(tagID), seen); err != nil {
+			return err
+		}
+		res := tx.Where("id = ? AND user_id = ?", uint(tagID), userID).Delete(&models.Tag{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		if deleted == 0 {
+			return nil
+		}
+		return tx.Exec("DELETE FROM transaction_tags WHERE tag_id = ?", uint(tagID)).Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Tag not found or does not belong to you", func() (string, gin.H, error) {
+			return currentTag(userID.(uint), uint(tagID))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete tag: user=%v tag=%v err=%v", userID, tagID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found or does not belong to you"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+type tagSummaryRow struct {
+	TagID       uint    `gorm:"column:tag_id"`
+	TagName     string  `gorm:"column:tag_name"`
+	TotalAmount float64 `gorm:"column:total_amount"`
+	Count       int64   `gorm:"column:tx_count"`
+}
+
+// GetTagSummary → GET /api/summary/tags?begin_date=&end_date=[&type=]
+//
+// Totals per tag over a date range, the tag counterpart of GetPeriodSummary.
+// A transaction with several tags counts in full towards each of them, so the
+// rows are not meant to add up to the period total.
+func GetTagSummary(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	beginDateStr := c.Query("begin_date")
+	endDateStr := c.Query("end_date")
+	if beginDateStr == "" || endDateStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'begin_date' and 'end_date' are required (format YYYY-MM-DD)"})
+		return
+	}
+	parsedBeginDate, err := time.Parse("2006-01-02", beginDateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid begin_date format. Use YYYY-MM-DD"})
+		return
+	}
+	parsedEndDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+		return
+	}
+	parsedEndDate = parsedEndDate.Add(24*time.Hour - time.Second)
+
+	q := database.DB.Table("transactions").
+		Select("tags.id AS tag_id, tags.name AS tag_name, SUM(transactions.amount) AS total_amount, COUNT(*) AS tx_count").
+		Joins("JOIN transaction_tags ON transaction_tags.transaction_id = transactions.id").
+		Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+		Where("transactions.user_id = ? AND transactions.deleted_at IS NULL AND transactions.date >= ? AND transactions.date <= ?",
+			userID, parsedBeginDate, parsedEndDate)
+	if t := c.Query("type"); t != "" {
+		if !transactionTypes[t] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Allowed values: expense, income, savings_deposit, savings_withdrawal, transfer_out, transfer_in"})
+			return
+		}
+		q = q.Where("transactions.type = ?", t)
+	}
+
+	var rows []tagSummaryRow
+	if err := q.Group("tags.id, tags.name").Order("total_amount DESC").Scan(&rows).Error; err != nil {
+		log.Printf("get tag summary: user=%v begin=%v end=%v err=%v", userID, beginDateStr, endDateStr, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag summary"})
+		return
+	}
+
+	summary := []map[string]interface{}{}
+	for _, r := range rows {
+		summary = append(summary, map[string]interface{}{
+			"tag":          map[string]interface{}{"id": r.TagID, "name": r.TagName},
+			"total_amount": r.TotalAmount,
+			"count":        r.Count,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"begin_date": beginDateStr,
+		"end_date":   endDateStr,
+		"summary":    summary,
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func seedTaggedTxs(t *testing.T) (models.User, []models.Transaction) {
+	t.Helper()
+	setupFlowDB(t)
+	user := models.User{Username: "tagger", Password: "x"}
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Travel"}
+	database.DB.Create(&cat)
+
+	var txs []models.Transaction
+	for _, in := range []struct {
+		amount float64
+		tags   []string
+	}{
+		{100, []string{"Vacation-2026", " business ", "vacation-2026"}},
+		{40, []string{"vacation-2026"}},
+		{15, []string{"business", "reimbursable"}},
+		{7, nil},
+	} {
+		w := callHandler(user.ID, map[string]any{
+			"category_id": cat.ID, "amount": in.amount, "date": "2026-05-10", "type": "expense", "tags": in.tags,
+		}, CreateTransaction)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Transaction models.Transaction `json:"transaction"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		txs = append(txs, resp.Transaction)
+	}
+	return user, txs
+}
+
+// Tag names are normalised and de-duplicated, and unknown names are created
+// once per user.
+func TestTags_CreatedOnTheFly(t *testing.T) {
+	user, txs := seedTaggedTxs(t)
+	if len(txs[0].Tags) != 2 {
+		t.Errorf("want 2 distinct tags on the first transaction, got %+v", txs[0].Tags)
+	}
+	var tags []models.Tag
+	database.DB.Where("user_id = ?", user.ID).Order("name").Find(&tags)
+	if len(tags) != 3 || tags[0].Name != "business" || tags[2].Name != "vacation-2026" {
+		t.Errorf("want business/reimbursable/vacation-2026, got %+v", tags)
+	}
+}
+
+func TestTags_FilterAnyAll(t *testing.T) {
+	user, _ := seedTaggedTxs(t)
+	cases := []struct {
+		query url.Values
+		want  int64
+	}{
+		{url.Values{"tags": {"business"}}, 2},
+		{url.Values{"tags": {"business,vacation-2026"}}, 3},
+		{url.Values{"tags": {"business,vacation-2026"}, "tag_mode": {"all"}}, 1},
+		{url.Values{"tags": {"Business,REIMBURSABLE"}, "tag_mode": {"all"}}, 1},
+		{url.Values{"tags": {"nope"}}, 0},
+	}
+	for _, tc := range cases {
+		if p := getTxPage(t, user.ID, tc.query); p.Total != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.query.Encode(), tc.want, p.Total)
+		}
+	}
+	if w := callHandlerGET(user.ID, "tags=a&tag_mode=some", GetTransactions); w.Code != http.StatusBadRequest {
+		t.Errorf("bad tag_mode: want 400, got %d", w.Code)
+	}
+}
+
+func TestTags_Summary(t *testing.T) {
+	user, _ := seedTaggedTxs(t)
+	w := callHandlerGET(user.ID, "begin_date=2026-05-01&end_date=2026-05-31", GetTagSummary)
+	if w.Code != http.StatusOK {
+		t.Fatalf("summary: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Summary []struct {
+			Tag         struct{ Name string } `json:"tag"`
+			TotalAmount float64               `json:"total_amount"`
+			Count       int                   `json:"count"`
+		} `json:"summary"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	got := map[string]float64{}
+	for _, s := range resp.Summary {
+		got[s.Tag.Name] = s.TotalAmount
+	}
+	if got["vacation-2026"] != 140 || got["business"] != 115 || got["reimbursable"] != 15 || len(got) != 3 {
+		t.Errorf("unexpected per-tag totals: %v", got)
+	}
+	if resp.Summary[0].Tag.Name != "vacation-2026" {
+		t.Errorf("summary should be ordered by total desc, got %+v", resp.Summary)
+	}
+}
+
+// Updating replaces the tag set; deleting a tag detaches it everywhere.
+func TestTags_UpdateAndDelete(t *testing.T) {
+	user, txs := seedTaggedTxs(t)
+	id := gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(txs[1].ID), 10)}}
+	w := callHandlerParamBody(user.ID, id, map[string]any{"tags": []string{"business"}}, UpdateTransaction)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	if p := getTxPage(t, user.ID, url.Values{"tags": {"business"}}); p.Total != 3 {
+		t.Errorf("want 3 business transactions after retagging, got %d", p.Total)
+	}
+
+	var business models.Tag
+	database.DB.Where("user_id = ? AND name = ?", user.ID, "business").First(&business)
+	w = callHandlerParam(user.ID, gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(business.ID), 10)}}, DeleteTag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete tag: %d %s", w.Code, w.Body.String())
+	}
+	if p := getTxPage(t, user.ID, url.Values{"tags": {"business"}}); p.Total != 0 {
+		t.Errorf("deleted tag still matches %d transactions", p.Total)
+	}
+	var n int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&n)
+	if n != 4 {
+		t.Errorf("deleting a tag must keep its transactions, found %d", n)
+	}
+}
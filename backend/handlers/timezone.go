@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Per-user time zone ───────────────────────────────────────────────────────
+//
+// Two kinds of time values flow through the handlers:
+//
+//   - calendar dates (cycle start, payday, a transaction's Date, recurring
+//     dates): stored anchored at midnight or noon and read back with
+//     toDateOnly, which yields a UTC-midnight "date key";
+//   - instants (created_at, now): real moments in time.
+//
+// An instant only has a calendar day relative to a zone, so every "today",
+// week start and window boundary goes through the user's zone: localDate turns
+// an instant into a date key, dayStartIn turns a date key into the instant the
+// user's day begins. Window queries on created_at use the latter, which keeps
+// them correct across DST changes (a day is 23 or 25 hours there).
+
+// loadTimezone resolves an IANA zone name; "" is UTC.
+func loadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	// time.LoadLocation also accepts "Local", which would silently mean the
+	// server's zone.
+	if name == "Local" {
+		return nil, errors.New("Invalid timezone. Use an IANA name such as Europe/Berlin")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("Invalid timezone. Use an IANA name such as Europe/Berlin")
+	}
+	return loc, nil
+}
+
+// userLocation is the zone the user's dates are evaluated in, UTC when unset
+// or unreadable.
+func userLocation(uid uint) *time.Location {
+	var name string
+	database.DB.Model(&models.User{}).Where("id = ?", uid).Select("timezone").Scan(&name)
+	loc, err := loadTimezone(name)
+	if err != nil {
+		log.Printf("user timezone: user=%v zone=%q err=%v", uid, name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// userNow is the current instant in the user's zone. Window helpers that take
+// a `now` evaluate calendar boundaries in now.Location(), so pass this.
+func userNow(uid uint) time.Time {
+	return time.Now().In(userLocation(uid))
+}
+
+// localDate is the calendar day of instant t in loc, as a date key comparable
+// with toDateOnly values.
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dayStartIn is the instant the calendar day of date key d begins in loc.
+func dayStartIn(d time.Time, loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
+// daysBetween counts calendar days from date key a to date key b. Date keys
+// are UTC midnights, so the division is exact.
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
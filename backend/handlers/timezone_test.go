@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+// The day the clocks go forward is 23 hours long: local-day helpers must still
+// count it as one calendar day and start it at local midnight.
+func TestLocalDayHelpers_DST(t *testing.T) {
+	berlin := mustZone(t, "Europe/Berlin")
+	// 2026-03-29 02:00 CET → 03:00 CEST.
+	sat := time.Date(2026, 3, 28, 23, 30, 0, 0, berlin)
+	sun := sat.Add(4 * time.Hour) // 04:30 CEST, after the jump
+	if got := localDate(sun, berlin); !got.Equal(utcDay(2026, 3, 29)) {
+		t.Errorf("localDate across the jump: %v", got)
+	}
+	mon := dayStartIn(utcDay(2026, 3, 30), berlin)
+	if h := mon.Sub(dayStartIn(utcDay(2026, 3, 29), berlin)).Hours(); h != 23 {
+		t.Errorf("DST day should be 23h long, got %v", h)
+	}
+	if n := daysBetween(localDate(sat, berlin), localDate(mon, berlin)); n != 2 {
+		t.Errorf("want 2 calendar days Saturday→Monday, got %d", n)
+	}
+	// Late evening in New York is already tomorrow in UTC.
+	ny := mustZone(t, "America/New_York")
+	if got := localDate(time.Date(2026, 11, 1, 23, 30, 0, 0, ny), ny); !got.Equal(utcDay(2026, 11, 1)) {
+		t.Errorf("late evening should stay on the local day, got %v", got)
+	}
+}
+
+// The month window is bounded by local midnights — the March window in Berlin
+// ends at 00:00 CEST on April 1 — and week day counts survive the DST week.
+func TestBudgetWindow_UserZoneAcrossDST(t *testing.T) {
+	setupFlowDB(t)
+	berlin := mustZone(t, "Europe/Berlin")
+	user := models.User{Username: "berliner", Password: "x", Timezone: "Europe/Berlin"}
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Food"}
+	database.DB.Create(&cat)
+
+	addExpense(t, user.ID, cat.ID, 40, time.Date(2026, 3, 31, 23, 30, 0, 0, berlin)) // still March locally
+	addExpense(t, user.ID, cat.ID, 60, time.Date(2026, 4, 1, 0, 30, 0, 0, berlin))   // March 31 in UTC
+	addExpense(t, user.ID, cat.ID, 5, time.Date(2026, 3, 1, 0, 15, 0, 0, berlin))    // February 28 in UTC
+
+	now := time.Date(2026, 3, 31, 23, 45, 0, 0, berlin)
+	bw := computeBudgetWindow(user.ID, 500, now)
+	if !bw.WindowStart.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, berlin)) {
+		t.Errorf("window start: %v", bw.WindowStart)
+	}
+	if _, off := bw.WindowEnd.Zone(); off != 2*3600 {
+		t.Errorf("window end should be in CEST, got offset %d", off)
+	}
+	if bw.SpentThisWindow != 45 {
+		t.Errorf("want 45 spent in local March, got %.2f", bw.SpentThisWindow)
+	}
+	// Monday 2026-03-30 started the week, so Tuesday has one completed day.
+	if bw.DaysElapsedInWeek != 1 || bw.DaysTotal != 31 || bw.DaysElapsed != 31 {
+		t.Errorf("day counts: %+v", bw)
+	}
+
+	// Sunday after the jump, in the DST week itself: six completed days.
+	sunday := time.Date(2026, 3, 29, 3, 30, 0, 0, berlin)
+	if bw := computeBudgetWindow(user.ID, 500, sunday); bw.DaysElapsedInWeek != 6 || bw.DaysRemainingInWeek != 1 {
+		t.Errorf("DST week: elapsed %d, remaining %d", bw.DaysElapsedInWeek, bw.DaysRemainingInWeek)
+	}
+}
+
+// A cycle's spend window runs from local midnight of its first day through the
+// end of payday in the user's zone, including when DST changes in between.
+func TestCycleSpendWindow_UserZone(t *testing.T) {
+	ny := mustZone(t, "America/New_York")
+	payday := utcDay(2026, 3, 31)
+	cycle := models.SalaryCycle{CycleStartAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), NextPaydayAt: &payday}
+
+	w := cycleSpendWindow(cycle, time.Date(2026, 3, 15, 9, 0, 0, 0, ny))
+	if !w.From.Equal(time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC)) { // EST
+		t.Errorf("from: %v", w.From.UTC())
+	}
+	if !w.To.Equal(time.Date(2026, 4, 1, 4, 0, 0, 0, time.UTC)) { // EDT
+		t.Errorf("to: %v", w.To.UTC())
+	}
+}
+
+// Starting a cycle in a zone far ahead of UTC keeps the requested calendar
+// day, and the salary lands on that local day.
+func TestStartSalaryCycle_UserZone(t *testing.T) {
+	setupFlowDB(t)
+	auckland := mustZone(t, "Pacific/Auckland")
+	user := models.User{Username: "kiwi", Password: "x", Timezone: "Pacific/Auckland"}
+	database.DB.Create(&user)
+
+	received := time.Now().In(auckland).AddDate(0, 0, -1).Format("2006-01-02")
+	body := map[string]any{"base_salary": 3000.0, "received_at_date": received, "language": "en"}
+	if w := callHandler(user.ID, body, StartSalaryCycle); w.Code != http.StatusCreated {
+		t.Fatalf("start: %d %s", w.Code, w.Body.String())
+	}
+	var cycle models.SalaryCycle
+	database.DB.Where("user_id = ?", user.ID).First(&cycle)
+	if got := toDateOnly(cycle.CycleStartAt).Format("2006-01-02"); got != received {
+		t.Errorf("cycle start: want %s, got %s", received, got)
+	}
+	var salary models.Transaction
+	database.DB.Where("user_id = ? AND description = ?", user.ID, "Salary").First(&salary)
+	if got := salary.CreatedAt.In(auckland).Format("2006-01-02"); got != received {
+		t.Errorf("salary should land on %s in Auckland, got %s", received, got)
+	}
+	if s := computeCycleStats(user.ID, cycle); s.CycleIncome != 3000 || s.DaysElapsed != 1 {
+		t.Errorf("cycle stats: income %.2f, days elapsed %d", s.CycleIncome, s.DaysElapsed)
+	}
+}
+
+// A recurring occurrence falls due at the owner's local midnight.
+func TestRecurringScheduler_UserZone(t *testing.T) {
+	setupFlowDB(t)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC) // already June 2 in Kiritimati (UTC+14)
+	due := utcDay(2026, 6, 2)
+	for _, zone := range []string{"Pacific/Kiritimati", ""} {
+		user := models.User{Username: "rec" + zone, Password: "x", Timezone: zone}
+		database.DB.Create(&user)
+		cat := models.Category{UserID: user.ID, Name: "Rent"}
+		database.DB.Create(&cat)
+		database.DB.Create(&models.RecurringTransaction{
+			UserID: user.ID, CategoryID: cat.ID, Amount: 900, Type: "expense", Frequency: "monthly",
+			Interval: 1, StartDate: due, NextDate: &due,
+		})
+	}
+	if n := runRecurringScheduler(now); n != 1 {
+		t.Errorf("only the Kiritimati template is due, posted %d", n)
+	}
+}
+
+func TestUpdateProfile_Timezone(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "zoned", Password: "x"}
+	database.DB.Create(&user)
+
+	if w := callHandler(user.ID, map[string]any{"timezone": "Mars/Olympus"}, UpdateProfile); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown zone: want 400, got %d", w.Code)
+	}
+	if w := callHandler(user.ID, map[string]any{"timezone": "Asia/Tokyo"}, UpdateProfile); w.Code != http.StatusOK {
+		t.Fatalf("set zone: %d %s", w.Code, w.Body.String())
+	}
+	// A profile save from a client that does not know the field keeps the zone.
+	callHandler(user.ID, map[string]any{"currency": "EUR"}, UpdateProfile)
+	if loc := userLocation(user.ID); loc.String() != "Asia/Tokyo" {
+		t.Errorf("want Asia/Tokyo, got %s", loc)
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,12 +26,24 @@ func CreateTransaction(c *gin.Context) {
 	}
 
 	var input struct {
-		CategoryID  uint    `json:"category_id" binding:"required"`
+		// CategoryID: omitted = the largest split line's category, else the
+		// first matching rule's.
+		CategoryID  uint    `json:"category_id"`
 		Amount      float64 `json:"amount" binding:"required,gt=0"`
 		Description string  `json:"description"`
 		Date        string  `json:"date" binding:"required"`
-		Type        string  `json:"type" binding:"required,oneof=expense income"`
+		Type        string  `json:"type" binding:"required,oneof=expense income refund"`
 		IncomeType  string  `json:"income_type"`
+		// RefundOfID: the expense a "refund" gives money back for; its
+		// category defaults to the expense's and its currency is the expense's.
+		RefundOfID *uint `json:"refund_of_id"`
+		// AccountID: omitted = the default account.
+		AccountID *uint `json:"account_id"`
+		// Currency: ISO code the amount (and any split lines) are in;
+		// omitted = the account's currency.
+		Currency string       `json:"currency"`
+		Splits   []splitInput `json:"splits"`
+		Tags     []string     `json:"tags"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -40,6 +56,79 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	// A refund books against the expense it gives money back for.
+	var refundOf *models.Transaction
+	if input.Type == txTypeRefund {
+		if input.RefundOfID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refund_of_id is required for a refund"})
+			return
+		}
+		if len(input.Splits) > 0 || input.Currency != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A refund takes its currency and categories from the original expense"})
+			return
+		}
+		original, status, err := loadRefundable(database.DB, userID.(uint), *input.RefundOfID)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if input.CategoryID, err = refundCategory(original, input.CategoryID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if status, err := checkRefundAmount(database.DB, original, input.Amount, 0); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if input.AccountID == nil {
+			input.AccountID = original.AccountID
+		}
+		refundOf = &original
+	} else if input.RefundOfID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refund_of_id is only valid for a refund"})
+		return
+	}
+
+	// Optional split lines: the parent then carries the largest line's
+	// category unless the client named one explicitly.
+	var splits []models.TransactionSplit
+	if len(input.Splits) > 0 {
+		var largestCat uint
+		var status int
+		var err error
+		splits, largestCat, status, err = buildSplits(userID.(uint), input.Amount, input.Splits)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if input.CategoryID == 0 {
+			input.CategoryID = largestCat
+		}
+	}
+	// No category given: the user's rules get a go first.
+	if input.CategoryID == 0 {
+		rules, err := loadRules(database.DB, userID.(uint))
+		if err != nil {
+			log.Printf("create transaction: rules user=%v err=%v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load rules"})
+			return
+		}
+		if out, ok := rules.first(input.Description, input.Amount, input.Type); ok {
+			input.CategoryID = out.CategoryID
+			input.Description = out.Description
+			input.Tags = append(input.Tags, out.Tags...)
+		}
+	}
+	if input.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+		return
+	}
+	tagNames, err := normalizeTagNames(input.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var category models.Category
 	if err := database.DB.Where("id = ? AND user_id = ?", input.CategoryID, userID).First(&category).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -56,6 +145,27 @@ func CreateTransaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
+	if refundOf != nil && toDateOnly(parsedDate).Before(toDateOnly(refundOf.Date)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A refund cannot be dated before the original expense"})
+		return
+	}
+
+	account, status, err := resolveTxAccount(userID.(uint), input.AccountID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Currency == "" {
+		input.Currency = account.Currency
+	}
+	currency, err := normalizeCurrency(input.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if refundOf != nil {
+		currency = refundOf.Currency
+	}
 
 	incomeType := "one_time"
 	if input.Type == "income" && (input.IncomeType == "one_time" || input.IncomeType == "part") {
@@ -65,16 +175,39 @@ func CreateTransaction(c *gin.Context) {
 	transaction := models.Transaction{
 		UserID:      userID.(uint),
 		CategoryID:  input.CategoryID,
+		AccountID:   &account.ID,
 		Amount:      input.Amount,
 		Description: input.Description,
 		Date:        parsedDate,
 		Type:        input.Type,
 		IncomeType:  incomeType,
+		RefundOfID:  input.RefundOfID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	missing, err := applyFX(database.DB, &transaction, userBaseCurrency(database.DB, userID.(uint)), currency, input.Amount)
+	if err != nil {
+		log.Printf("create transaction: fx user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert currency"})
+		return
+	}
+	if missing != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": missing.message(), "missing_rates": []missingRate{*missing}})
+		return
+	}
+	// Split lines arrive in the transaction's currency.
+	rescaleSplits(splits, transaction.Amount)
 
-	if err := database.DB.Create(&transaction).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Splits", "Tags").Create(&transaction).Error; err != nil {
+			return err
+		}
+		if err := replaceSplits(tx, &transaction, splits); err != nil {
+			return err
+		}
+		return setTransactionTags(tx, &transaction, tagNames)
+	})
+	if err != nil {
 		log.Printf("create transaction: user=%v err=%v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
 		return
@@ -82,9 +215,21 @@ func CreateTransaction(c *gin.Context) {
 	InvalidateCycleCache(userID.(uint))
 	ScheduleBrainResync(userID.(uint))
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Transaction created successfully", "transaction": transaction})
+	resp := gin.H{"message": "Transaction created successfully", "transaction": transaction}
+	// The row is saved either way; a likely double entry only earns a warning.
+	if dup, err := findPossibleDuplicate(database.DB, transaction); err != nil {
+		log.Printf("create transaction: duplicate check user=%v err=%v", userID, err)
+	} else if dup != nil {
+		resp["possible_duplicate"] = dup
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
+// GetTransactions → GET /api/transactions
+// Filters: category_id, begin_date, end_date, type, income_type, min_amount,
+// max_amount, q (case-insensitive description substring) and tags (comma
+// separated; tag_mode=any (default) or all). Pagination via
+// limit + cursor is opt-in — see transaction_query.go.
 func GetTransactions(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -92,42 +237,47 @@ func GetTransactions(c *gin.Context) {
 		return
 	}
 
-	query := database.DB.Where("user_id = ?", userID)
-
-	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
-		categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_id format"})
-			return
-		}
-		query = query.Where("category_id = ?", uint(categoryID))
+	filter, err := parseTxFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if beginDateStr := c.Query("begin_date"); beginDateStr != "" {
-		parsedBeginDate, err := time.Parse("2006-01-02", beginDateStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid begin_date format. Use YYYY-MM-DD"})
-			return
-		}
-		query = query.Where("date >= ?", parsedBeginDate)
+	var total int64
+	if err := filter.apply(database.DB.Model(&models.Transaction{}).Where("user_id = ?", userID)).
+		Count(&total).Error; err != nil {
+		log.Printf("get transactions: count user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
 	}
-	if endDateStr := c.Query("end_date"); endDateStr != "" {
-		parsedEndDate, err := time.Parse("2006-01-02", endDateStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
-			return
-		}
-		query = query.Where("date <= ?", parsedEndDate.Add(24*time.Hour-time.Second))
+
+	query := filter.applyCursor(filter.apply(database.DB.Where("user_id = ?", userID))).
+		Preload("Category").Preload("Splits.Category").Preload("Tags").Order("date desc").Order("id desc")
+	if filter.Paginate {
+		// One extra row tells us whether another page exists without a second query.
+		query = query.Limit(filter.Limit + 1)
 	}
 
 	var transactions []models.Transaction
-	if err := query.Preload("Category").Order("date desc").Find(&transactions).Error; err != nil {
+	if err := query.Find(&transactions).Error; err != nil {
 		log.Printf("get transactions: user=%v err=%v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+	var nextCursor *string
+	if filter.Paginate && len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+		last := transactions[len(transactions)-1]
+		cur := encodeTxCursor(last.Date, last.ID)
+		nextCursor = &cur
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"next_cursor":  nextCursor, // nil → JSON null on the last page / unpaginated
+		"total":        total,
+	})
 }
 
 func GetTransactionByID(c *gin.Context) {
@@ -144,7 +294,7 @@ func GetTransactionByID(c *gin.Context) {
 	}
 
 	var transaction models.Transaction
-	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", uint(transactionID), userID).First(&transaction).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").Where("id = ? AND user_id = ?", uint(transactionID), userID).First(&transaction).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or does not belong to you"})
 		} else {
@@ -154,6 +304,7 @@ func GetTransactionByID(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", transactionETag(transaction))
 	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
 }
 
@@ -175,6 +326,40 @@ func UpdateTransaction(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or access denied"})
 		return
 	}
+	if etag := transactionETag(transaction); ifMatchFails(c, etag) {
+		database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").First(&transaction, transaction.ID)
+		preconditionFailed(c, etag, gin.H{"transaction": transaction})
+		return
+	}
+	seen := transaction.UpdatedAt
+	if transaction.Status == txStatusReconciled {
+		c.JSON(http.StatusConflict, gin.H{"error": errReconciled.Error(), "reconciliation_id": transaction.ReconciliationID})
+		return
+	}
+	if isTransferType(transaction.Type) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Transfers cannot be edited — delete the transfer and create a new one"})
+		return
+	}
+	// A refund stays a refund of the same expense, in its currency; an
+	// expense with refunds keeps its type and currency and never drops below
+	// what was refunded.
+	var refundOf *models.Transaction
+	var refunded float64
+	switch {
+	case transaction.Type == txTypeRefund && transaction.RefundOfID != nil:
+		original, status, err := loadRefundable(database.DB, userID.(uint), *transaction.RefundOfID)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		refundOf = &original
+	case transaction.Type == "expense":
+		if refunded, err = refundedTotal(database.DB, transaction.ID, 0); err != nil {
+			log.Printf("update transaction: refunds user=%v tx=%v err=%v", userID, transactionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+			return
+		}
+	}
 
 	var input struct {
 		CategoryID  *uint    `json:"category_id"`
@@ -183,12 +368,41 @@ func UpdateTransaction(c *gin.Context) {
 		Date        *string  `json:"date"`
 		Type        *string  `json:"type"`
 		IncomeType  *string  `json:"income_type"`
+		AccountID   *uint    `json:"account_id"`
+		// Status: pending or cleared; "reconciled" is only set by locking a
+		// reconciliation.
+		Status *string `json:"status"`
+		// Currency: omitted = keep. Amount and split lines are in the
+		// transaction's currency; "" switches to the base currency.
+		Currency *string `json:"currency"`
+		// Splits: omitted = keep the current lines, [] = remove the split,
+		// otherwise replace all lines.
+		Splits *[]splitInput `json:"splits"`
+		// Tags: omitted = keep, otherwise replace the whole set ([] clears it).
+		Tags *[]string `json:"tags"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if refundOf != nil {
+		if (input.Type != nil && *input.Type != txTypeRefund) || input.Currency != nil || input.Splits != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A refund cannot change its type, currency or split lines"})
+			return
+		}
+		input.Type = nil // "refund" itself is not a settable type
+		if input.CategoryID != nil {
+			if _, err := refundCategory(*refundOf, *input.CategoryID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+	if refunded > 0 && input.Type != nil && *input.Type != transaction.Type {
+		c.JSON(http.StatusConflict, gin.H{"error": errHasRefunds.Error()})
+		return
+	}
 	if input.Type != nil {
 		if *input.Type != "expense" && *input.Type != "income" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Allowed values: expense, income. Use the /salary-cycle/savings endpoint for savings transfers."})
@@ -216,12 +430,46 @@ func UpdateTransaction(c *gin.Context) {
 		}
 		transaction.CategoryID = *input.CategoryID
 	}
+	if input.AccountID != nil {
+		account, status, err := resolveTxAccount(userID.(uint), input.AccountID)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		transaction.AccountID = &account.ID
+	}
+	if input.Status != nil {
+		if *input.Status != txStatusPending && *input.Status != txStatusCleared {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Allowed values: pending, cleared"})
+			return
+		}
+		transaction.Status = *input.Status
+	}
+	amountBefore := transaction.Amount
+	original := txOriginalAmount(transaction)
 	if input.Amount != nil {
 		if *input.Amount <= 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
 			return
 		}
-		transaction.Amount = *input.Amount
+		original = *input.Amount
+	}
+	currency := transaction.Currency
+	if input.Currency != nil {
+		if currency, err = normalizeCurrency(*input.Currency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if refundOf != nil && input.Amount != nil {
+		if status, err := checkRefundAmount(database.DB, *refundOf, original, transaction.ID); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if refunded > 0 && (currency != transaction.Currency || round2(original) < round2(refunded)) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The expense has %.2f in refunds — its amount cannot drop below that, nor its currency change", refunded)})
+		return
 	}
 	if input.Description != nil {
 		trimmed := strings.TrimSpace(*input.Description)
@@ -239,9 +487,96 @@ func UpdateTransaction(c *gin.Context) {
 		}
 		transaction.Date = parsedDate
 	}
+	if refundOf != nil && toDateOnly(transaction.Date).Before(toDateOnly(refundOf.Date)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A refund cannot be dated before the original expense"})
+		return
+	}
+	// Re-converted on every save: the date picks the rate.
+	missing, err := applyFX(database.DB, &transaction, userBaseCurrency(database.DB, userID.(uint)), currency, original)
+	if err != nil {
+		log.Printf("update transaction: fx user=%v tx=%v err=%v", userID, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert currency"})
+		return
+	}
+	if missing != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": missing.message(), "missing_rates": []missingRate{*missing}})
+		return
+	}
+
+	var existingSplits []models.TransactionSplit
+	if err := database.DB.Preload("Category").Where("transaction_id = ?", transaction.ID).Find(&existingSplits).Error; err != nil {
+		log.Printf("update transaction: load splits user=%v tx=%v err=%v", userID, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+	var newSplits []models.TransactionSplit
+	switch {
+	case input.Splits != nil && len(*input.Splits) > 0:
+		splits, largestCat, status, err := buildSplits(userID.(uint), original, *input.Splits)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		rescaleSplits(splits, transaction.Amount)
+		newSplits = splits
+		if input.CategoryID == nil {
+			transaction.CategoryID = largestCat
+		}
+	case input.Splits == nil && len(existingSplits) > 0 && input.Amount != nil:
+		// The stored lines no longer add up unless they are re-sent.
+		var sum float64
+		for _, s := range existingSplits {
+			sum += s.Amount
+		}
+		if math.Round(sum*100) != math.Round(transaction.Amount*100) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount no longer matches the split lines — send splits with the new amount"})
+			return
+		}
+	case input.Splits == nil && len(existingSplits) > 0 && transaction.Amount != amountBefore:
+		// Only the conversion moved (new date or currency): the lines keep
+		// their proportions.
+		rescaleSplits(existingSplits, transaction.Amount)
+	}
+	var tagNames []string
+	if input.Tags != nil {
+		if tagNames, err = normalizeTagNames(*input.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	transaction.UpdatedAt = time.Now()
 
-	if err := database.DB.Save(&transaction).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Transaction{}, transaction.ID, seen); err != nil {
+			return err
+		}
+		if err := tx.Omit("Splits", "Tags").Save(&transaction).Error; err != nil {
+			return err
+		}
+		if input.Splits != nil {
+			if err := replaceSplits(tx, &transaction, newSplits); err != nil {
+				return err
+			}
+		} else {
+			if transaction.Amount != amountBefore {
+				if err := saveSplitAmounts(tx, existingSplits); err != nil {
+					return err
+				}
+			}
+			transaction.Splits = existingSplits
+		}
+		if input.Tags != nil {
+			return setTransactionTags(tx, &transaction, tagNames)
+		}
+		return tx.Model(&transaction).Association("Tags").Find(&transaction.Tags)
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Transaction not found or access denied", func() (string, gin.H, error) {
+			return currentTransaction(userID.(uint), transaction.ID)
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("update transaction: user=%v tx=%v err=%v", userID, transactionID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
 		return
@@ -249,6 +584,7 @@ func UpdateTransaction(c *gin.Context) {
 	InvalidateCycleCache(userID.(uint))
 	ScheduleBrainResync(userID.(uint))
 
+	c.Header("ETag", transactionETag(transaction))
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction updated successfully", "transaction": transaction})
 }
 
@@ -265,13 +601,65 @@ func DeleteTransaction(c *gin.Context) {
 		return
 	}
 
-	result := database.DB.Where("id = ? AND user_id = ?", uint(transactionID), userID).Delete(&models.Transaction{})
-	if result.Error != nil {
-		log.Printf("delete transaction: user=%v tx=%v err=%v", userID, transactionID, result.Error)
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.Transaction
+		if err := database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").
+			Where("id = ? AND user_id = ?", uint(transactionID), userID).First(&current).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or access denied"})
+			return
+		}
+		if etag := transactionETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, gin.H{"transaction": current})
+			return
+		}
+		seen = current.UpdatedAt
+	}
+
+	// A transfer goes as a whole: deleting either leg takes its peer along.
+	ids, err := withTransferPeers(database.DB, userID.(uint), []uint{uint(transactionID)})
+	if err != nil {
+		log.Printf("delete transaction: peers user=%v tx=%v err=%v", userID, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
+	if locked, err := anyReconciled(database.DB, userID.(uint), ids); err != nil {
+		log.Printf("delete transaction: status user=%v tx=%v err=%v", userID, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	} else if locked {
+		c.JSON(http.StatusConflict, gin.H{"error": errReconciled.Error()})
+		return
+	}
+	if refunded, err := hasLiveRefunds(database.DB, ids); err != nil {
+		log.Printf("delete transaction: refunds user=%v tx=%v err=%v", userID, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	} else if refunded {
+		c.JSON(http.StatusConflict, gin.H{"error": errHasRefunds.Error()})
+		return
+	}
+	var deleted int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.Transaction{}, uint(transactionID), seen); err != nil {
+			return err
+		}
+		result := tx.Where("id IN ? AND user_id = ?", ids, userID).Delete(&models.Transaction{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "Transaction not found or access denied", func() (string, gin.H, error) {
+			return currentTransaction(userID.(uint), uint(transactionID))
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("delete transaction: user=%v tx=%v err=%v", userID, transactionID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
 		return
 	}
-	if result.RowsAffected == 0 {
+	if deleted == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or access denied"})
 		return
 	}
@@ -311,11 +699,12 @@ func GetDailySummary(c *gin.Context) {
 	endOfDay := parsedDate.Add(24*time.Hour - time.Second)
 
 	var summaries []DailyExpenseSummary
-	result := database.DB.Table("transactions").
-		Select("transactions.category_id AS category_id, SUM(transactions.amount) AS total_amount, categories.name AS category_name").
-		Joins("LEFT JOIN categories ON transactions.category_id = categories.id").
-		Where("transactions.user_id = ? AND transactions.date >= ? AND transactions.date <= ?", userID, startOfDay, endOfDay).
-		Group("transactions.category_id, categories.id, categories.name").
+	// Per category line, so a split receipt counts towards each of its categories.
+	result := database.DB.Table(categoryLinesTable).
+		Select("tx_lines.category_id AS category_id, SUM(tx_lines.amount) AS total_amount, categories.name AS category_name").
+		Joins("LEFT JOIN categories ON tx_lines.category_id = categories.id").
+		Where("tx_lines.user_id = ? AND tx_lines.date >= ? AND tx_lines.date <= ?", userID, startOfDay, endOfDay).
+		Group("tx_lines.category_id, categories.id, categories.name").
 		Order("total_amount DESC").
 		Scan(&summaries)
 
@@ -365,11 +754,11 @@ func GetPeriodSummary(c *gin.Context) {
 	parsedEndDate = parsedEndDate.Add(24*time.Hour - time.Second)
 
 	var summaries []DailyExpenseSummary
-	result := database.DB.Table("transactions").
-		Select("transactions.category_id AS category_id, SUM(transactions.amount) AS total_amount, categories.name AS category_name").
-		Joins("LEFT JOIN categories ON transactions.category_id = categories.id").
-		Where("transactions.user_id = ? AND transactions.date >= ? AND transactions.date <= ?", userID, parsedBeginDate, parsedEndDate).
-		Group("transactions.category_id, categories.id, categories.name").
+	result := database.DB.Table(categoryLinesTable).
+		Select("tx_lines.category_id AS category_id, SUM(tx_lines.amount) AS total_amount, categories.name AS category_name").
+		Joins("LEFT JOIN categories ON tx_lines.category_id = categories.id").
+		Where("tx_lines.user_id = ? AND tx_lines.date >= ? AND tx_lines.date <= ?", userID, parsedBeginDate, parsedEndDate).
+		Group("tx_lines.category_id, categories.id, categories.name").
 		Order("total_amount DESC").
 		Scan(&summaries)
 
@@ -379,6 +768,18 @@ func GetPeriodSummary(c *gin.Context) {
 		return
 	}
 
+	// rollup=true folds every sub-category's total into its top-level
+	// category, so "Food" reports Food + Restaurants + Groceries.
+	if c.Query("rollup") == "true" {
+		forest, err := loadCategoryForest(database.DB, userID.(uint))
+		if err != nil {
+			log.Printf("get period summary: load tree user=%v err=%v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch period summary"})
+			return
+		}
+		summaries = rollUpSummaries(forest, summaries)
+	}
+
 	formattedSummaries := []map[string]interface{}{}
 	for _, s := range summaries {
 		formattedSummaries = append(formattedSummaries, map[string]interface{}{
@@ -393,3 +794,24 @@ func GetPeriodSummary(c *gin.Context) {
 		"summary":    formattedSummaries,
 	})
 }
+
+// rollUpSummaries merges per-category rows into their top-level categories,
+// keeping the total-desc ordering of GetPeriodSummary.
+func rollUpSummaries(forest *categoryForest, rows []DailyExpenseSummary) []DailyExpenseSummary {
+	index := map[uint]int{}
+	out := make([]DailyExpenseSummary, 0, len(rows))
+	for _, r := range rows {
+		root := forest.rootOf(r.CategoryID)
+		if i, ok := index[root]; ok {
+			out[i].TotalAmount = round2(out[i].TotalAmount + r.TotalAmount)
+			continue
+		}
+		if cat, ok := forest.byID[root]; ok {
+			r.CategoryID, r.CategoryName = cat.ID, cat.Name
+		}
+		index[root] = len(out)
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TotalAmount > out[j].TotalAmount })
+	return out
+}
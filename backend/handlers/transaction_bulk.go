@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Bulk operations ──────────────────────────────────────────────────────────
+//
+// POST /transactions/bulk applies one operation to many rows at once. Each
+// row is checked the way UpdateTransaction / DeleteTransaction would check it;
+// if any row fails nothing is written and the per-id results say why.
+// Otherwise everything commits in one DB transaction and the cycle cache and
+// brain resync fire once for the whole batch.
+
+const (
+	bulkSetCategory = "set_category"
+	bulkSetType     = "set_type"
+	bulkAddTags     = "add_tags"
+	bulkRemoveTags  = "remove_tags"
+	bulkDelete      = "delete"
+	bulkShiftDate   = "shift_date"
+
+	// maxBulkRows caps one request; a filter matching more must be narrowed.
+	maxBulkRows = 1000
+	// maxBulkShiftDays keeps a date shift to within a year either way.
+	maxBulkShiftDays = 366
+)
+
+// bulkResult is the outcome for one requested id: "updated", "unchanged" or
+// "failed" (with Error).
+type bulkResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	code   int
+}
+
+func (r *bulkResult) fail(code int, msg string) {
+	r.Status, r.Error, r.code = "failed", msg, code
+}
+
+// bulkFilterValue renders one JSON filter value the way it would appear in a
+// GET /transactions query string; a list of strings becomes comma-separated.
+func bulkFilterValue(v any) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case float64, bool:
+		return fmt.Sprint(x), nil
+	case []any:
+		parts := make([]string, 0, len(x))
+		for _, p := range x {
+			s, ok := p.(string)
+			if !ok {
+				return "", errors.New("filter lists may only hold strings")
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", errors.New("filter values must be strings, numbers or lists of strings")
+}
+
+// parseBulkFilter turns the request's filter object into a txFilter. It takes
+// the GET /transactions filter keys; pagination keys are refused and an empty
+// filter is too, so a bulk call can never silently hit every row.
+func parseBulkFilter(raw map[string]any) (txFilter, error) {
+	values := map[string]string{}
+	for k, v := range raw {
+		if k == "limit" || k == "cursor" {
+			return txFilter{}, errors.New("filter does not take limit or cursor")
+		}
+		s, err := bulkFilterValue(v)
+		if err != nil {
+			return txFilter{}, err
+		}
+		if s != "" {
+			values[k] = s
+		}
+	}
+	if len(values) == 0 {
+		return txFilter{}, errors.New("filter must constrain at least one field")
+	}
+	return parseTxFilterFrom(func(key string) string { return values[key] })
+}
+
+// BulkTransactions → POST /api/transactions/bulk
+// Body: either "ids" or "filter" (GET /transactions keys), plus "operation":
+//
+//	set_category  category_id
+//	set_type      type (expense | income)
+//	add_tags      tags
+//	remove_tags   tags
+//	delete        — (a transfer leg takes its peer along)
+//	shift_date    days (± up to 366; foreign amounts re-convert at the new date;
+//	              a recurring instance may not land on a sibling's date)
+func BulkTransactions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		IDs        []uint         `json:"ids"`
+		Filter     map[string]any `json:"filter"`
+		Operation  string         `json:"operation" binding:"required"`
+		CategoryID uint           `json:"category_id"`
+		Type       string         `json:"type"`
+		Tags       []string       `json:"tags"`
+		Days       int            `json:"days"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tagNames []string
+	switch input.Operation {
+	case bulkSetCategory:
+		if input.CategoryID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "set_category needs category_id"})
+			return
+		}
+		var n int64
+		database.DB.Model(&models.Category{}).Where("id = ? AND user_id = ?", input.CategoryID, uid).Count(&n)
+		if n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or does not belong to you"})
+			return
+		}
+	case bulkSetType:
+		if input.Type != "expense" && input.Type != "income" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Allowed values: expense, income"})
+			return
+		}
+	case bulkAddTags, bulkRemoveTags:
+		names, err := normalizeTagNames(input.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(names) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": input.Operation + " needs at least one tag"})
+			return
+		}
+		tagNames = names
+	case bulkShiftDate:
+		if input.Days == 0 || input.Days > maxBulkShiftDays || input.Days < -maxBulkShiftDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("shift_date needs days between -%d and %d (not 0)", maxBulkShiftDays, maxBulkShiftDays)})
+			return
+		}
+	case bulkDelete:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation. Allowed values: set_category, set_type, add_tags, remove_tags, delete, shift_date"})
+		return
+	}
+
+	// Which rows: explicit ids or a filter, never both.
+	var ids []uint
+	switch {
+	case len(input.IDs) > 0 && input.Filter != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send either ids or filter, not both"})
+		return
+	case len(input.IDs) > 0:
+		ids = uniqueUints(input.IDs)
+		if len(ids) > maxBulkRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d transactions per request", maxBulkRows)})
+			return
+		}
+	case input.Filter != nil:
+		filter, err := parseBulkFilter(input.Filter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := filter.apply(database.DB.Model(&models.Transaction{}).Where("user_id = ?", uid)).
+			Order("date DESC").Order("id DESC").Limit(maxBulkRows+1).Pluck("id", &ids).Error; err != nil {
+			log.Printf("bulk transactions: filter user=%v err=%v", uid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+			return
+		}
+		if len(ids) > maxBulkRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The filter matches more than %d transactions — narrow it down", maxBulkRows)})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids or filter is required"})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusOK, gin.H{"operation": input.Operation, "results": []bulkResult{}, "updated": 0})
+		return
+	}
+
+	plan, err := planBulk(uid, ids, input.Operation, input.CategoryID, input.Type, tagNames, input.Days)
+	if err != nil {
+		log.Printf("bulk transactions: plan user=%v op=%v err=%v", uid, input.Operation, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare bulk operation"})
+		return
+	}
+	if plan.failed > 0 {
+		c.JSON(plan.failCode, gin.H{
+			"error":   fmt.Sprintf("No changes were made — %d of %d transactions cannot be changed", plan.failed, len(ids)),
+			"results": plan.results,
+		})
+		return
+	}
+
+	if len(plan.changed) > 0 {
+		if err := database.DB.Transaction(plan.commit); err != nil {
+			log.Printf("bulk transactions: user=%v op=%v err=%v", uid, input.Operation, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operation"})
+			return
+		}
+		InvalidateCycleCache(uid)
+		ScheduleBrainResync(uid)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"operation": input.Operation, "results": plan.results, "updated": len(plan.changed)})
+}
+
+// bulkPlan is a validated bulk operation: per-id results and, when nothing
+// failed, the rows to write.
+type bulkPlan struct {
+	results  []bulkResult
+	failed   int
+	failCode int
+	changed  []models.Transaction
+	commit   func(tx *gorm.DB) error
+}
+
+// planBulk checks every row against the operation without writing. Rows are
+// reported in the order of ids.
+func planBulk(uid uint, ids []uint, op string, categoryID uint, newType string, tagNames []string, days int) (*bulkPlan, error) {
+	var rows []models.Transaction
+	if err := database.DB.Preload("Splits").Preload("Tags").
+		Where("user_id = ? AND id IN ?", uid, ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Transaction, len(rows))
+	for _, r := range rows {
+		byID[r.ID] = r
+	}
+
+	// Live refunds per expense in the batch, and originals of refunds in it.
+	refundsOf := map[uint][]uint{}
+	var refunds []models.Transaction
+	if err := database.DB.Where("user_id = ? AND type = ? AND refund_of_id IN ?", uid, txTypeRefund, ids).
+		Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range refunds {
+		refundsOf[*r.RefundOfID] = append(refundsOf[*r.RefundOfID], r.ID)
+	}
+	originals := map[uint]models.Transaction{}
+	var originalIDs []uint
+	for _, r := range rows {
+		if r.Type == txTypeRefund && r.RefundOfID != nil {
+			originalIDs = append(originalIDs, *r.RefundOfID)
+		}
+	}
+	if len(originalIDs) > 0 {
+		var list []models.Transaction
+		if err := database.DB.Preload("Splits").Where("user_id = ? AND id IN ?", uid, originalIDs).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, o := range list {
+			originals[o.ID] = o
+		}
+	}
+	inBatch := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		inBatch[id] = true
+	}
+
+	p := &bulkPlan{results: make([]bulkResult, len(ids))}
+	base := userBaseCurrency(database.DB, uid)
+	var deleteIDs []uint
+	for i, id := range ids {
+		res := &p.results[i]
+		res.ID, res.Status = id, "updated"
+		t, ok := byID[id]
+		switch {
+		case !ok:
+			res.fail(http.StatusNotFound, "Transaction not found or access denied")
+			continue
+		case t.Status == txStatusReconciled:
+			res.fail(http.StatusConflict, errReconciled.Error())
+			continue
+		case isTransferType(t.Type) && op != bulkDelete:
+			res.fail(http.StatusConflict, "Transfers cannot be edited — delete the transfer and create a new one")
+			continue
+		}
+
+		switch op {
+		case bulkSetCategory:
+			if len(t.Splits) > 0 {
+				res.fail(http.StatusConflict, "A split transaction keeps its lines' categories — edit it individually")
+				continue
+			}
+			if t.Type == txTypeRefund {
+				if _, err := refundCategory(originals[*t.RefundOfID], categoryID); err != nil {
+					res.fail(http.StatusBadRequest, err.Error())
+					continue
+				}
+			}
+			if t.CategoryID == categoryID {
+				res.Status = "unchanged"
+				continue
+			}
+			t.CategoryID = categoryID
+
+		case bulkSetType:
+			if t.Type == txTypeRefund {
+				res.fail(http.StatusConflict, "A refund cannot change its type")
+				continue
+			}
+			if t.Type == newType {
+				res.Status = "unchanged"
+				continue
+			}
+			if len(refundsOf[t.ID]) > 0 {
+				res.fail(http.StatusConflict, errHasRefunds.Error())
+				continue
+			}
+			t.Type = newType
+
+		case bulkAddTags, bulkRemoveTags:
+			have := map[string]bool{}
+			names := make([]string, 0, len(t.Tags)+len(tagNames))
+			for _, tag := range t.Tags {
+				have[tag.Name] = true
+			}
+			drop := map[string]bool{}
+			if op == bulkRemoveTags {
+				for _, n := range tagNames {
+					drop[n] = true
+				}
+			}
+			for _, tag := range t.Tags {
+				if !drop[tag.Name] {
+					names = append(names, tag.Name)
+				}
+			}
+			if op == bulkAddTags {
+				for _, n := range tagNames {
+					if !have[n] {
+						names = append(names, n)
+					}
+				}
+			}
+			if len(names) == len(t.Tags) {
+				res.Status = "unchanged"
+				continue
+			}
+			// Carried to the commit through Tags; resolved there by name.
+			t.Tags = make([]models.Tag, len(names))
+			for j, n := range names {
+				t.Tags[j] = models.Tag{Name: n}
+			}
+
+		case bulkShiftDate:
+			t.Date = t.Date.AddDate(0, 0, days)
+			if t.Type == txTypeRefund {
+				if o, ok := originals[*t.RefundOfID]; ok && toDateOnly(t.Date).Before(toDateOnly(o.Date)) {
+					res.fail(http.StatusBadRequest, "A refund cannot be dated before the original expense")
+					continue
+				}
+			}
+			amountBefore := t.Amount
+			missing, err := applyFX(database.DB, &t, base, t.Currency, txOriginalAmount(t))
+			if err != nil {
+				return nil, err
+			}
+			if missing != nil {
+				res.fail(http.StatusUnprocessableEntity, missing.message())
+				continue
+			}
+			if len(t.Splits) > 0 && t.Amount != amountBefore {
+				rescaleSplits(t.Splits, t.Amount)
+			}
+
+		case bulkDelete:
+			for _, r := range refundsOf[t.ID] {
+				if !inBatch[r] {
+					res.fail(http.StatusConflict, errHasRefunds.Error())
+					break
+				}
+			}
+			if res.Status == "failed" {
+				continue
+			}
+			deleteIDs = append(deleteIDs, t.ID)
+		}
+		t.UpdatedAt = time.Now()
+		p.changed = append(p.changed, t)
+	}
+
+	if op == bulkDelete && len(deleteIDs) > 0 {
+		// Peers come along, but only if they may go too.
+		all, err := withTransferPeers(database.DB, uid, deleteIDs)
+		if err != nil {
+			return nil, err
+		}
+		var locked []uint
+		if err := database.DB.Unscoped().Model(&models.Transaction{}).
+			Where("user_id = ? AND id IN ? AND status = ?", uid, all, txStatusReconciled).
+			Pluck("id", &locked).Error; err != nil {
+			return nil, err
+		}
+		lockedSet := make(map[uint]bool, len(locked))
+		for _, id := range locked {
+			lockedSet[id] = true
+		}
+		for i := range p.results {
+			t := byID[p.results[i].ID]
+			if p.results[i].Status == "updated" && t.TransferPeerID != nil && lockedSet[*t.TransferPeerID] {
+				p.results[i].fail(http.StatusConflict, errReconciled.Error())
+			}
+		}
+		deleteIDs = all
+	}
+	if op == bulkShiftDate {
+		if err := markRecurringCollisions(uid, p); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range p.results {
+		if r.Status == "failed" {
+			p.failed++
+			if p.failCode == 0 || r.code == http.StatusNotFound {
+				p.failCode = r.code
+			}
+		}
+	}
+	if p.failed > 0 {
+		p.changed = nil
+		return p, nil
+	}
+
+	sort.Slice(deleteIDs, func(i, j int) bool { return deleteIDs[i] < deleteIDs[j] })
+	p.commit = func(tx *gorm.DB) error {
+		switch op {
+		case bulkDelete:
+			return tx.Where("user_id = ? AND id IN ?", uid, deleteIDs).Delete(&models.Transaction{}).Error
+		case bulkAddTags, bulkRemoveTags:
+			for i := range p.changed {
+				t := &p.changed[i]
+				names := make([]string, len(t.Tags))
+				for j, tag := range t.Tags {
+					names[j] = tag.Name
+				}
+				if err := setTransactionTags(tx, t, names); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if op == bulkShiftDate {
+			// Move the row furthest along first, so a recurring instance
+			// never lands on a sibling that has not moved yet.
+			sort.Slice(p.changed, func(i, j int) bool {
+				if days > 0 {
+					return p.changed[i].Date.After(p.changed[j].Date)
+				}
+				return p.changed[i].Date.Before(p.changed[j].Date)
+			})
+		}
+		for i := range p.changed {
+			t := &p.changed[i]
+			if err := tx.Omit("Splits", "Tags").Save(t).Error; err != nil {
+				return err
+			}
+			if op == bulkShiftDate && len(t.Splits) > 0 {
+				if err := saveSplitAmounts(tx, t.Splits); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return p, nil
+}
+
+// markRecurringCollisions fails every shifted recurring instance whose new
+// date is already held by another instance of its template, trashed ones
+// included since the (recurring_id, date) unique index covers them. Every
+// shifted row moves by the same number of days, so only rows staying put can
+// be in the way.
+func markRecurringCollisions(uid uint, p *bulkPlan) error {
+	moving := map[uint]models.Transaction{}
+	var templates []uint
+	for _, t := range p.changed {
+		if t.RecurringID != nil {
+			moving[t.ID] = t
+			templates = append(templates, *t.RecurringID)
+		}
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	var siblings []models.Transaction
+	if err := database.DB.Unscoped().Select("id", "recurring_id", "date").
+		Where("user_id = ? AND recurring_id IN ?", uid, uniqueUints(templates)).Find(&siblings).Error; err != nil {
+		return err
+	}
+	type slot struct {
+		recurringID uint
+		day         string
+	}
+	taken := map[slot]bool{}
+	for _, s := range siblings {
+		if _, ok := moving[s.ID]; !ok {
+			taken[slot{*s.RecurringID, toDateOnly(s.Date).Format("2006-01-02")}] = true
+		}
+	}
+	for i := range p.results {
+		t, ok := moving[p.results[i].ID]
+		if ok && p.results[i].Status == "updated" && taken[slot{*t.RecurringID, toDateOnly(t.Date).Format("2006-01-02")}] {
+			p.results[i].fail(http.StatusConflict, "Another instance of its recurring transaction is already on that date")
+		}
+	}
+	return nil
+}
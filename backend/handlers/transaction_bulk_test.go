@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// One failing row blocks the whole batch; otherwise ids or a filter select
+// the rows and every id gets its own result.
+func TestBulkTransactions(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	seed := func(day int, status string) models.Transaction {
+		tx := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 10, Type: "expense", Date: utcDay(2026, 3, day), Status: status}
+		database.DB.Create(&tx)
+		return tx
+	}
+	a, b := seed(10, txStatusPending), seed(11, txStatusPending)
+	locked := seed(12, txStatusReconciled)
+	other := models.User{Username: "someone-else", Password: "x"}
+	database.DB.Create(&other)
+	foreign := models.Transaction{UserID: other.ID, CategoryID: f.food.ID, Amount: 1, Type: "expense", Date: utcDay(2026, 3, 10)}
+	database.DB.Create(&foreign)
+
+	bulk := func(body map[string]any) (int, []bulkResult) {
+		w := callHandler(uid, body, BulkTransactions)
+		var resp struct {
+			Results []bulkResult `json:"results"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Results
+	}
+	categoryOf := func(id uint) uint {
+		var got models.Transaction
+		database.DB.First(&got, id)
+		return got.CategoryID
+	}
+
+	code, res := bulk(map[string]any{"ids": []uint{a.ID, locked.ID}, "operation": "set_category", "category_id": f.rent.ID})
+	if code != http.StatusConflict || len(res) != 2 || res[0].Status != "updated" || res[1].Status != "failed" {
+		t.Fatalf("a reconciled row should fail the batch: %d %+v", code, res)
+	}
+	if categoryOf(a.ID) != f.food.ID {
+		t.Errorf("a failed batch must not write")
+	}
+	if code, _ := bulk(map[string]any{"ids": []uint{a.ID, foreign.ID}, "operation": "delete"}); code != http.StatusNotFound {
+		t.Errorf("another user's row: want 404, got %d", code)
+	}
+	if code, _ := bulk(map[string]any{"filter": map[string]any{}, "operation": "delete"}); code != http.StatusBadRequest {
+		t.Errorf("an empty filter: want 400, got %d", code)
+	}
+
+	filter := map[string]any{"begin_date": "2026-03-01", "end_date": "2026-03-11"}
+	if code, res := bulk(map[string]any{"filter": filter, "operation": "set_category", "category_id": f.rent.ID}); code != http.StatusOK || len(res) != 2 {
+		t.Fatalf("set_category by filter: %d %+v", code, res)
+	}
+	if categoryOf(a.ID) != f.rent.ID || categoryOf(b.ID) != f.rent.ID || categoryOf(locked.ID) != f.food.ID {
+		t.Errorf("only the filtered rows should move")
+	}
+
+	if code, _ := bulk(map[string]any{"ids": []uint{a.ID}, "operation": "add_tags", "tags": []string{"trip"}}); code != http.StatusOK {
+		t.Fatalf("add_tags: %d", code)
+	}
+	code, res = bulk(map[string]any{"ids": []uint{a.ID, b.ID}, "operation": "add_tags", "tags": []string{"Trip"}})
+	if code != http.StatusOK || res[0].Status != "unchanged" || res[1].Status != "updated" {
+		t.Errorf("add_tags again: %d %+v", code, res)
+	}
+
+	if code, _ := bulk(map[string]any{"ids": []uint{a.ID}, "operation": "shift_date", "days": -3}); code != http.StatusOK {
+		t.Fatalf("shift_date: %d", code)
+	}
+	var shifted models.Transaction
+	database.DB.First(&shifted, a.ID)
+	if !toDateOnly(shifted.Date).Equal(utcDay(2026, 3, 7)) {
+		t.Errorf("shift_date: got %v", shifted.Date)
+	}
+
+	if code, _ := bulk(map[string]any{"ids": []uint{a.ID, b.ID}, "operation": "delete"}); code != http.StatusOK {
+		t.Fatalf("delete: %d", code)
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("id IN ?", []uint{a.ID, b.ID}).Count(&live)
+	if live != 0 {
+		t.Errorf("deleted rows should be in the trash, %d still live", live)
+	}
+}
+
+func bulkCall(uid uint, body map[string]any) (int, []bulkResult) {
+	w := callHandler(uid, body, BulkTransactions)
+	var resp struct {
+		Results []bulkResult `json:"results"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Results
+}
+
+// A filter must constrain something, and neither ids nor a filter may reach
+// past maxBulkRows.
+func TestBulkTransactions_RejectsOversizedOrEmptySelections(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	if code, _ := bulkCall(uid, map[string]any{"filter": map[string]any{"q": "", "tags": []any{}}, "operation": "delete"}); code != http.StatusBadRequest {
+		t.Errorf("a filter of blanks: want 400, got %d", code)
+	}
+
+	ids := make([]uint, maxBulkRows+1)
+	rows := make([]models.Transaction, maxBulkRows+1)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+		rows[i] = models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 1, Type: "expense", Date: utcDay(2026, 3, 1)}
+	}
+	if code, _ := bulkCall(uid, map[string]any{"ids": ids, "operation": "delete"}); code != http.StatusBadRequest {
+		t.Errorf("%d ids: want 400, got %d", len(ids), code)
+	}
+	database.DB.CreateInBatches(&rows, 200)
+	if code, _ := bulkCall(uid, map[string]any{"filter": map[string]any{"type": "expense"}, "operation": "delete"}); code != http.StatusBadRequest {
+		t.Errorf("a filter matching %d rows: want 400, got %d", len(rows), code)
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", uid).Count(&live)
+	if live != int64(len(rows)) {
+		t.Errorf("nothing should be deleted, %d of %d left", live, len(rows))
+	}
+}
+
+// Deleting one leg of a transfer takes its peer along; a reconciled peer
+// fails only the leg it belongs to, and that failure rolls back the batch.
+func TestBulkTransactions_TransferLegs(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	w := callHandler(uid, map[string]any{"name": "Wallet", "type": "cash"}, CreateAccount)
+	var wallet struct {
+		Account models.Account `json:"account"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &wallet)
+	def, _ := defaultAccount(database.DB, uid)
+	transfer := func(day string) (models.Transaction, models.Transaction) {
+		w := callHandler(uid, map[string]any{"from_account_id": def.ID, "to_account_id": wallet.Account.ID, "amount": 25, "date": day}, CreateAccountTransfer)
+		var resp struct {
+			From models.Transaction `json:"from"`
+			To   models.Transaction `json:"to"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("transfer: %d %s", w.Code, w.Body.String())
+		}
+		return resp.From, resp.To
+	}
+	out1, in1 := transfer("2026-03-02")
+	out2, in2 := transfer("2026-03-03")
+	expense := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 5, Type: "expense", Date: utcDay(2026, 3, 4)}
+	database.DB.Create(&expense)
+	database.DB.Model(&in2).Update("status", txStatusReconciled)
+
+	code, res := bulkCall(uid, map[string]any{"ids": []uint{out1.ID, out2.ID, expense.ID}, "operation": "delete"})
+	if code != http.StatusConflict || res[0].Status != "updated" || res[1].Status != "failed" || res[2].Status != "updated" {
+		t.Fatalf("only the leg with the reconciled peer should fail: %d %+v", code, res)
+	}
+	var live int64
+	database.DB.Model(&models.Transaction{}).Where("id IN ?", []uint{out1.ID, in1.ID, expense.ID}).Count(&live)
+	if live != 3 {
+		t.Fatalf("the failed batch must not delete anything, %d of 3 left", live)
+	}
+
+	if code, _ := bulkCall(uid, map[string]any{"ids": []uint{out1.ID}, "operation": "delete"}); code != http.StatusOK {
+		t.Fatalf("delete one leg: %d", code)
+	}
+	database.DB.Model(&models.Transaction{}).Where("id IN ?", []uint{out1.ID, in1.ID}).Count(&live)
+	if live != 0 {
+		t.Errorf("the peer should go to the trash with its leg, %d left", live)
+	}
+}
+
+// shift_date re-converts at the new date and refuses to leave a row without a
+// rate; recurring instances may move past each other but never onto a
+// sibling that stays put, trashed or not.
+func TestBulkTransactions_ShiftDate(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	if w := callHandler(uid, map[string]any{"data": ecbDailyCSV}, ImportExchangeRates); w.Code != http.StatusOK {
+		t.Fatalf("rates: %d %s", w.Code, w.Body.String())
+	}
+	w := callHandler(uid, map[string]any{"category_id": f.food.ID, "amount": 10, "currency": "GBP", "date": "2026-05-15", "type": "expense"}, CreateTransaction)
+	var foreign struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &foreign); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	code, res := bulkCall(uid, map[string]any{"ids": []uint{foreign.Transaction.ID}, "operation": "shift_date", "days": -30})
+	if code != http.StatusUnprocessableEntity || res[0].Status != "failed" {
+		t.Errorf("no rate a month earlier: want 422, got %d %+v", code, res)
+	}
+
+	// The index database.Connect creates; setupFlowDB only auto-migrates.
+	if err := database.DB.Exec(`CREATE UNIQUE INDEX idx_transactions_recurring_date ON transactions (recurring_id, date) WHERE recurring_id IS NOT NULL`).Error; err != nil {
+		t.Fatal(err)
+	}
+	tmpl := models.RecurringTransaction{UserID: uid, CategoryID: f.rent.ID, Amount: 3, Type: "expense", Frequency: "daily", Interval: 1, StartDate: utcDay(2026, 4, 1)}
+	database.DB.Create(&tmpl)
+	instance := func(day int) models.Transaction {
+		tx := models.Transaction{UserID: uid, CategoryID: f.rent.ID, Amount: 3, Type: "expense", Date: utcDay(2026, 4, day), RecurringID: &tmpl.ID}
+		database.DB.Create(&tx)
+		return tx
+	}
+	d1, d2, d3 := instance(1), instance(2), instance(3)
+	d5 := instance(5)
+	database.DB.Delete(&d5)
+
+	if code, res := bulkCall(uid, map[string]any{"ids": []uint{d1.ID, d2.ID, d3.ID}, "operation": "shift_date", "days": 1}); code != http.StatusOK {
+		t.Fatalf("consecutive instances moving together: %d %+v", code, res)
+	}
+	// Now on the 2nd, 3rd and 4th; the 5th is in the trash.
+	for name, id := range map[string]uint{"onto a sibling that stays": d1.ID, "onto a trashed sibling": d3.ID} {
+		code, res := bulkCall(uid, map[string]any{"ids": []uint{id}, "operation": "shift_date", "days": 1})
+		if code != http.StatusConflict || res[0].Status != "failed" {
+			t.Errorf("%s: want 409, got %d %+v", name, code, res)
+		}
+	}
+	var got models.Transaction
+	database.DB.First(&got, d3.ID)
+	if !toDateOnly(got.Date).Equal(utcDay(2026, 4, 4)) {
+		t.Errorf("the refused shift must not write: %v", got.Date)
+	}
+}
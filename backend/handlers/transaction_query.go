@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+)
+
+// ── GET /api/transactions — filters + cursor pagination ──────────────────────
+//
+// Pagination is opt-in: a request without `limit` or `cursor` still receives the
+// full filtered list (the history page filters client-side over it). With
+// either parameter the list is returned newest-first in pages of `limit` rows,
+// keyed by an opaque (date, id) cursor so rows inserted while the user scrolls
+// never shift or duplicate a page the way OFFSET would.
+
+const (
+	defaultTxPageSize = 50
+	maxTxPageSize     = 200
+)
+
+// transactionTypes is every value the app writes to transactions.type.
+var transactionTypes = map[string]bool{
+	"expense": true, "income": true, "savings_deposit": true, "savings_withdrawal": true,
+	txTypeTransferOut: true, txTypeTransferIn: true, txTypeRefund: true,
+}
+
+// txCursor is the decoded form of the opaque next_cursor token: the position of
+// the last row of the previous page in (date DESC, id DESC) order.
+type txCursor struct {
+	Date time.Time
+	ID   uint
+}
+
+// encodeTxCursor serialises a row position as base64url("<RFC3339Nano>|<id>").
+// RFC3339Nano keeps the stored offset, so the decoded time binds to the exact
+// same value SQLite compares as text.
+func encodeTxCursor(date time.Time, id uint) string {
+	raw := date.Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTxCursor is the inverse of encodeTxCursor. Any malformed token is an
+// error — clients must treat the cursor as opaque and echo it back verbatim.
+func decodeTxCursor(s string) (txCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return txCursor{}, errors.New("invalid cursor")
+	}
+	datePart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return txCursor{}, errors.New("invalid cursor")
+	}
+	date, err := time.Parse(time.RFC3339Nano, datePart)
+	if err != nil {
+		return txCursor{}, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil || id == 0 {
+		return txCursor{}, errors.New("invalid cursor")
+	}
+	return txCursor{Date: date, ID: uint(id)}, nil
+}
+
+// txFilter holds the parsed, validated GET /transactions query. Zero values
+// mean "no constraint".
+type txFilter struct {
+	CategoryID  uint
+	AccountID   uint // the default account also matches rows without account_id
+	BeginDate   *time.Time
+	EndDate     *time.Time // inclusive upper bound (end of the given day)
+	Type        string
+	IncomeType  string
+	MinAmount   *float64
+	MaxAmount   *float64
+	Description string   // lower-cased substring
+	Tags        []string // normalised tag names
+	TagsAll     bool     // tag_mode=all: every tag must be present, not just one
+
+	Paginate bool
+	Limit    int
+	Cursor   *txCursor
+}
+
+// parseTxFilter validates the query string. The returned error message is safe
+// to send to the client as-is.
+func parseTxFilter(c *gin.Context) (txFilter, error) {
+	return parseTxFilterFrom(c.Query)
+}
+
+// parseTxFilterFrom validates filter values read through get, which returns
+// "" for an absent key.
+func parseTxFilterFrom(get func(key string) string) (txFilter, error) {
+	var f txFilter
+
+	if s := get("category_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return f, errors.New("Invalid category_id format")
+		}
+		f.CategoryID = uint(id)
+	}
+	if s := get("account_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return f, errors.New("Invalid account_id format")
+		}
+		f.AccountID = uint(id)
+	}
+	if s := get("begin_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return f, errors.New("Invalid begin_date format. Use YYYY-MM-DD")
+		}
+		f.BeginDate = &d
+	}
+	if s := get("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return f, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+		}
+		d = d.Add(24*time.Hour - time.Second)
+		f.EndDate = &d
+	}
+	if s := get("type"); s != "" {
+		if !transactionTypes[s] {
+			return f, errors.New("Invalid type. Allowed values: expense, income, refund, savings_deposit, savings_withdrawal, transfer_out, transfer_in")
+		}
+		f.Type = s
+	}
+	if s := get("income_type"); s != "" {
+		if s != "one_time" && s != "part" {
+			return f, errors.New("Invalid income_type. Allowed values: one_time, part")
+		}
+		f.IncomeType = s
+	}
+	if s := get("min_amount"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			return f, errors.New("Invalid min_amount")
+		}
+		f.MinAmount = &v
+	}
+	if s := get("max_amount"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			return f, errors.New("Invalid max_amount")
+		}
+		f.MaxAmount = &v
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return f, errors.New("min_amount must not exceed max_amount")
+	}
+	if s := strings.TrimSpace(get("q")); s != "" {
+		if len(s) > 255 {
+			return f, errors.New("Search text must be 255 characters or fewer")
+		}
+		f.Description = strings.ToLower(s)
+	}
+
+	if s := get("tags"); s != "" {
+		names, err := normalizeTagNames(strings.Split(s, ","))
+		if err != nil {
+			return f, err
+		}
+		f.Tags = names
+	}
+	switch get("tag_mode") {
+	case "", "any":
+	case "all":
+		f.TagsAll = true
+	default:
+		return f, errors.New("Invalid tag_mode. Allowed values: any, all")
+	}
+
+	limitStr, cursorStr := get("limit"), get("cursor")
+	if limitStr == "" && cursorStr == "" {
+		return f, nil
+	}
+	f.Paginate = true
+	f.Limit = defaultTxPageSize
+	if limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > maxTxPageSize {
+			return f, errors.New("limit must be between 1 and " + strconv.Itoa(maxTxPageSize))
+		}
+		f.Limit = n
+	}
+	if cursorStr != "" {
+		cur, err := decodeTxCursor(cursorStr)
+		if err != nil {
+			return f, errors.New("Invalid cursor")
+		}
+		f.Cursor = &cur
+	}
+	return f, nil
+}
+
+// escapeLike escapes LIKE wildcards so user text is matched literally. Paired
+// with `ESCAPE '\'`, which both SQLite and Postgres understand.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// apply adds the filter (but not the cursor) to q. The cursor is applied
+// separately so the same filter can also drive the total count.
+func (f txFilter) apply(q *gorm.DB) *gorm.DB {
+	if f.CategoryID > 0 {
+		q = q.Where("category_id = ?", f.CategoryID)
+	}
+	if f.AccountID > 0 {
+		q = q.Where("(account_id = ? OR (account_id IS NULL AND EXISTS (SELECT 1 FROM accounts a WHERE a.id = ? AND a.is_default = ?)))",
+			f.AccountID, f.AccountID, true)
+	}
+	if f.BeginDate != nil {
+		q = q.Where("date >= ?", *f.BeginDate)
+	}
+	if f.EndDate != nil {
+		q = q.Where("date <= ?", *f.EndDate)
+	}
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if f.IncomeType != "" {
+		q = q.Where("income_type = ?", f.IncomeType)
+	}
+	if f.MinAmount != nil {
+		q = q.Where("amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		q = q.Where("amount <= ?", *f.MaxAmount)
+	}
+	if f.Description != "" {
+		q = q.Where(`LOWER(description) LIKE ? ESCAPE '\'`, "%"+escapeLike(f.Description)+"%")
+	}
+	if len(f.Tags) > 0 {
+		// Tag names are unique per user and the outer query is already scoped
+		// to the user, so matching by name cannot reach another user's tags.
+		sub := database.DB.Table("transaction_tags").
+			Select("transaction_tags.transaction_id").
+			Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+			Where("tags.name IN ?", f.Tags)
+		if f.TagsAll {
+			sub = sub.Group("transaction_tags.transaction_id").
+				Having("COUNT(DISTINCT tags.id) = ?", len(f.Tags))
+		}
+		q = q.Where("id IN (?)", sub)
+	}
+	return q
+}
+
+// applyCursor restricts q to rows strictly after the cursor in
+// (date DESC, id DESC) order.
+func (f txFilter) applyCursor(q *gorm.DB) *gorm.DB {
+	if f.Cursor == nil {
+		return q
+	}
+	return q.Where("(date < ? OR (date = ? AND id < ?))", f.Cursor.Date, f.Cursor.Date, f.Cursor.ID)
+}
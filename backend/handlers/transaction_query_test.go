@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+type txPage struct {
+	Transactions []models.Transaction `json:"transactions"`
+	NextCursor   *string              `json:"next_cursor"`
+	Total        int64                `json:"total"`
+}
+
+func getTxPage(t *testing.T, uid uint, q url.Values) txPage {
+	t.Helper()
+	w := callHandlerGET(uid, q.Encode(), GetTransactions)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetTransactions(%s): %d %s", q.Encode(), w.Code, w.Body.String())
+	}
+	var p txPage
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return p
+}
+
+func seedQueryTxs(t *testing.T) models.User {
+	t.Helper()
+	setupFlowDB(t)
+	user := models.User{Username: "pager", Password: "x"}
+	database.DB.Create(&user)
+	cat := models.Category{UserID: user.ID, Name: "Food"}
+	database.DB.Create(&cat)
+
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC) }
+	rows := []models.Transaction{
+		{Amount: 10, Description: "Молоко", Date: day(1), Type: "expense"},
+		{Amount: 20, Description: "REWE groceries", Date: day(2), Type: "expense"},
+		{Amount: 30, Description: "rewe again", Date: day(2), Type: "expense"}, // same date → id breaks the tie
+		{Amount: 40, Description: "100% juice", Date: day(3), Type: "expense"},
+		{Amount: 2500, Description: "Salary", Date: day(4), Type: "income", IncomeType: "part"},
+	}
+	for i := range rows {
+		rows[i].UserID, rows[i].CategoryID = user.ID, cat.ID
+		if rows[i].IncomeType == "" {
+			rows[i].IncomeType = "one_time"
+		}
+		database.DB.Create(&rows[i])
+	}
+	return user
+}
+
+// Walking every page with a small limit must visit each row exactly once, in
+// date-desc/id-desc order, and report the full total on every page.
+func TestGetTransactions_CursorWalk(t *testing.T) {
+	user := seedQueryTxs(t)
+
+	seen := map[uint]bool{}
+	var order []float64
+	q := url.Values{"limit": {"2"}}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor walk did not terminate")
+		}
+		p := getTxPage(t, user.ID, q)
+		if p.Total != 5 {
+			t.Errorf("total: want 5, got %d", p.Total)
+		}
+		for _, tx := range p.Transactions {
+			if seen[tx.ID] {
+				t.Fatalf("row %d returned twice", tx.ID)
+			}
+			seen[tx.ID] = true
+			order = append(order, tx.Amount)
+		}
+		if p.NextCursor == nil {
+			break
+		}
+		q.Set("cursor", *p.NextCursor)
+	}
+
+	want := []float64{2500, 40, 30, 20, 10}
+	if len(order) != len(want) {
+		t.Fatalf("walked %d rows, want %d", len(order), len(want))
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("row %d: want amount %.0f, got %.0f", i, want[i], order[i])
+		}
+	}
+}
+
+// Without limit/cursor the legacy full list is returned with no next_cursor.
+func TestGetTransactions_UnpaginatedByDefault(t *testing.T) {
+	user := seedQueryTxs(t)
+	p := getTxPage(t, user.ID, url.Values{})
+	if len(p.Transactions) != 5 || p.NextCursor != nil || p.Total != 5 {
+		t.Errorf("want 5 rows, nil cursor, total 5; got %d rows, cursor=%v, total %d",
+			len(p.Transactions), p.NextCursor, p.Total)
+	}
+}
+
+func TestGetTransactions_Filters(t *testing.T) {
+	user := seedQueryTxs(t)
+
+	cases := []struct {
+		name  string
+		query url.Values
+		want  int64
+	}{
+		{"description is case-insensitive", url.Values{"q": {"ReWe"}}, 2},
+		{"cyrillic case folding", url.Values{"q": {"МОЛОКО"}}, 1},
+		{"percent is literal", url.Values{"q": {"100%"}}, 1},
+		{"type", url.Values{"type": {"income"}}, 1},
+		{"income_type", url.Values{"income_type": {"part"}}, 1},
+		{"amount range", url.Values{"min_amount": {"20"}, "max_amount": {"40"}}, 3},
+		{"combined", url.Values{"q": {"rewe"}, "min_amount": {"25"}}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := tc.query
+			q.Set("limit", "1")
+			p := getTxPage(t, user.ID, q)
+			if p.Total != tc.want {
+				t.Errorf("total: want %d, got %d", tc.want, p.Total)
+			}
+		})
+	}
+}
+
+func TestGetTransactions_RejectsBadParams(t *testing.T) {
+	user := seedQueryTxs(t)
+	for _, q := range []string{
+		"cursor=not-a-cursor",
+		"limit=0",
+		"limit=1000",
+		"type=gift",
+		"min_amount=50&max_amount=10",
+	} {
+		if w := callHandlerGET(user.ID, q, GetTransactions); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", q, w.Code)
+		}
+	}
+}
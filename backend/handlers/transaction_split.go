@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Split transactions ───────────────────────────────────────────────────────
+//
+// A transaction may carry split lines, each with its own category and amount,
+// summing exactly to the parent Amount. Everything that attributes money to a
+// category works on "category lines": the splits of a split transaction, or
+// the transaction itself otherwise. Totals per type (income / expense) are
+// unchanged by splitting, so only category-aware code needs to care.
+
+// maxSplitLines keeps a single receipt's breakdown reasonable.
+const maxSplitLines = 50
+
+type splitInput struct {
+	CategoryID  uint    `json:"category_id"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+// categoryLine is one category attribution of a transaction.
+type categoryLine struct {
+	CategoryID uint
+	Amount     float64
+}
+
+// txCategoryLines returns the category attribution of tx. tx.Splits must have
+// been preloaded; a transaction without splits is a single line.
+func txCategoryLines(tx models.Transaction) []categoryLine {
+	if len(tx.Splits) == 0 {
+		return []categoryLine{{CategoryID: tx.CategoryID, Amount: tx.Amount}}
+	}
+	lines := make([]categoryLine, len(tx.Splits))
+	for i, s := range tx.Splits {
+		lines[i] = categoryLine{CategoryID: s.CategoryID, Amount: s.Amount}
+	}
+	return lines
+}
+
+// buildSplits validates split input against the parent amount and returns the
+// lines plus the category the parent should carry (the largest line's). The
+// error is safe to send to the client with the returned status.
+func buildSplits(uid uint, amount float64, in []splitInput) ([]models.TransactionSplit, uint, int, error) {
+	if len(in) < 2 {
+		return nil, 0, http.StatusBadRequest, errors.New("A split needs at least 2 lines")
+	}
+	if len(in) > maxSplitLines {
+		return nil, 0, http.StatusBadRequest, errors.New("A split can have at most " + strconv.Itoa(maxSplitLines) + " lines")
+	}
+
+	ids := make([]uint, 0, len(in))
+	var sumCents int64
+	for i, s := range in {
+		if s.CategoryID == 0 {
+			return nil, 0, http.StatusBadRequest, errors.New("Split line " + strconv.Itoa(i+1) + ": category_id is required")
+		}
+		if s.Amount <= 0 {
+			return nil, 0, http.StatusBadRequest, errors.New("Split line " + strconv.Itoa(i+1) + ": amount must be greater than zero")
+		}
+		if len(strings.TrimSpace(s.Description)) > 255 {
+			return nil, 0, http.StatusBadRequest, errors.New("Split line " + strconv.Itoa(i+1) + ": description must be 255 characters or fewer")
+		}
+		ids = append(ids, s.CategoryID)
+		sumCents += int64(math.Round(s.Amount * 100))
+	}
+	if sumCents != int64(math.Round(amount*100)) {
+		return nil, 0, http.StatusBadRequest, errors.New("Split amounts must add up to the transaction amount")
+	}
+
+	var owned int64
+	if err := database.DB.Model(&models.Category{}).
+		Where("user_id = ? AND id IN ?", uid, ids).Count(&owned).Error; err != nil {
+		return nil, 0, http.StatusInternalServerError, errors.New("Failed to verify categories")
+	}
+	distinct := map[uint]bool{}
+	for _, id := range ids {
+		distinct[id] = true
+	}
+	if int(owned) != len(distinct) {
+		return nil, 0, http.StatusNotFound, errors.New("Split category not found or does not belong to you")
+	}
+
+	now := time.Now()
+	splits := make([]models.TransactionSplit, len(in))
+	largest := 0
+	for i, s := range in {
+		splits[i] = models.TransactionSplit{
+			UserID:      uid,
+			CategoryID:  s.CategoryID,
+			Amount:      round2(s.Amount),
+			Description: strings.TrimSpace(s.Description),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if s.Amount > in[largest].Amount {
+			largest = i
+		}
+	}
+	return splits, in[largest].CategoryID, http.StatusOK, nil
+}
+
+// replaceSplits swaps parent's split lines for splits inside db (a DB
+// transaction). An empty splits slice turns the parent back into a plain
+// single-category transaction.
+func replaceSplits(db *gorm.DB, parent *models.Transaction, splits []models.TransactionSplit) error {
+	if err := db.Where("transaction_id = ?", parent.ID).Delete(&models.TransactionSplit{}).Error; err != nil {
+		return err
+	}
+	for i := range splits {
+		splits[i].TransactionID = parent.ID
+	}
+	if len(splits) > 0 {
+		if err := db.Create(&splits).Error; err != nil {
+			return err
+		}
+	}
+	parent.Splits = splits
+	return nil
+}
+
+// categoryLinesTable is a derived table with one row per category line of
+// every live transaction — split lines for split transactions, the
+// transaction itself otherwise — for SQL-side per-category aggregation.
+// Transfer legs are not spending and never appear; they are never split.
+// A refund is a negative expense line on its own date, carrying its
+// original's created_at so cycle windows net it against the original.
+const categoryLinesTable = `(
+	SELECT t.user_id, t.date, t.created_at, t.type, t.category_id, t.amount
+	FROM transactions t
+	WHERE t.deleted_at IS NULL
+	  AND t.type NOT IN ('transfer_out', 'transfer_in', 'refund')
+	  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+	UNION ALL
+	SELECT t.user_id, t.date, t.created_at, t.type, s.category_id, s.amount
+	FROM transaction_splits s
+	JOIN transactions t ON t.id = s.transaction_id
+	WHERE t.deleted_at IS NULL
+	UNION ALL
+	SELECT r.user_id, r.date, o.created_at, 'expense', r.category_id, -r.amount
+	FROM transactions r
+	JOIN transactions o ON o.id = r.refund_of_id
+	WHERE r.deleted_at IS NULL AND r.type = 'refund'
+) AS tx_lines`
+
+// expandSplitsForExport turns every split transaction into one row per line
+// (category and amount of the line, the parent's date/type/description), so
+// the PDF shows where the money actually went. Category must be preloaded on
+// both parents and lines.
+func expandSplitsForExport(txs []models.Transaction) []models.Transaction {
+	out := make([]models.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if len(tx.Splits) == 0 {
+			out = append(out, tx)
+			continue
+		}
+		for _, s := range tx.Splits {
+			row := tx
+			row.Splits = nil
+			row.CategoryID, row.Category, row.Amount = s.CategoryID, s.Category, s.Amount
+			if tx.FXRate != nil && *tx.FXRate > 0 {
+				// The line's share of what was paid in the foreign currency.
+				orig := round2(s.Amount / *tx.FXRate)
+				row.OriginalAmount = &orig
+			}
+			switch {
+			case s.Description != "" && tx.Description != "":
+				row.Description = tx.Description + " — " + s.Description
+			case s.Description != "":
+				row.Description = s.Description
+			}
+			out = append(out, row)
+		}
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+type splitFixture struct {
+	user               models.User
+	food, beauty, rent models.Category
+}
+
+func seedSplitUser(t *testing.T) splitFixture {
+	t.Helper()
+	setupFlowDB(t)
+	f := splitFixture{user: models.User{Username: "splitter", Password: "x"}}
+	database.DB.Create(&f.user)
+	for _, c := range []*models.Category{&f.food, &f.beauty, &f.rent} {
+		c.UserID = f.user.ID
+	}
+	f.food.Name, f.beauty.Name, f.rent.Name = "Food", "Beauty", "Rent"
+	database.DB.Create(&f.food)
+	database.DB.Create(&f.beauty)
+	database.DB.Create(&f.rent)
+	return f
+}
+
+func createSplitTx(t *testing.T, f splitFixture) models.Transaction {
+	t.Helper()
+	w := callHandler(f.user.ID, map[string]any{
+		"amount": 50, "date": "2026-03-10", "type": "expense", "description": "Supermarket",
+		"splits": []map[string]any{
+			{"category_id": f.food.ID, "amount": 35.5},
+			{"category_id": f.beauty.ID, "amount": 14.5, "description": "shampoo"},
+		},
+	}, CreateTransaction)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create split: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Transaction models.Transaction `json:"transaction"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Transaction
+}
+
+// The period summary attributes a split receipt to each line's category, and
+// the parent defaults to the largest line's category.
+func TestSplitTransaction_SummaryPerLine(t *testing.T) {
+	f := seedSplitUser(t)
+	tx := createSplitTx(t, f)
+	if tx.CategoryID != f.food.ID || len(tx.Splits) != 2 {
+		t.Fatalf("want parent in Food with 2 lines, got category %d / %d lines", tx.CategoryID, len(tx.Splits))
+	}
+
+	w := callHandlerGET(f.user.ID, "begin_date=2026-03-01&end_date=2026-03-31", GetPeriodSummary)
+	var resp struct {
+		Summary []struct {
+			Category    struct{ ID uint } `json:"category"`
+			TotalAmount float64           `json:"total_amount"`
+		} `json:"summary"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	got := map[uint]float64{}
+	for _, s := range resp.Summary {
+		got[s.Category.ID] = s.TotalAmount
+	}
+	if len(got) != 2 || got[f.food.ID] != 35.5 || got[f.beauty.ID] != 14.5 {
+		t.Errorf("want Food 35.5 / Beauty 14.5, got %v", got)
+	}
+
+	// A soft-deleted split transaction drops out of the summary entirely.
+	database.DB.Delete(&models.Transaction{}, tx.ID)
+	w = callHandlerGET(f.user.ID, "begin_date=2026-03-01&end_date=2026-03-31", GetPeriodSummary)
+	resp.Summary = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Summary) != 0 {
+		t.Errorf("deleted transaction still summarised: %+v", resp.Summary)
+	}
+}
+
+func TestSplitTransaction_Validation(t *testing.T) {
+	f := seedSplitUser(t)
+	foreign := models.Category{UserID: f.user.ID + 1, Name: "Not mine"}
+	database.DB.Create(&foreign)
+
+	for name, splits := range map[string][]map[string]any{
+		"sum mismatch":     {{"category_id": f.food.ID, "amount": 30}, {"category_id": f.beauty.ID, "amount": 10}},
+		"single line":      {{"category_id": f.food.ID, "amount": 50}},
+		"foreign category": {{"category_id": f.food.ID, "amount": 25}, {"category_id": foreign.ID, "amount": 25}},
+		"zero line":        {{"category_id": f.food.ID, "amount": 50}, {"category_id": f.beauty.ID, "amount": 0}},
+	} {
+		w := callHandler(f.user.ID, map[string]any{
+			"amount": 50, "date": "2026-03-10", "type": "expense", "splits": splits,
+		}, CreateTransaction)
+		if w.Code < 400 {
+			t.Errorf("%s: want an error, got %d", name, w.Code)
+		}
+	}
+	var n int64
+	database.DB.Model(&models.Transaction{}).Where("user_id = ?", f.user.ID).Count(&n)
+	if n != 0 {
+		t.Errorf("rejected splits must not leave a parent behind, found %d", n)
+	}
+}
+
+// Changing the amount requires re-sending matching lines; [] removes the split.
+func TestSplitTransaction_Update(t *testing.T) {
+	f := seedSplitUser(t)
+	tx := createSplitTx(t, f)
+	id := gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(tx.ID), 10)}}
+
+	if w := callHandlerParamBody(f.user.ID, id, map[string]any{"amount": 60}, UpdateTransaction); w.Code != http.StatusBadRequest {
+		t.Errorf("amount change without splits: want 400, got %d", w.Code)
+	}
+
+	w := callHandlerParamBody(f.user.ID, id, map[string]any{
+		"amount": 60,
+		"splits": []map[string]any{
+			{"category_id": f.food.ID, "amount": 20},
+			{"category_id": f.beauty.ID, "amount": 40},
+		},
+	}, UpdateTransaction)
+	if w.Code != http.StatusOK {
+		t.Fatalf("replace splits: %d %s", w.Code, w.Body.String())
+	}
+	var lines []models.TransactionSplit
+	database.DB.Where("transaction_id = ?", tx.ID).Find(&lines)
+	var parent models.Transaction
+	database.DB.First(&parent, tx.ID)
+	if len(lines) != 2 || parent.CategoryID != f.beauty.ID || parent.Amount != 60 {
+		t.Errorf("want 2 new lines and parent in Beauty at 60, got %d lines, cat %d, amount %.2f",
+			len(lines), parent.CategoryID, parent.Amount)
+	}
+
+	if w := callHandlerParamBody(f.user.ID, id, map[string]any{"splits": []any{}}, UpdateTransaction); w.Code != http.StatusOK {
+		t.Fatalf("remove splits: %d %s", w.Code, w.Body.String())
+	}
+	var n int64
+	database.DB.Model(&models.TransactionSplit{}).Where("transaction_id = ?", tx.ID).Count(&n)
+	if n != 0 {
+		t.Errorf("want no lines after removing the split, got %d", n)
+	}
+}
+
+// A receipt split between the fixed-payments category and a variable one is
+// attributed line by line in the cycle stats.
+func TestSplitTransaction_CycleStatsPerLine(t *testing.T) {
+	f := seedSplitUser(t)
+	start := time.Now().AddDate(0, 0, -3)
+	cycle := models.SalaryCycle{UserID: f.user.ID, TotalIncome: 1000, SavingsPct: 20, CycleStartAt: start, FixedExpCategoryID: f.rent.ID}
+	database.DB.Create(&cycle)
+
+	w := callHandler(f.user.ID, map[string]any{
+		"amount": 100, "date": time.Now().Format("2006-01-02"), "type": "expense",
+		"splits": []map[string]any{
+			{"category_id": f.rent.ID, "amount": 70},
+			{"category_id": f.food.ID, "amount": 30},
+		},
+	}, CreateTransaction)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+
+	stats := computeCycleStats(f.user.ID, cycle)
+	if stats.CycleExpenses != 100 || stats.CycleFixedExpenses != 70 || stats.CycleVariableExpenses != 30 {
+		t.Errorf("want 100 total / 70 fixed / 30 variable, got %.2f / %.2f / %.2f",
+			stats.CycleExpenses, stats.CycleFixedExpenses, stats.CycleVariableExpenses)
+	}
+	if stats.CurrentWeekSpent != 30 {
+		t.Errorf("only the variable line counts towards the week, got %.2f", stats.CurrentWeekSpent)
+	}
+}
+
+func TestExpandSplitsForExport(t *testing.T) {
+	txs := []models.Transaction{
+		{ID: 1, Amount: 5, Description: "Bus", Category: models.Category{Name: "Transport"}},
+		{ID: 2, Amount: 50, Description: "Supermarket", Splits: []models.TransactionSplit{
+			{Amount: 35.5, Category: models.Category{Name: "Food"}},
+			{Amount: 14.5, Category: models.Category{Name: "Beauty"}, Description: "shampoo"},
+		}},
+	}
+	rows := expandSplitsForExport(txs)
+	if len(rows) != 3 {
+		t.Fatalf("want 3 rows, got %d", len(rows))
+	}
+	if rows[1].Category.Name != "Food" || rows[1].Amount != 35.5 || rows[1].Description != "Supermarket" {
+		t.Errorf("first line: %+v", rows[1])
+	}
+	if rows[2].Description != "Supermarket — shampoo" {
+		t.Errorf("line note should be appended, got %q", rows[2].Description)
+	}
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// ── Trash: list / restore / purge soft-deleted transactions ─────────────────
+//
+// DeleteTransaction only soft-deletes (deleted_at is set), so a deleted row —
+// with its split lines and tags — stays recoverable until it is purged, either
+// explicitly or by the retention job after TRASH_RETENTION_DAYS.
+
+const (
+	defaultTrashRetentionDays = 30
+	trashPurgeInterval        = 6 * time.Hour
+)
+
+// trashRetentionDays reads TRASH_RETENTION_DAYS (default 30). 0 keeps trashed
+// rows forever; an invalid value falls back to the default.
+func trashRetentionDays() int {
+	raw := os.Getenv("TRASH_RETENTION_DAYS")
+	if raw == "" {
+		return defaultTrashRetentionDays
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		log.Printf("[trash] invalid TRASH_RETENTION_DAYS=%q — using %d", raw, defaultTrashRetentionDays)
+		return defaultTrashRetentionDays
+	}
+	return n
+}
+
+// trashedTransaction is a trash listing row: the transaction plus when it was
+// deleted and when the retention job will purge it (nil = never).
+type trashedTransaction struct {
+	models.Transaction
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// hardDeleteTransactions permanently removes the given transactions together
+// with their split lines and tag links. Runs inside the caller's DB transaction.
+func hardDeleteTransactions(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("transaction_id IN ?", ids).Delete(&models.TransactionSplit{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM transaction_tags WHERE transaction_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Transaction{}).Error
+}
+
+// GetTrash → GET /api/transactions/trash
+// Soft-deleted transactions, most recently deleted first.
+func GetTrash(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var txs []models.Transaction
+	if err := database.DB.Unscoped().Preload("Category").Preload("Splits.Category").Preload("Tags").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").Order("id desc").
+		Find(&txs).Error; err != nil {
+		log.Printf("get trash: user=%v err=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted transactions"})
+		return
+	}
+
+	retention := trashRetentionDays()
+	items := make([]trashedTransaction, 0, len(txs))
+	for _, tx := range txs {
+		item := trashedTransaction{Transaction: tx, DeletedAt: tx.DeletedAt.Time}
+		if retention > 0 {
+			purgeAt := tx.DeletedAt.Time.AddDate(0, 0, retention)
+			item.PurgeAt = &purgeAt
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": items, "retention_days": retention})
+}
+
+// RestoreTransaction → POST /api/transactions/:id/restore
+//
+// Brings a trashed transaction back. Its category (and every split line's
+// category) must still exist; if the main category is gone the client may
+// pass {"category_id": N} to restore it into another one.
+func RestoreTransaction(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID format"})
+		return
+	}
+
+	var input struct {
+		CategoryID *uint `json:"category_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var transaction models.Transaction
+	if err := database.DB.Unscoped().Preload("Splits").
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", uint(transactionID), uid).
+		First(&transaction).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found in trash"})
+		} else {
+			log.Printf("restore transaction: fetch user=%v tx=%v err=%v", uid, transactionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		}
+		return
+	}
+
+	categoryID := transaction.CategoryID
+	if input.CategoryID != nil {
+		categoryID = *input.CategoryID
+	}
+	catIDs := []uint{categoryID}
+	for _, s := range transaction.Splits {
+		catIDs = append(catIDs, s.CategoryID)
+	}
+	var cats []models.Category
+	if err := database.DB.Where("user_id = ? AND id IN ?", uid, catIDs).Find(&cats).Error; err != nil {
+		log.Printf("restore transaction: categories user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify category"})
+		return
+	}
+	have := make(map[uint]bool, len(cats))
+	for _, cat := range cats {
+		have[cat.ID] = true
+	}
+	if !have[categoryID] {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "The transaction's category no longer exists — pass category_id to restore it into another category",
+			"category_id": categoryID,
+		})
+		return
+	}
+	for _, s := range transaction.Splits {
+		if !have[s.CategoryID] {
+			c.JSON(http.StatusConflict, gin.H{"error": "A split line's category no longer exists", "category_id": s.CategoryID})
+			return
+		}
+	}
+
+	// A refund only comes back next to the expense it refunds.
+	if transaction.Type == txTypeRefund && transaction.RefundOfID != nil {
+		var n int64
+		database.DB.Model(&models.Transaction{}).Where("id = ? AND user_id = ?", *transaction.RefundOfID, uid).Count(&n)
+		if n == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "The refunded expense is not live — restore it first", "refund_of_id": *transaction.RefundOfID})
+			return
+		}
+	}
+
+	// Both legs of a transfer share the transfers category and come back together.
+	ids, err := withTransferPeers(database.DB, uid, []uint{transaction.ID})
+	if err != nil {
+		log.Printf("restore transaction: peers user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore transaction"})
+		return
+	}
+	if err := database.DB.Unscoped().Model(&models.Transaction{}).
+		Where("id IN ? AND user_id = ?", ids, uid).
+		Updates(map[string]any{"deleted_at": nil, "category_id": categoryID, "updated_at": time.Now()}).Error; err != nil {
+		log.Printf("restore transaction: user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore transaction"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	database.DB.Preload("Category").Preload("Splits.Category").Preload("Tags").First(&transaction, transaction.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction restored successfully", "transaction": transaction})
+}
+
+// PurgeTransaction → DELETE /api/transactions/:id/purge
+// Permanently deletes a transaction, trashed or not. There is no way back.
+func PurgeTransaction(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID format"})
+		return
+	}
+
+	var n int64
+	if err := database.DB.Unscoped().Model(&models.Transaction{}).
+		Where("id = ? AND user_id = ?", uint(transactionID), uid).Count(&n).Error; err != nil {
+		log.Printf("purge transaction: fetch user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge transaction"})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found or access denied"})
+		return
+	}
+
+	ids, err := purgeableSet(database.DB, uid, uint(transactionID))
+	if errors.Is(err, errReconciled) || errors.Is(err, errHasRefunds) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("purge transaction: checks user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge transaction"})
+		return
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return hardDeleteTransactions(tx, ids)
+	}); err != nil {
+		log.Printf("purge transaction: user=%v tx=%v err=%v", uid, transactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge transaction"})
+		return
+	}
+	InvalidateCycleCache(uid)
+	ScheduleBrainResync(uid)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction permanently deleted"})
+}
+
+// purgeableSet returns id together with its transfer peer, ready for
+// hardDeleteTransactions, or errReconciled / errHasRefunds when the set must
+// not be purged. Any other error is a database failure.
+func purgeableSet(db *gorm.DB, uid, id uint) ([]uint, error) {
+	ids, err := withTransferPeers(db, uid, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	if locked, err := anyReconciled(db, uid, ids); err != nil {
+		return nil, err
+	} else if locked {
+		return nil, errReconciled
+	}
+	if refunded, err := hasLiveRefunds(db, ids); err != nil {
+		return nil, err
+	} else if refunded {
+		return nil, errHasRefunds
+	}
+	return ids, nil
+}
+
+// purgeExpiredTrash hard-deletes every transaction trashed more than
+// retentionDays before now. Rows that PurgeTransaction would refuse, and
+// transfer legs whose peer is not expired too, are logged and kept. Returns
+// the number of rows removed.
+func purgeExpiredTrash(now time.Time, retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	var candidates []struct {
+		ID     uint
+		UserID uint
+	}
+	if err := database.DB.Unscoped().Model(&models.Transaction{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Select("id, user_id").Order("id").Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+	expired := make(map[uint]bool, len(candidates))
+	for _, cand := range candidates {
+		expired[cand.ID] = true
+	}
+
+	var ids []uint
+	seen := make(map[uint]bool, len(candidates))
+	for _, cand := range candidates {
+		if seen[cand.ID] {
+			continue
+		}
+		set, err := purgeableSet(database.DB, cand.UserID, cand.ID)
+		if errors.Is(err, errReconciled) || errors.Is(err, errHasRefunds) {
+			log.Printf("[trash] retention skipped user=%v tx=%v: %v", cand.UserID, cand.ID, err)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		keep := false
+		for _, id := range set {
+			if !expired[id] {
+				keep = true
+			}
+		}
+		if keep {
+			log.Printf("[trash] retention skipped user=%v tx=%v: transfer peer is not past retention", cand.UserID, cand.ID)
+			continue
+		}
+		for _, id := range set {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	// Chunked so one enormous backlog does not become one enormous statement.
+	for start := 0; start < len(ids); start += 500 {
+		chunk := ids[start:min(start+500, len(ids))]
+		if err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return hardDeleteTransactions(tx, chunk)
+		}); err != nil {
+			return start, err
+		}
+	}
+	return len(ids), nil
+}
+
+// StartTrashPurger runs the retention job every trashPurgeInterval. Trashed
+// rows are already excluded from every stat, so no cache invalidation is
+// needed when they disappear for good.
+func StartTrashPurger() {
+	go func() {
+		for {
+			if n, err := purgeExpiredTrash(time.Now(), trashRetentionDays()); err != nil {
+				log.Printf("[trash] retention purge failed after %d row(s): %v", n, err)
+			} else if n > 0 {
+				log.Printf("[trash] purged %d transaction(s) past retention", n)
+			}
+			time.Sleep(trashPurgeInterval)
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+func txParam(id uint) gin.Params {
+	return gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(id), 10)}}
+}
+
+// Delete → shows up in the trash → restore brings it back into the list.
+func TestTrash_DeleteListRestore(t *testing.T) {
+	f := seedSplitUser(t)
+	tx := createSplitTx(t, f)
+
+	if w := callHandlerParam(f.user.ID, txParam(tx.ID), DeleteTransaction); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	w := callHandlerGET(f.user.ID, "", GetTrash)
+	var trash struct {
+		Transactions []struct {
+			ID        uint       `json:"id"`
+			DeletedAt time.Time  `json:"deleted_at"`
+			PurgeAt   *time.Time `json:"purge_at"`
+			Splits    []models.TransactionSplit
+		} `json:"transactions"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Transactions) != 1 || trash.Transactions[0].ID != tx.ID || trash.Transactions[0].DeletedAt.IsZero() {
+		t.Fatalf("want the deleted row in the trash, got %s", w.Body.String())
+	}
+	if trash.Transactions[0].PurgeAt == nil || len(trash.Transactions[0].Splits) != 2 {
+		t.Errorf("trash row should carry purge_at and its split lines: %s", w.Body.String())
+	}
+
+	if w := callHandler(f.user.ID, nil, func(c *gin.Context) { c.Params = txParam(tx.ID); RestoreTransaction(c) }); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	if p := getTxPage(t, f.user.ID, nil); p.Total != 1 {
+		t.Errorf("restored transaction should be listed again, total=%d", p.Total)
+	}
+	if w := callHandler(f.user.ID, nil, func(c *gin.Context) { c.Params = txParam(tx.ID); RestoreTransaction(c) }); w.Code != http.StatusNotFound {
+		t.Errorf("restoring a live row: want 404, got %d", w.Code)
+	}
+}
+
+// Restoring into a category that was deleted meanwhile is refused until the
+// client names a replacement.
+func TestTrash_RestoreRevalidatesCategory(t *testing.T) {
+	setupFlowDB(t)
+	user := models.User{Username: "restorer", Password: "x"}
+	database.DB.Create(&user)
+	gone := models.Category{UserID: user.ID, Name: "Gone"}
+	kept := models.Category{UserID: user.ID, Name: "Kept"}
+	database.DB.Create(&gone)
+	database.DB.Create(&kept)
+	tx := models.Transaction{UserID: user.ID, CategoryID: gone.ID, Amount: 9, Date: time.Now(), Type: "expense", IncomeType: "one_time"}
+	database.DB.Create(&tx)
+	database.DB.Delete(&tx)
+	database.DB.Delete(&gone)
+
+	restore := func(body any) int {
+		return callHandler(user.ID, body, func(c *gin.Context) { c.Params = txParam(tx.ID); RestoreTransaction(c) }).Code
+	}
+	if code := restore(nil); code != http.StatusConflict {
+		t.Fatalf("missing category: want 409, got %d", code)
+	}
+	if code := restore(map[string]any{"category_id": kept.ID}); code != http.StatusOK {
+		t.Fatalf("restore into another category: want 200, got %d", code)
+	}
+	var got models.Transaction
+	database.DB.First(&got, tx.ID)
+	if got.CategoryID != kept.ID {
+		t.Errorf("want category %d, got %d", kept.ID, got.CategoryID)
+	}
+}
+
+// Purge and the retention job remove the row together with its split lines
+// and tag links; rows inside the retention window survive.
+func TestTrash_PurgeAndRetention(t *testing.T) {
+	f := seedSplitUser(t)
+	purged := createSplitTx(t, f)
+	old := createSplitTx(t, f)
+	recent := createSplitTx(t, f)
+
+	if w := callHandlerParam(f.user.ID, txParam(purged.ID), PurgeTransaction); w.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", w.Code, w.Body.String())
+	}
+
+	database.DB.Delete(&models.Transaction{}, old.ID)
+	database.DB.Delete(&models.Transaction{}, recent.ID)
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("id = ?", old.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -45))
+
+	n, err := purgeExpiredTrash(time.Now(), 30)
+	if err != nil || n != 1 {
+		t.Fatalf("retention: want 1 row purged, got %d (%v)", n, err)
+	}
+
+	var left []uint
+	database.DB.Unscoped().Model(&models.Transaction{}).Where("user_id = ?", f.user.ID).Pluck("id", &left)
+	if len(left) != 1 || left[0] != recent.ID {
+		t.Errorf("only the recently trashed row should remain, got %v", left)
+	}
+	var lines int64
+	database.DB.Model(&models.TransactionSplit{}).Where("transaction_id IN ?", []uint{purged.ID, old.ID}).Count(&lines)
+	if lines != 0 {
+		t.Errorf("purged rows left %d split lines behind", lines)
+	}
+}
+
+// The retention job applies PurgeTransaction's checks: a reconciled row, an
+// expense with a live refund and a transfer leg whose peer is still live are
+// kept, while an expired transfer goes with both legs.
+func TestTrash_RetentionSkipsProtectedRows(t *testing.T) {
+	f := seedSplitUser(t)
+	uid := f.user.ID
+	seed := func(typ, status string) models.Transaction {
+		tx := models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 10, Type: typ, Status: status, Date: utcDay(2026, 3, 1)}
+		database.DB.Create(&tx)
+		return tx
+	}
+	link := func(a, b models.Transaction) {
+		database.DB.Model(&models.Transaction{}).Where("id = ?", a.ID).Update("transfer_peer_id", b.ID)
+		database.DB.Model(&models.Transaction{}).Where("id = ?", b.ID).Update("transfer_peer_id", a.ID)
+	}
+	expire := func(txs ...models.Transaction) {
+		for _, tx := range txs {
+			database.DB.Delete(&models.Transaction{}, tx.ID)
+			database.DB.Unscoped().Model(&models.Transaction{}).Where("id = ?", tx.ID).
+				Update("deleted_at", time.Now().AddDate(0, 0, -45))
+		}
+	}
+
+	plain := seed("expense", "pending")
+	locked := seed("expense", txStatusReconciled)
+	refunded := seed("expense", "pending")
+	refundOf := refunded.ID
+	database.DB.Create(&models.Transaction{UserID: uid, CategoryID: f.food.ID, Amount: 4, Type: txTypeRefund, RefundOfID: &refundOf, Date: utcDay(2026, 3, 2)})
+	outA, inA := seed("expense", "pending"), seed("income", "pending")
+	link(outA, inA)
+	outB, inB := seed("expense", "pending"), seed("income", "pending")
+	link(outB, inB)
+	expire(plain, locked, refunded, outA, inA, outB)
+
+	n, err := purgeExpiredTrash(time.Now(), 30)
+	if err != nil || n != 3 {
+		t.Fatalf("retention: want 3 rows purged, got %d (%v)", n, err)
+	}
+	exists := func(id uint) bool {
+		var c int64
+		database.DB.Unscoped().Model(&models.Transaction{}).Where("id = ?", id).Count(&c)
+		return c == 1
+	}
+	for _, id := range []uint{plain.ID, outA.ID, inA.ID} {
+		if exists(id) {
+			t.Errorf("tx %d should have been purged", id)
+		}
+	}
+	for _, id := range []uint{locked.ID, refunded.ID, outB.ID, inB.ID} {
+		if !exists(id) {
+			t.Errorf("tx %d should have been kept", id)
+		}
+	}
+}
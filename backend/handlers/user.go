@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
@@ -109,6 +110,7 @@ func createDefaultCategories(userID uint, language string) {
 		cat := models.Category{
 			UserID: userID, Name: name,
 			TranslationKey: defaultCategoryKey(name), // built-in → follows UI language
+			Bucket:         defaultCategoryBucket(defaultCategoryKey(name)),
 			CreatedAt:      now, UpdatedAt: now,
 		}
 		if err := database.DB.Create(&cat).Error; err != nil {
@@ -185,12 +187,19 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", profileETag(user))
+	c.JSON(http.StatusOK, profileView(user))
+}
+
+// profileView is the GET /profile representation of user.
+func profileView(user models.User) gin.H {
 	currency := user.Currency
 	if currency == "" {
 		currency = "USD"
 	}
+	weekStart, anchorDay := budgetPrefsOf(user.WeekStart, user.BudgetAnchorDay)
 
-	c.JSON(http.StatusOK, gin.H{
+	return gin.H{
 		"id":                    user.ID,
 		"username":              user.Username,
 		"currency":              currency,
@@ -199,12 +208,16 @@ func GetProfile(c *gin.Context) {
 		"monthly_spending_goal": user.MonthlySpendingGoal,
 		"expected_salary":      user.ExpectedSalary,
 		"payday_mode":          user.PaydayMode,
+		"budget_mode":          user.BudgetMode,
+		"timezone":             user.Timezone,
+		"week_start":           weekdayNames[weekStart],
+		"budget_anchor_day":    anchorDayJSON(anchorDay),
 		"fixed_payday":         user.FixedPayday,
 		"manual_next_payday":   user.ManualNextPayday,
 		"hearts_count":         user.HeartsCount,
 		"reputation_score":     user.ReputationScore,
 		"lite_mode":            user.LiteMode,
-	})
+	}
 }
 
 func UpdateProfile(c *gin.Context) {
@@ -224,6 +237,12 @@ func UpdateProfile(c *gin.Context) {
 		FixedPayday         int     `json:"fixed_payday"`
 		ManualNextPayday    string  `json:"manual_next_payday"`
 		LiteMode            bool    `json:"lite_mode"`
+		// Optional: older clients send the profile without it, and that must
+		// not reset the zone.
+		Timezone *string `json:"timezone"`
+		// Optional, same reason: a weekday name and a day 1–28 or "last".
+		WeekStart       *string         `json:"week_start"`
+		BudgetAnchorDay json.RawMessage `json:"budget_anchor_day"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -256,12 +275,107 @@ func UpdateProfile(c *gin.Context) {
 		"manual_next_payday":   req.ManualNextPayday,
 		"lite_mode":            req.LiteMode,
 	}
-	if err := database.DB.Model(&models.User{}).Where("id = ?", userID.(uint)).Updates(updates).Error; err != nil {
+	if req.Timezone != nil {
+		if _, err := loadTimezone(*req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["timezone"] = strings.TrimSpace(*req.Timezone)
+	}
+	if req.WeekStart != nil {
+		day, ok := parseWeekday(*req.WeekStart)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid week_start. Use a weekday name such as monday"})
+			return
+		}
+		updates["week_start"] = int(day)
+	}
+	if len(req.BudgetAnchorDay) > 0 && string(req.BudgetAnchorDay) != "null" {
+		day, err := parseAnchorDay(req.BudgetAnchorDay)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["budget_anchor_day"] = day
+	}
+	// A new base currency re-converts every foreign-currency transaction;
+	// the switch is refused while any of them has no rate into it.
+	// Base-currency transactions keep their amounts.
+	uid := userID.(uint)
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.User
+		if err := database.DB.First(&current, uid).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if etag := profileETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, profileView(current))
+			return
+		}
+		seen = current.UpdatedAt
+	}
+	rebase := userBaseCurrency(database.DB, uid) != req.Currency
+	var missing []missingRate
+	var reconciled []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimIfMatch(c, tx, &models.User{}, uid, seen); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", uid).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !rebase {
+			return nil
+		}
+		m, r, err := reconvertForeignTransactions(tx, uid, req.Currency)
+		if err != nil {
+			return err
+		}
+		reconciled = r
+		if len(m) > 0 {
+			missing = m
+			return errFXMissing
+		}
+		return nil
+	})
+	if errors.Is(err, errFXMissing) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Some foreign-currency transactions have no rate into " + req.Currency,
+			"missing_rates": missing,
+		})
+		return
+	}
+	if errors.Is(err, errRefundCap) {
+		c.JSON(http.StatusConflict, gin.H{"error": errRefundCap.Error()})
+		return
+	}
+	if errors.Is(err, errStale) {
+		respondStale(c, "User not found", func() (string, gin.H, error) {
+			return currentProfile(uid)
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("update profile: user=%v err=%v", uid, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
+	if rebase {
+		InvalidateCycleCache(uid)
+		ScheduleBrainResync(uid)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated"})
+	var updated models.User
+	if err := database.DB.First(&updated, uid).Error; err == nil {
+		c.Header("ETag", profileETag(updated))
+	}
+	resp := gin.H{"message": "Profile updated"}
+	if rebase {
+		// Reconciled rows keep the amounts they were reconciled at.
+		resp["reconciled_unchanged"] = reconciled
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func DeleteAccount(c *gin.Context) {
@@ -271,23 +385,38 @@ func DeleteAccount(c *gin.Context) {
 		return
 	}
 	uid := userID.(uint)
+	var seen time.Time
+	if c.GetHeader("If-Match") != "" {
+		var current models.User
+		if err := database.DB.First(&current, uid).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if etag := profileETag(current); ifMatchFails(c, etag) {
+			preconditionFailed(c, etag, profileView(current))
+			return
+		}
+		seen = current.UpdatedAt
+	}
 
-	// Manual cascade: fixed_expenses → salary_cycles → transactions → categories → user
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.FixedExpense{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.SalaryCycle{}).Error; err != nil {
+		if err := claimIfMatch(c, tx, &models.User{}, uid, seen); err != nil {
 			return err
 		}
-		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.Transaction{}).Error; err != nil {
+		if err := deleteUserData(tx, uid); err != nil {
 			return err
 		}
-		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.Category{}).Error; err != nil {
+		if err := tx.Where("user_id = ?", uid).Delete(&models.IdempotencyKey{}).Error; err != nil {
 			return err
 		}
 		return tx.Unscoped().Delete(&models.User{}, uid).Error
 	})
+	if errors.Is(err, errStale) {
+		respondStale(c, "User not found", func() (string, gin.H, error) {
+			return currentProfile(uid)
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
 		return
@@ -297,6 +426,61 @@ func DeleteAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
 
+// deleteUserData removes the user's financial data — everything a full
+// archive holds — but keeps the user row and its settings.
+// Manual cascade: fixed_expenses → cycle audits → salary_cycles → recurring → splits → tags → transactions → budgets → rates → rules → reconciliations → accounts → categories
+func deleteUserData(tx *gorm.DB, uid uint) error {
+	if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.FixedExpense{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.SalaryCycleAudit{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.SalaryCycle{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.RecurringTransaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.TransactionSplit{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM transaction_tags WHERE tag_id IN (SELECT id FROM tags WHERE user_id = ?)", uid).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.Tag{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&models.Transaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.CategoryBudget{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.EnvelopeTransfer{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.Envelope{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.SavingsGoal{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.ExchangeRate{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.CategoryRule{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.Reconciliation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&models.Account{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("user_id = ?", uid).Delete(&models.Category{}).Error
+}
+
 // isDuplicateUsername detects unique constraint violations for both PostgreSQL (code 23505)
 // and SQLite ("UNIQUE constraint failed"), so raw DB errors are never sent to the client.
 func isDuplicateUsername(err error) bool {
@@ -5,6 +5,9 @@ import (
 	"os"
 	"strings"
 	"time"
+	// Embedded IANA zone database, so per-user time zones resolve even on a
+	// slim container image without /usr/share/zoneinfo.
+	_ "time/tzdata"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -25,8 +28,13 @@ func main() {
 	// One-time, idempotent: key existing users' exact-match default categories so
 	// they follow the UI language (never touches transactions or links).
 	handlers.MigrateDefaultCategoryKeys()
+	// Same for their 50/30/20 bucket; needs the keys stamped above.
+	handlers.MigrateDefaultCategoryBuckets()
 	go handlers.WarmUpBrain()
 	handlers.StartBrainRepoller()
+	handlers.StartRecurringScheduler()
+	handlers.StartTrashPurger()
+	middleware.StartIdempotencyPurger()
 
 	router := gin.Default()
 
@@ -34,8 +42,14 @@ func main() {
 	router.Use(middleware.SecurityHeaders())
 	// 512 KB body limit — generous for this API (no file uploads).
 	// The /ai/analyze endpoint can carry a few hundred transactions; 512 KB
-	// provides headroom while blocking oversized abuse payloads.
-	router.Use(middleware.MaxBodySize(512 * 1024))
+	// provides headroom while blocking oversized abuse payloads. Statement
+	// imports (up to maxImportRows rows) and a full account archive are the
+	// uploads that outgrow it.
+	router.Use(middleware.MaxBodySize(512*1024, map[string]int64{
+		"/api/transactions/import":     8 << 20,
+		"/api/transactions/import/ofx": 8 << 20,
+		"/api/import/full":             32 << 20,
+	}))
 
 	// ── CORS ─────────────────────────────────────────────────────────────────
 	allowOrigins := []string{"http://localhost:5173", "http://localhost"}
@@ -45,8 +59,8 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     allowOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Disposition"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -71,25 +85,81 @@ func main() {
 	// ── Protected routes ──────────────────────────────────────────────────────
 	protected := router.Group("/api")
 	protected.Use(middleware.AuthMiddleware())
+	// Retried POSTs carrying the same Idempotency-Key replay the first response.
+	protected.Use(middleware.Idempotency())
 	{
 		protected.POST("/categories", handlers.CreateCategory)
 		protected.GET("/categories", handlers.GetCategories)
+		protected.GET("/categories/:id", handlers.GetCategory)
 		protected.PUT("/categories/:id", handlers.UpdateCategory)
 		protected.DELETE("/categories/:id", handlers.DeleteCategory)
+		protected.POST("/categories/:id/merge-into/:target", handlers.MergeCategory)
 
 		protected.POST("/transactions", handlers.CreateTransaction)
+		protected.POST("/transactions/import", handlers.ImportTransactionsCSV)
+		protected.POST("/transactions/import/ofx", handlers.ImportTransactionsOFX)
 		protected.GET("/transactions", handlers.GetTransactions)
+		protected.GET("/transactions/trash", handlers.GetTrash)
+		protected.GET("/transactions/duplicates", handlers.GetDuplicates)
+		protected.POST("/transactions/merge", handlers.MergeTransactions)
+		protected.POST("/transactions/bulk", handlers.BulkTransactions)
+		protected.POST("/transactions/:id/restore", handlers.RestoreTransaction)
+		protected.DELETE("/transactions/:id/purge", handlers.PurgeTransaction)
 		protected.GET("/transactions/:id", handlers.GetTransactionByID)
 		protected.PUT("/transactions/:id", handlers.UpdateTransaction)
 		protected.DELETE("/transactions/:id", handlers.DeleteTransaction)
 
+		protected.POST("/tags", handlers.CreateTag)
+		protected.GET("/tags", handlers.GetTags)
+		protected.GET("/tags/:id", handlers.GetTag)
+		protected.PUT("/tags/:id", handlers.UpdateTag)
+		protected.DELETE("/tags/:id", handlers.DeleteTag)
+
+		protected.POST("/recurring", handlers.CreateRecurring)
+		protected.GET("/recurring", handlers.GetRecurring)
+		protected.GET("/recurring/:id", handlers.GetRecurringByID)
+		protected.PUT("/recurring/:id", handlers.UpdateRecurring)
+		protected.DELETE("/recurring/:id", handlers.DeleteRecurring)
+
 		protected.GET("/profile", handlers.GetProfile)
 		protected.PUT("/profile", handlers.UpdateProfile)
 		protected.DELETE("/user", handlers.DeleteAccount)
 
+		// Exchange rates for foreign-currency transactions
+		protected.GET("/fx/rates", handlers.GetExchangeRates)
+		protected.POST("/fx/rates", handlers.SetExchangeRate)
+		protected.POST("/fx/rates/import", handlers.ImportExchangeRates)
+		protected.DELETE("/fx/rates/:id", handlers.DeleteExchangeRate)
+
+		protected.GET("/accounts", handlers.GetAccounts)
+		protected.POST("/accounts", handlers.CreateAccount)
+		protected.GET("/accounts/balances", handlers.GetAccountBalances)
+		protected.POST("/accounts/transfers", handlers.CreateAccountTransfer)
+		protected.GET("/accounts/:id", handlers.GetAccount)
+		protected.PUT("/accounts/:id", handlers.UpdateAccount)
+		protected.DELETE("/accounts/:id", handlers.RemoveAccount)
+
+		protected.GET("/reconciliations", handlers.GetReconciliations)
+		protected.POST("/reconciliations", handlers.CreateReconciliation)
+		protected.GET("/reconciliations/:id", handlers.GetReconciliation)
+		protected.POST("/reconciliations/:id/clear", handlers.ClearReconciliationRows)
+		protected.POST("/reconciliations/:id/lock", handlers.LockReconciliation)
+		protected.POST("/reconciliations/:id/unlock", handlers.UnlockReconciliation)
+		protected.DELETE("/reconciliations/:id", handlers.DeleteReconciliation)
+
+		// Auto-categorization rules
+		protected.GET("/rules", handlers.GetRules)
+		protected.POST("/rules", handlers.CreateRule)
+		protected.POST("/rules/test", handlers.TestRule)
+		protected.POST("/rules/apply", handlers.ApplyRules)
+		protected.GET("/rules/:id", handlers.GetRule)
+		protected.PUT("/rules/:id", handlers.UpdateRule)
+		protected.DELETE("/rules/:id", handlers.DeleteRule)
+
 		protected.GET("/summary/daily", handlers.GetDailySummary)
 		protected.GET("/summary/period", handlers.GetPeriodSummary)
 		protected.GET("/stats", handlers.GetPeriodSummary)
+		protected.GET("/summary/tags", handlers.GetTagSummary)
 
 		// AI endpoints — per-user rate limit to protect the Python service.
 		// 20 calls per minute per user (burst of 5) is far more than any
@@ -111,6 +181,8 @@ func main() {
 		protected.GET("/ai/status", handlers.GetAIServiceStatus)
 
 		protected.GET("/transactions/export/pdf", handlers.ExportTransactionsPDF)
+		protected.GET("/export/full", handlers.ExportFullArchive)
+		protected.POST("/import/full", handlers.ImportFullArchive)
 
 		protected.POST("/salary-cycle", handlers.StartSalaryCycle)
 		protected.GET("/salary-cycle/current", handlers.GetCurrentSalaryCycle)
@@ -123,8 +195,31 @@ func main() {
 		protected.GET("/salary-cycle/savings-history", handlers.GetSavingsHistory)
 		protected.POST("/salary-cycle/savings", handlers.AddSavingsManual)
 
+		// Savings goals — earmarked parts of the savings pool
+		protected.GET("/savings-goals", handlers.GetSavingsGoals)
+		protected.POST("/savings-goals", handlers.CreateSavingsGoal)
+		protected.GET("/savings-goals/:id", handlers.GetSavingsGoal)
+		protected.PUT("/savings-goals/:id", handlers.UpdateSavingsGoal)
+		protected.DELETE("/savings-goals/:id", handlers.DeleteSavingsGoal)
+
 		// Server-authoritative monthly budget for users without a salary cycle.
 		protected.GET("/budget/current", handlers.GetCurrentBudget)
+		protected.POST("/budget/categories", handlers.CreateCategoryBudget)
+		protected.GET("/budget/categories", handlers.GetCategoryBudgets)
+		protected.GET("/budget/categories/:id", handlers.GetCategoryBudget)
+		protected.PUT("/budget/categories/:id", handlers.UpdateCategoryBudget)
+		protected.DELETE("/budget/categories/:id", handlers.DeleteCategoryBudget)
+
+		// Envelope (zero-based) budgeting
+		protected.GET("/envelopes", handlers.GetEnvelopes)
+		protected.POST("/envelopes", handlers.CreateEnvelope)
+		protected.PUT("/envelopes/mode", handlers.SetBudgetMode)
+		protected.POST("/envelopes/assign", handlers.AssignEnvelope)
+		protected.POST("/envelopes/move", handlers.MoveEnvelope)
+		protected.GET("/envelopes/transfers", handlers.GetEnvelopeTransfers)
+		protected.GET("/envelopes/:id", handlers.GetEnvelope)
+		protected.PUT("/envelopes/:id", handlers.UpdateEnvelope)
+		protected.DELETE("/envelopes/:id", handlers.DeleteEnvelope)
 	}
 
 	port := os.Getenv("PORT")
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+const (
+	// idempotencyTTL is how long a key and its response are kept; a retry
+	// after that runs the request again.
+	idempotencyTTL = 24 * time.Hour
+	// maxIdempotencyKeyLen matches the column width.
+	maxIdempotencyKeyLen = 255
+)
+
+// bodyRecorder tees everything the handler writes into body so the response
+// can be stored for replay.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyRequestHash identifies a request by method, URI and body, so a
+// key reused for anything else is told apart from a retry.
+func idempotencyRequestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Idempotency makes authenticated POSTs safe to retry. A POST carrying an
+// Idempotency-Key header is handled once per user and key: the response is
+// stored for 24 h and a retry with the same key and body gets it back (with
+// Idempotent-Replayed: true) instead of running the handler again. The same
+// key with a different body is refused with 422, and a retry that arrives
+// while the first request is still running gets 409.
+//
+// 5xx and 429 responses are not stored, so the client can retry them with the
+// same key. Must be placed after AuthMiddleware so "userID" is already in the
+// context; requests without the header pass straight through.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be 255 characters or fewer"})
+			return
+		}
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.Next()
+			return
+		}
+		uid := userID.(uint)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request payload too large"})
+			} else {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			}
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := idempotencyRequestHash(c.Request, body)
+
+		// An expired key is free for reuse. Claiming the key is a single
+		// insert, so of two concurrent retries only one runs the handler.
+		database.DB.Where("user_id = ? AND idempotency_key = ? AND created_at < ?", uid, key, time.Now().Add(-idempotencyTTL)).
+			Delete(&models.IdempotencyKey{})
+		entry := models.IdempotencyKey{UserID: uid, Key: key, RequestHash: hash}
+		res := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if res.Error != nil {
+			log.Printf("idempotency: claim user=%v err=%v", uid, res.Error)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			replayIdempotent(c, uid, key, hash)
+			return
+		}
+
+		rec := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		stored := false
+		defer func() {
+			// A panic or an uncached status releases the key for a retry.
+			if !stored {
+				database.DB.Delete(&models.IdempotencyKey{}, entry.ID)
+			}
+		}()
+		c.Next()
+
+		status := rec.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+		if err := database.DB.Model(&models.IdempotencyKey{}).Where("id = ?", entry.ID).Updates(map[string]any{
+			"status_code":   status,
+			"content_type":  rec.Header().Get("Content-Type"),
+			"response_body": rec.body.String(),
+		}).Error; err != nil {
+			log.Printf("idempotency: store user=%v err=%v", uid, err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken: with the
+// stored response when it is a retry of the same request, otherwise with the
+// reason it cannot run.
+func replayIdempotent(c *gin.Context, uid uint, key, hash string) {
+	var prior models.IdempotencyKey
+	if err := database.DB.Where("user_id = ? AND idempotency_key = ?", uid, key).First(&prior).Error; err != nil {
+		// Released between our insert and this read: the first request failed.
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress — retry shortly"})
+		return
+	}
+	switch {
+	case prior.RequestHash != hash:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "This Idempotency-Key was already used for a different request"})
+	case prior.StatusCode == 0:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress — retry shortly"})
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(prior.StatusCode, prior.ContentType, []byte(prior.ResponseBody))
+		c.Abort()
+	}
+}
+
+// StartIdempotencyPurger deletes keys past their TTL every hour to bound table
+// growth; an expired key that is reused before then is dropped on the spot.
+func StartIdempotencyPurger() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			res := database.DB.Where("created_at < ?", time.Now().Add(-idempotencyTTL)).Delete(&models.IdempotencyKey{})
+			if res.Error != nil {
+				log.Printf("idempotency: purge err=%v", res.Error)
+			} else if res.RowsAffected > 0 {
+				log.Printf("idempotency: purged %d expired key(s)", res.RowsAffected)
+			}
+		}
+	}()
+}
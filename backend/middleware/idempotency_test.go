@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/database"
+	"github.com/t0n1ks/go-react-angular-expense-tracker/backend/models"
+)
+
+// A retry replays the stored response without running the handler again; the
+// same key with another body is refused, and a 5xx leaves the key reusable.
+func TestIdempotency(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "idem.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, e := db.DB(); e == nil {
+			sqlDB.Close()
+		}
+	})
+	database.DB = db
+
+	gin.SetMode(gin.TestMode)
+	runs, fail := 0, false
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) }, Idempotency())
+	r.POST("/transactions", func(c *gin.Context) {
+		runs++
+		if fail {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"run": runs})
+	})
+	post := func(key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("k1", `{"amount":10}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: %d", first.Code)
+	}
+	again := post("k1", `{"amount":10}`)
+	if again.Code != http.StatusCreated || again.Body.String() != first.Body.String() || again.Header().Get("Idempotent-Replayed") != "true" || runs != 1 {
+		t.Errorf("a retry should replay: %d %s, %d run(s)", again.Code, again.Body.String(), runs)
+	}
+	if w := post("k1", `{"amount":11}`); w.Code != http.StatusUnprocessableEntity || runs != 1 {
+		t.Errorf("a reused key with another body: want 422, got %d", w.Code)
+	}
+	if post("", `{"amount":10}`); runs != 2 {
+		t.Errorf("without a key the handler always runs: %d run(s)", runs)
+	}
+
+	fail = true
+	if w := post("k2", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failing request: %d", w.Code)
+	}
+	fail = false
+	if w := post("k2", `{}`); w.Code != http.StatusCreated || runs != 4 {
+		t.Errorf("a 5xx must not be replayed: %d, %d run(s)", w.Code, runs)
+	}
+}
@@ -23,9 +23,14 @@ func SecurityHeaders() gin.HandlerFunc {
 // MaxBodySize rejects request bodies larger than maxBytes before any handler
 // reads them, preventing memory-exhaustion attacks via oversized payloads.
 // 64 KB is generous for this API (no file uploads; largest payload is the
-// AI analyze request with a few hundred transactions).
-func MaxBodySize(maxBytes int64) gin.HandlerFunc {
+// AI analyze request with a few hundred transactions). Routes in overrides,
+// keyed by their registered path, get their own cap instead.
+func MaxBodySize(maxBytes int64, overrides map[string]int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		maxBytes := maxBytes
+		if n, ok := overrides[c.FullPath()]; ok {
+			maxBytes = n
+		}
 		if c.Request.ContentLength > maxBytes {
 			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
 				"error": "Request payload too large",
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Account is where money actually sits: a wallet, a checking account, a
+// credit card. Every transaction belongs to one (Transaction.AccountID); the
+// balance is OpeningBalance plus the account's transactions, in the account's
+// Currency (empty = the user's base currency). Each user has exactly one
+// default account, which takes transactions recorded without an account.
+// Archived accounts keep their history but accept no new transactions.
+type Account struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_accounts_user_name"`
+	Name           string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_accounts_user_name"`
+	Type           string    `json:"type" gorm:"type:varchar(20);not null;default:'checking'"` // cash | checking | savings | credit_card | other
+	OpeningBalance float64   `json:"opening_balance" gorm:"type:numeric(12,2);not null;default:0"`
+	Currency       string    `json:"currency" gorm:"type:varchar(3);not null;default:''"`
+	Archived       bool      `json:"archived" gorm:"not null;default:false"`
+	IsDefault      bool      `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -11,8 +11,19 @@ type Category struct {
 	// they render in the current UI language. Empty for user-created categories
 	// (and cleared when a default is renamed — it becomes the user's own). The
 	// stored Name is kept unchanged for backend name-based lookups.
-	TranslationKey string    `json:"translation_key" gorm:"default:''"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	TranslationKey string `json:"translation_key" gorm:"default:''"`
+	// ParentID nests the category under another of the same user's categories
+	// ("Food > Restaurants"); nil for a top-level category. Depth is capped and
+	// cycles are rejected by the handlers.
+	ParentID *uint `json:"parent_id" gorm:"index"`
+	// Bucket places the category in the 50/30/20 split: need | want | savings |
+	// ignore. Empty means "not classified yet"; a sub-category with no bucket
+	// of its own inherits its parent's.
+	Bucket string `json:"bucket" gorm:"type:varchar(10);not null;default:''"`
+	// EnvelopeID is the envelope this category's spending draws down in
+	// envelope budgeting mode; nil inherits the parent's envelope.
+	EnvelopeID *uint     `json:"envelope_id" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 	// Transactions []Transaction `json:"-" gorm:"foreignKey:CategoryID"` // Опционально: если нужна обратная связь. Пока не используем
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CategoryBudget is a per-category spending limit for one budget window — the
+// calendar month for users without a salary cycle, the cycle window otherwise.
+// A budget on a parent category also covers its sub-categories.
+//
+// With Rollover set, whatever was left (or overspent) at the end of a window is
+// carried into the next one, starting from the window the budget was created in.
+type CategoryBudget struct {
+	ID         uint     `json:"id" gorm:"primaryKey"`
+	UserID     uint     `json:"user_id" gorm:"not null;uniqueIndex:idx_category_budgets_user_cat"`
+	CategoryID uint     `json:"category_id" gorm:"not null;uniqueIndex:idx_category_budgets_user_cat"`
+	Category   Category `json:"category"`
+	// "limit" is reserved in SQL, hence the column name.
+	Limit     float64   `json:"limit" gorm:"column:limit_amount;type:numeric(10,2);not null"`
+	Rollover  bool      `json:"rollover" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// CategoryRule auto-categorizes transactions: when every condition that is
+// set holds, its actions are applied. Rules are tried in ascending Priority
+// (ties by ID) and the first match wins.
+//
+// Conditions: MatchType ("" = any, expense, income); DescriptionContains
+// (case-insensitive substring); DescriptionRegex (RE2); MinAmount / MaxAmount
+// (inclusive; 0 = unbounded), compared with the amount as entered.
+//
+// Actions: CategoryID (0 = keep), TagList (comma-separated tag names to add)
+// and DescriptionRewrite — the new description, or with a regex the
+// replacement template ($1 etc.).
+type CategoryRule struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	UserID              uint      `json:"user_id" gorm:"not null;index"`
+	Name                string    `json:"name" gorm:"type:varchar(100);not null"`
+	Priority            int       `json:"priority" gorm:"not null;default:0"`
+	Paused              bool      `json:"paused" gorm:"not null;default:false"`
+	MatchType           string    `json:"match_type" gorm:"type:varchar(10);not null;default:''"`
+	DescriptionContains string    `json:"description_contains" gorm:"type:varchar(255);not null;default:''"`
+	DescriptionRegex    string    `json:"description_regex" gorm:"type:varchar(255);not null;default:''"`
+	MinAmount           float64   `json:"min_amount" gorm:"type:numeric(12,2);not null;default:0"`
+	MaxAmount           float64   `json:"max_amount" gorm:"type:numeric(12,2);not null;default:0"`
+	CategoryID          uint      `json:"category_id" gorm:"not null;default:0"`
+	TagList             string    `json:"-" gorm:"column:tags;type:varchar(1100);not null;default:''"`
+	DescriptionRewrite  string    `json:"description_rewrite" gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
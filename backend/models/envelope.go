@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Envelope is a named pot of money in envelope (zero-based) budgeting mode.
+// Categories point at the envelope their spending draws down (see
+// Category.EnvelopeID); its balance for a window is what was assigned to it in
+// that window minus what was spent from it.
+type Envelope struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_envelopes_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_envelopes_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EnvelopeTransfer is an append-only record of money moving between
+// envelopes. A nil side is the "to be assigned" pool: nil → envelope is an
+// assignment, envelope → nil hands money back, envelope → envelope is a move.
+// Rows are never updated or deleted, so every balance can be explained.
+type EnvelopeTransfer struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	FromEnvelopeID *uint     `json:"from_envelope_id"`
+	ToEnvelopeID   *uint     `json:"to_envelope_id"`
+	Amount         float64   `json:"amount" gorm:"type:numeric(10,2);not null"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ExchangeRate says that on Date one unit of Base is worth Rate units of
+// Quote. Rates are per user: imported from the ECB reference files (Base is
+// then always EUR) or entered by hand for any pair. Date is a calendar date
+// stored at UTC midnight, like Transaction.Date.
+type ExchangeRate struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_exchange_rates_user_day_pair"`
+	Date      time.Time `json:"date" gorm:"not null;uniqueIndex:idx_exchange_rates_user_day_pair"`
+	Base      string    `json:"base" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_user_day_pair"`
+	Quote     string    `json:"quote" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_user_day_pair"`
+	Rate      float64   `json:"rate" gorm:"not null"`
+	Source    string    `json:"source" gorm:"type:varchar(10);not null;default:'manual'"` // manual | ecb
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
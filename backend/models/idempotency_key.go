@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to one POST sent with an
+// Idempotency-Key header, so a retry of the same request gets that response
+// back instead of running the handler again. RequestHash covers the method,
+// path and body; StatusCode 0 marks a request that is still being handled.
+// Rows expire 24 h after CreatedAt.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key          string    `json:"key" gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	RequestHash  string    `json:"request_hash" gorm:"type:varchar(64);not null"`
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"`
+	ContentType  string    `json:"content_type" gorm:"type:varchar(100)"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Reconciliation is one pass of checking an account against a bank
+// statement: the statement's closing date and balance, and the transactions
+// ticked off as cleared while the session is open. Locking it requires the
+// cleared balance to match the statement; its cleared rows then become
+// "reconciled" and are read-only until the session is unlocked again.
+type Reconciliation struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	UserID           uint       `json:"user_id" gorm:"not null;index"`
+	AccountID        uint       `json:"account_id" gorm:"not null;index"`
+	StatementDate    time.Time  `json:"statement_date" gorm:"not null"`
+	StatementBalance float64    `json:"statement_balance" gorm:"type:numeric(12,2);not null"`
+	Status           string     `json:"status" gorm:"type:varchar(10);not null;default:'open'"` // open | locked
+	LockedAt         *time.Time `json:"locked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// RecurringTransaction is a template the scheduler turns into real
+// transactions (rent, subscriptions, allowances). Every posted instance carries
+// RecurringID = this row's ID and Date = the occurrence date; a unique index on
+// that pair guarantees an occurrence is materialized at most once.
+//
+// Schedule:
+//   - daily / weekly: every Interval days / weeks from StartDate;
+//   - monthly: every Interval months on DayOfMonth (clamped to the month's
+//     length, so 31 means "last day") or, with LastBusinessDay, on the last
+//     Monday–Friday of the month.
+//
+// The series ends after EndDate (inclusive) or after Count occurrences,
+// whichever comes first; 0 / nil means open-ended.
+type RecurringTransaction struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	CategoryID      uint       `json:"category_id" gorm:"not null"`
+	Category        Category   `json:"category"`
+	Amount          float64    `json:"amount" gorm:"type:numeric(10,2);not null"`
+	Description     string     `json:"description"`
+	Type            string     `json:"type" gorm:"type:varchar(30);not null;default:'expense'"` // expense | income
+	Frequency       string     `json:"frequency" gorm:"type:varchar(10);not null"`              // daily | weekly | monthly
+	Interval        int        `json:"interval" gorm:"not null;default:1"`
+	DayOfMonth      int        `json:"day_of_month" gorm:"not null;default:0"` // monthly only; 1–31
+	LastBusinessDay bool       `json:"last_business_day" gorm:"not null;default:false"`
+	StartDate       time.Time  `json:"start_date" gorm:"not null"`
+	EndDate         *time.Time `json:"end_date"`
+	Count           int        `json:"count" gorm:"not null;default:0"`
+	// Occurrences is how many instances have been posted so far.
+	Occurrences int `json:"occurrences" gorm:"not null;default:0"`
+	// NextDate is the next occurrence not yet posted; nil once the series has
+	// ended. The scheduler only ever moves it forward.
+	NextDate  *time.Time `json:"next_date" gorm:"index"`
+	Paused    bool       `json:"paused" gorm:"not null;default:false"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
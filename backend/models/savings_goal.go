@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SavingsGoal is a named target inside the savings pool ("Emergency fund",
+// 3000 by 2027-06). Savings deposits and withdrawals are earmarked to a goal
+// through Transaction.SavingsGoalID; the goal's balance is the net of those
+// transactions. Deadline is optional.
+type SavingsGoal struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_savings_goals_user_name"`
+	Name         string     `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_savings_goals_user_name"`
+	TargetAmount float64    `json:"target_amount" gorm:"type:numeric(10,2);not null"`
+	Deadline     *time.Time `json:"deadline"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
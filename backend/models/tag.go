@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Tag is a free-form, cross-cutting label ("vacation-2026", "business",
+// "reimbursable"). Unlike a category, a transaction can carry any number of
+// tags. Names are stored trimmed and lower-cased and are unique per user.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_tags_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
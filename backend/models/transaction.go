@@ -17,8 +17,51 @@ type Transaction struct {
 	Date        time.Time `json:"date"        gorm:"not null"`
 	Type        string    `json:"type"        gorm:"type:varchar(30);not null;default:'expense'"`
 	IncomeType  string    `json:"income_type" gorm:"type:varchar(20);not null;default:'one_time'"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// FITID is the bank's transaction id for rows imported from an OFX/QFX
+	// statement (empty for everything else). Re-importing an overlapping
+	// statement skips any FITID the account already has.
+	FITID string `json:"fitid,omitempty" gorm:"column:fitid;type:varchar(255);not null;default:''"`
+	// RecurringID links an instance posted by the recurring scheduler back to
+	// its template; nil for everything entered or imported by hand.
+	RecurringID *uint `json:"recurring_id,omitempty" gorm:"index"`
+	// SavingsGoalID earmarks a savings-pool deposit or withdrawal to one of
+	// the user's savings goals; nil for unallocated pool money and everything
+	// outside the pool.
+	SavingsGoalID *uint `json:"savings_goal_id,omitempty" gorm:"index"`
+	// AccountID is the account the money moved in or out of. Every write
+	// stamps it (automatic postings use the default account); nil only
+	// survives on rows written before accounts existed and means the user's
+	// current default account.
+	AccountID *uint `json:"account_id,omitempty" gorm:"index"`
+	// TransferPeerID links the two legs of a transfer between accounts: a
+	// "transfer_out" on the source and a "transfer_in" on the destination,
+	// each pointing at the other. Transfers are never income or expense.
+	TransferPeerID *uint `json:"transfer_peer_id,omitempty"`
+	// Status tracks the row against the bank: "pending" until it shows up on
+	// a statement, "cleared" once ticked off, "reconciled" when a locked
+	// reconciliation (ReconciliationID) covers it — reconciled rows are
+	// read-only until that reconciliation is unlocked.
+	Status           string `json:"status" gorm:"type:varchar(12);not null;default:'pending'"`
+	ReconciliationID *uint  `json:"reconciliation_id,omitempty" gorm:"index"`
+	// RefundOfID, on a "refund" row, is the expense it gives money back
+	// for. A refund is negative spend in that expense's category, never
+	// income; an expense may have several partial refunds up to its amount.
+	RefundOfID *uint `json:"refund_of_id,omitempty" gorm:"index"`
+	// Currency is the ISO code a foreign-currency transaction was made in;
+	// empty means the user's base currency. For foreign transactions
+	// OriginalAmount holds the amount as paid and Amount its base-currency
+	// value at FXRate, the rate on the transaction's Date — so every total
+	// that sums Amount is already in the base currency.
+	Currency       string   `json:"currency,omitempty" gorm:"type:varchar(3);not null;default:''"`
+	OriginalAmount *float64 `json:"original_amount,omitempty" gorm:"type:numeric(12,2)"`
+	FXRate         *float64 `json:"fx_rate,omitempty" gorm:"column:fx_rate"`
+	// Splits, when present, break Amount down across several categories; the
+	// parent CategoryID then names the largest line.
+	Splits []TransactionSplit `json:"splits,omitempty" gorm:"foreignKey:TransactionID"`
+	// Tags are cross-cutting labels, joined through transaction_tags.
+	Tags      []Tag     `json:"tags,omitempty" gorm:"many2many:transaction_tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 	// Soft-delete: GORM v2 automatically filters deleted_at IS NULL on all queries.
 	DeletedAt gorm.DeletedAt `json:"-"           gorm:"index"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TransactionSplit attributes part of a transaction to its own category — one
+// supermarket receipt that is partly "Food" and partly "Beauty". A split
+// transaction's lines always sum to the parent's Amount, and every
+// per-category report counts the lines instead of the parent's CategoryID.
+//
+// Lines belong to their parent for life: they are replaced wholesale on update
+// and are not soft-deleted themselves — a soft-deleted parent hides them.
+type TransactionSplit struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	TransactionID uint      `json:"transaction_id" gorm:"not null;index"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	CategoryID    uint      `json:"category_id" gorm:"not null;index"`
+	Category      Category  `json:"category"`
+	Amount        float64   `json:"amount" gorm:"type:numeric(10,2);not null"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -19,4 +19,16 @@ type User struct {
 	// LiteMode: opt-in "track-only" mode. Hides salary-cycle/analytics UI and
 	// suppresses Python analytics/forecast calls. Advisor (joke/fact) stays on.
 	LiteMode bool `gorm:"default:false" json:"lite_mode"`
+	// BudgetMode selects how spending is planned: "framework" (50/30/20
+	// needs/wants/savings limits) or "envelope" (zero-based envelopes).
+	BudgetMode string `gorm:"type:varchar(20);default:'framework'" json:"budget_mode"`
+	// Timezone is the IANA zone ("Europe/Berlin") every day, week, month and
+	// payday boundary is evaluated in. Empty means UTC.
+	Timezone string `gorm:"type:varchar(64);default:''" json:"timezone"`
+	// WeekStart is the first day of the user's week as a time.Weekday
+	// (0 = Sunday, 1 = Monday).
+	WeekStart int `gorm:"default:1" json:"week_start"`
+	// BudgetAnchorDay is the day of month the no-cycle budget window rolls
+	// over on: 1–28, or -1 for the last day of the month.
+	BudgetAnchorDay int `gorm:"default:1" json:"budget_anchor_day"`
 }